@@ -36,6 +36,8 @@ type Arguments struct {
 	ThriftOptions []string // options to pass through to thriftgo for go flag
 	ProtocOptions []string // options to pass through to protoc
 	GenBase       bool
+	Tracing       bool // generate the tracing spans of the repository methods and the OpenTelemetry adapter
+	Mock          bool // generate the mocks of the repository interfaces
 }
 
 func (a *Arguments) Unpack(args []string) error {
@@ -92,6 +92,9 @@ func (rs Returns) GetCode() string {
 }
 
 func getParamsCode(ps []Param) string {
+	if len(ps) == 0 {
+		return "()"
+	}
 	result := "("
 	for index, param := range ps {
 		if index != len(ps)-1 {
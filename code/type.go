@@ -38,7 +38,10 @@
 
 package code
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Type interface {
 	RealName() string
@@ -92,3 +95,28 @@ type StarExprType struct {
 func (set StarExprType) RealName() string {
 	return "*" + set.RealType.RealName()
 }
+
+type FuncType struct {
+	Params  []Type
+	Returns []Type
+}
+
+func (ft FuncType) RealName() string {
+	params := make([]string, 0, len(ft.Params))
+	for _, param := range ft.Params {
+		params = append(params, param.RealName())
+	}
+	returns := make([]string, 0, len(ft.Returns))
+	for _, result := range ft.Returns {
+		returns = append(returns, result.RealName())
+	}
+	name := "func(" + strings.Join(params, ", ") + ")"
+	switch len(returns) {
+	case 0:
+		return name
+	case 1:
+		return name + " " + returns[0]
+	default:
+		return name + " (" + strings.Join(returns, ", ") + ")"
+	}
+}
@@ -17,12 +17,17 @@
 package codegen
 
 import (
+	"strings"
+
 	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
 	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
 )
 
-func bulkCodegen(bulk *parse.BulkParse) []code.Statement {
-	return []code.Statement{
+func bulkCodegen(bulk *parse.BulkParse, routed bool, reserve sequenceReserver) []code.Statement {
+	stmts := append(bulkValidateCodegen(bulk, "return nil, err"), bulkPrepareCodegen(bulk, reserve, "return nil, err")...)
+	return append(stmts, []code.Statement{
 		code.DeclVarStmt{
 			Name: "models",
 			Type: code.SliceType{
@@ -32,33 +37,80 @@ func bulkCodegen(bulk *parse.BulkParse) []code.Statement {
 				},
 			},
 		},
-		bulkOperationsCodegen(bulk),
+		bulkOperationsCodegen(bulk, routed),
+		code.DeclColonStmt{
+			Left: code.ListCommaStmt{
+				code.RawStmt("result"),
+				code.RawStmt("err"),
+			},
+			Right: code.CallStmt{
+				Caller:   code.RawStmt("r.collection"),
+				CallName: "BulkWrite",
+				Args:     bulkWriteArgsCodegen(bulk, bulk.CtxParamName),
+			},
+		},
+		code.RawStmt("if err != nil {\n\treturn result, translateError(err)\n}"),
 		code.ReturnStmt{
 			ListCommaStmt: code.ListCommaStmt{
-				code.CallStmt{
-					Caller:   code.RawStmt("r.collection"),
-					CallName: "BulkWrite",
-					Args: code.ListCommaStmt{
-						code.RawStmt(bulk.CtxParamName),
-						code.RawStmt("models"),
-					},
-				},
+				code.RawStmt("result"),
+				code.RawStmt("nil"),
 			},
 		},
+	}...)
+}
+
+// bulkPrepareCodegen prepares the inserted and updated structures before the models are built,
+// reserve reserves the ids of the sequence in the bulk written collection, onError handles the err of the preparation.
+func bulkPrepareCodegen(bulk *parse.BulkParse, reserve sequenceReserver, onError string) []code.Statement {
+	stmts := make([]code.Statement, 0, 5)
+	for _, operation := range bulk.Operations {
+		if operation.GetOperationName() == parse.Insert {
+			insert := operation.(*parse.InsertParse)
+			stmts = append(stmts, insertPrepareCodegen(insert, insert.MethodParamNames[0], insert.MethodParamNames[0])...)
+			stmts = append(stmts, sequenceCodegen(insert, insert.MethodParamNames[0], reserve, onError)...)
+		}
+		if operation.GetOperationName() == parse.Update {
+			stmts = append(stmts, updatePrepareCodegen(operation.(*parse.UpdateParse))...)
+		}
+		if operation.GetOperationName() == parse.Replace {
+			stmts = append(stmts, replacePrepareCodegen(operation.(*parse.ReplaceParse))...)
+		}
+	}
+	return stmts
+}
+
+func bulkWriteArgsCodegen(bulk *parse.BulkParse, ctxName string) code.ListCommaStmt {
+	args := code.ListCommaStmt{
+		code.RawStmt(ctxName),
+		code.RawStmt("models"),
 	}
+	if bulk.Unordered {
+		chainCall := make(code.ChainStmt, 0, 5)
+		args = append(args, chainCall.ChainCall(code.Chain{
+			CallName: "options.BulkWrite",
+			Args:     code.ListCommaStmt{},
+		}).ChainCall(code.Chain{
+			CallName: "SetOrdered",
+			Args:     code.ListCommaStmt{code.RawStmt("false")},
+		}))
+	}
+	return args
 }
 
-func bulkOperationsCodegen(bulk *parse.BulkParse) code.SliceAppendsStmt {
+func bulkOperationsCodegen(bulk *parse.BulkParse, routed bool) code.SliceAppendsStmt {
 	operations := make([]code.SliceAppendStmt, 0, 10)
 	for _, operation := range bulk.Operations {
 		if operation.GetOperationName() == parse.Insert {
 			operations = append(operations, bulkInsertCodegen(operation.(*parse.InsertParse)))
 		}
 		if operation.GetOperationName() == parse.Update {
-			operations = append(operations, bulkUpdateCodegen(operation.(*parse.UpdateParse)))
+			operations = append(operations, bulkUpdateCodegen(operation.(*parse.UpdateParse), routed))
+		}
+		if operation.GetOperationName() == parse.Replace {
+			operations = append(operations, bulkReplaceCodegen(operation.(*parse.ReplaceParse), routed))
 		}
 		if operation.GetOperationName() == parse.Delete {
-			operations = append(operations, bulkDeleteCodegen(operation.(*parse.DeleteParse)))
+			operations = append(operations, bulkDeleteCodegen(operation.(*parse.DeleteParse), routed))
 		}
 	}
 	return operations
@@ -77,30 +129,61 @@ func bulkInsertCodegen(insert *parse.InsertParse) code.SliceAppendStmt {
 	}
 }
 
-func bulkUpdateCodegen(update *parse.UpdateParse) code.SliceAppendStmt {
+func bulkUpdateCodegen(update *parse.UpdateParse, routed bool) code.SliceAppendStmt {
 	if update.OperateMode == parse.OperateOne {
-		return getBulkUpdateCode(update, "mongo.NewUpdateOneModel().SetFilter")
+		return getBulkUpdateCode(update, "mongo.NewUpdateOneModel().SetFilter", routed)
 	} else {
-		return getBulkUpdateCode(update, "mongo.NewUpdateManyModel().SetFilter")
+		return getBulkUpdateCode(update, "mongo.NewUpdateManyModel().SetFilter", routed)
 	}
 }
 
-func bulkDeleteCodegen(delete *parse.DeleteParse) code.SliceAppendStmt {
+func bulkReplaceCodegen(replace *parse.ReplaceParse, routed bool) code.SliceAppendStmt {
+	chainCall := make(code.ChainStmt, 0, 5)
+	return code.SliceAppendStmt{
+		SliceName: "models",
+		AppendData: chainCall.ChainCall(code.Chain{
+			CallName: "mongo.NewReplaceOneModel().SetFilter",
+			Args: code.ListCommaStmt{
+				queryCodegen(replace.Query, routed),
+			},
+		}).ChainCall(code.Chain{
+			CallName: "SetReplacement",
+			Args: code.ListCommaStmt{
+				code.RawStmt(replace.ReplaceStructObjName),
+			},
+		}).ChainCall(code.Chain{
+			CallName: "SetUpsert",
+			Args: code.ListCommaStmt{
+				upsertCodegen(replace.Upsert),
+			},
+		}),
+	}
+}
+
+func bulkDeleteCodegen(delete *parse.DeleteParse, routed bool) code.SliceAppendStmt {
+	if update, ok := softDeleteCodegen(delete); ok {
+		if delete.OperateMode == parse.OperateOne {
+			return getBulkSoftDeleteCode(delete, update, "mongo.NewUpdateOneModel().SetFilter", routed)
+		} else {
+			return getBulkSoftDeleteCode(delete, update, "mongo.NewUpdateManyModel().SetFilter", routed)
+		}
+	}
+
 	if delete.OperateMode == parse.OperateOne {
-		return getBulkDeleteCode(delete, "mongo.NewDeleteOneModel().SetFilter")
+		return getBulkDeleteCode(delete, "mongo.NewDeleteOneModel().SetFilter", routed)
 	} else {
-		return getBulkDeleteCode(delete, "mongo.NewDeleteManyModel().SetFilter")
+		return getBulkDeleteCode(delete, "mongo.NewDeleteManyModel().SetFilter", routed)
 	}
 }
 
-func getBulkUpdateCode(update *parse.UpdateParse, callName string) code.SliceAppendStmt {
+func getBulkUpdateCode(update *parse.UpdateParse, callName string, routed bool) code.SliceAppendStmt {
 	chainCall := make(code.ChainStmt, 0, 5)
 	return code.SliceAppendStmt{
 		SliceName: "models",
 		AppendData: chainCall.ChainCall(code.Chain{
 			CallName: callName,
 			Args: code.ListCommaStmt{
-				queryCodegen(update.Query),
+				queryCodegen(update.Query, routed),
 			},
 		}).ChainCall(code.Chain{
 			CallName: "SetUpdate",
@@ -116,15 +199,147 @@ func getBulkUpdateCode(update *parse.UpdateParse, callName string) code.SliceApp
 	}
 }
 
-func getBulkDeleteCode(delete *parse.DeleteParse, callName string) code.SliceAppendStmt {
+func getBulkDeleteCode(delete *parse.DeleteParse, callName string, routed bool) code.SliceAppendStmt {
 	chainCall := make(code.ChainStmt, 0, 5)
 	return code.SliceAppendStmt{
 		SliceName: "models",
 		AppendData: chainCall.ChainCall(code.Chain{
 			CallName: callName,
 			Args: code.ListCommaStmt{
-				queryCodegen(delete.Query),
+				queryCodegen(delete.Query, routed),
 			},
 		}),
 	}
 }
+
+func getBulkSoftDeleteCode(delete *parse.DeleteParse, update code.MapStmt, callName string, routed bool) code.SliceAppendStmt {
+	chainCall := make(code.ChainStmt, 0, 5)
+	return code.SliceAppendStmt{
+		SliceName: "models",
+		AppendData: chainCall.ChainCall(code.Chain{
+			CallName: callName,
+			Args: code.ListCommaStmt{
+				queryCodegen(delete.Query, routed),
+			},
+		}).ChainCall(code.Chain{
+			CallName: "SetUpdate",
+			Args: code.ListCommaStmt{
+				update,
+			},
+		}),
+	}
+}
+
+// BulkMethod returns the repository method which starts the runtime bulk builder of the struct.
+func BulkMethod(st *extract.IdlExtractStruct) code.InterfaceMethod {
+	return code.InterfaceMethod{
+		Name:    "Bulk",
+		Params:  code.Params{},
+		Returns: code.Returns{code.StarExprType{RealType: code.IdentType(st.Name + "Bulk")}},
+	}
+}
+
+// memoryBulkCodegen returns the Bulk method of the in-memory repository, the operations can be added
+// but Exec returns mongox.ErrUnsupported since the bulk writes need the MongoDB server.
+func memoryBulkCodegen(st *extract.IdlExtractStruct) *template.MethodRender {
+	method := BulkMethod(st)
+	return &template.MethodRender{
+		Name:    method.Name,
+		Comment: "// Bulk starts a bulk write of the in-memory collection, the failed write leaves the collection unchanged.",
+		MethodReceiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{
+				RealType: code.IdentType(st.Name + "RepositoryMemory"),
+			},
+		},
+		Params:     method.Params,
+		Returns:    method.Returns,
+		MethodBody: code.Body{code.RawStmt("return &" + st.Name + "Bulk{collection: r.collection, ordered: true}")},
+	}
+}
+
+// GetBulkBuilderRender returns the render of the runtime bulk builder of the struct,
+// Exec records the result counts on the tracing span if tracing is true.
+func GetBulkBuilderRender(st *extract.IdlExtractStruct, tracing bool) *template.BulkBuilderRender {
+	render := &template.BulkBuilderRender{
+		StructName:    st.Name,
+		ModelType:     "*" + st.ModelPackage + "." + st.Name,
+		HasValidation: st.HasValidation(),
+		IDStrategy:    st.IDStrategy,
+		SequenceBatch: st.SequenceBatch,
+		ChunkSize:     st.ChunkSize,
+		ModelElem:     st.ModelPackage + "." + st.Name,
+		Cached:        len(st.CacheOptions) != 0,
+	}
+	for _, field := range st.StructFields {
+		if field.Validation != nil {
+			render.ValidatedFields = append(render.ValidatedFields, template.BulkValidatedField{
+				Name: field.Name,
+				Key:  field.Tag.Get("bson"),
+			})
+		}
+	}
+
+	idField := st.IDField
+	if idField == nil {
+		for _, field := range st.StructFields {
+			if field.Tag.Get("bson") == "_id" {
+				idField = field
+				break
+			}
+		}
+	}
+	if idField != nil {
+		render.IDField = idField.Name
+		render.IDType = idField.Type.RealName()
+		render.IDZero = zeroValue(render.IDType)
+		render.IDKey = idField.Tag.Get("bson")
+	}
+	if st.CreatedAtField != nil {
+		render.CreatedAtField = st.CreatedAtField.Name
+		render.CreatedAtKey = st.CreatedAtField.Tag.Get("bson")
+		render.TimestampNow = timestampNow(st.CreatedAtField)
+		render.CreatedAtUnset = strings.TrimSpace(timestampUnset("doc", st.CreatedAtField))
+	}
+	if st.UpdatedAtField != nil {
+		render.UpdatedAtField = st.UpdatedAtField.Name
+		render.UpdatedAtKey = st.UpdatedAtField.Tag.Get("bson")
+		render.TimestampNow = timestampNow(st.UpdatedAtField)
+	}
+	if st.SoftDeleteField != nil {
+		render.SoftDeleteKey = st.SoftDeleteField.Tag.Get("bson")
+		if st.SoftDeleteField.Type.RealName() == "bool" {
+			render.SoftDeleteValue = "true"
+			render.NotDeleted = `bson.M{"` + render.SoftDeleteKey + `": bson.M{"$ne": true}}`
+		} else {
+			render.SoftDeleteValue = nowMilli
+			render.NotDeleted = `bson.M{"` + render.SoftDeleteKey + `": bson.M{"$in": bson.A{nil, 0}}}`
+		}
+	}
+	if st.TenantField != nil {
+		render.TenantField = st.TenantField.Name
+		render.TenantKey = st.TenantField.Tag.Get("bson")
+	}
+	render.TenantResolver = TenantRouted(st)
+	render.Intercepted = Intercepted(st)
+	render.Tracing = tracing && render.Intercepted
+	return render
+}
+
+// BulkBuilderImports returns the imports of the bulk builder file except for the model package.
+func BulkBuilderImports(st *extract.IdlExtractStruct) map[string]string {
+	imports := map[string]string{
+		"context":                                   "",
+		"go.mongodb.org/mongo-driver/bson":          "",
+		"go.mongodb.org/mongo-driver/mongo":         "",
+		"go.mongodb.org/mongo-driver/mongo/options": "",
+	}
+	if st.CreatedAtField != nil || st.UpdatedAtField != nil ||
+		(st.SoftDeleteField != nil && st.SoftDeleteField.Type.RealName() == "int64") {
+		imports["time"] = ""
+	}
+	if st.CreatedAtField != nil || st.UpdatedAtField != nil || st.HasValidation() {
+		imports["fmt"] = ""
+	}
+	return imports
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import "testing"
+
+func TestBulkReplaceOne(t *testing.T) {
+	operations := parseIDL(t, `namespace go video
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: string Title (go.tag="bson:\"title\"")
+}
+(
+    mongo.BulkInsertOneReplaceOneUpsertByIdEqualReplaceOneByTitleEqual = "Bulk(ctx context.Context, v *video.Video, v2 *video.Video, id int64, v3 *video.Video, title string) (*mongo.BulkWriteResult, error)"
+)
+`)
+	checkCode(t, renderMethods(t, HandleCodegen(operations, false)[0]),
+		"models = append(models, mongo.NewInsertOneModel().SetDocument(v))",
+		"models = append(models, mongo.NewReplaceOneModel().SetFilter(bson.M{",
+		"}).SetReplacement(v2).SetUpsert(true))",
+		"}).SetReplacement(v3).SetUpsert(false))",
+	)
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// CacheImports are the imports of the cache file generated in the shared package.
+var CacheImports = map[string]string{
+	"container/list": "",
+	"encoding/json":  "",
+	"fmt":            "",
+	"reflect":        "",
+	"strings":        "",
+	"sync":           "",
+	"time":           "",
+}
+
+// CachedRepositoryImports are the imports of the cached repository, the imports of the params are added by the caller.
+var CachedRepositoryImports = map[string]string{
+	"context": "",
+}
+
+// CachedRepositoryParamImports are the packages which may be referenced by the cached repository,
+// they are imported if they are used.
+var CachedRepositoryParamImports = []string{
+	"time",
+	"go.mongodb.org/mongo-driver/bson",
+	"go.mongodb.org/mongo-driver/bson/primitive",
+	"go.mongodb.org/mongo-driver/mongo",
+	"go.mongodb.org/mongo-driver/mongo/options",
+}
+
+// the first tokens of the methods which write the collection
+var writeOperations = []string{"Insert", "Update", "Delete", "HardDelete", "Transaction", "Bulk"}
+
+// GetCachedMethodRenders returns the methods of the cached repository, the methods which are neither
+// cached nor writes are promoted from the embedded repository.
+func GetCachedMethodRenders(st *extract.IdlExtractStruct) []*template.MethodRender {
+	methods := make([]*template.MethodRender, 0, len(st.PreIfMethods)+len(st.InterfaceInfo.Methods))
+	for _, method := range append(st.PreIfMethods[:len(st.PreIfMethods):len(st.PreIfMethods)], st.InterfaceInfo.Methods...) {
+		if options, ok := st.CacheOptions[method.Name]; ok {
+			methods = append(methods, cachedFindCodegen(st, method, options))
+			continue
+		}
+		for _, operation := range writeOperations {
+			if strings.HasPrefix(method.ParsedTokens, operation) {
+				methods = append(methods, invalidateCodegen(st, method))
+				break
+			}
+		}
+	}
+	return methods
+}
+
+func cachedMethodRender(st *extract.IdlExtractStruct, method *extract.InterfaceMethod, body code.Body) *template.MethodRender {
+	return &template.MethodRender{
+		Name: method.Name,
+		MethodReceiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{
+				RealType: code.IdentType("Cached" + st.Name + "Repository"),
+			},
+		},
+		Params:     method.Params,
+		Returns:    method.Returns,
+		MethodBody: body,
+	}
+}
+
+// cacheCallCodegen returns the names of the params and the call of the embedded repository.
+func cacheCallCodegen(st *extract.IdlExtractStruct, method *extract.InterfaceMethod) ([]string, string) {
+	params := make([]string, 0, len(method.Params))
+	for _, param := range method.Params {
+		params = append(params, param.Name)
+	}
+	variadic := ""
+	if len(method.Params) != 0 && strings.HasPrefix(method.Params[len(method.Params)-1].Type.RealName(), "...") {
+		variadic = "..."
+	}
+	return params, "r." + st.Name + "Repository." + method.Name + "(" + strings.Join(params, ", ") + variadic + ")"
+}
+
+// cachedFindCodegen returns the Find method which reads the result from the cache,
+// the result of the embedded repository is cached if it succeeds.
+func cachedFindCodegen(st *extract.IdlExtractStruct, method *extract.InterfaceMethod,
+	options *extract.CacheOptions,
+) *template.MethodRender {
+	params, call := cacheCallCodegen(st, method)
+	ctxName := params[0]
+
+	// only the found documents of Find One are cached in the id scope, so the inserts need not invalidate it
+	scope := "mongox.CacheScope(" + strconv.Quote(st.Name) + ")"
+	if _, ok := method.Returns[0].(code.StarExprType); ok && method.CacheIDParamName != "" {
+		scope = "mongox.CacheIDScope(" + strconv.Quote(st.Name) + ", " + method.CacheIDParamName + ")"
+	}
+	ttl := "0"
+	if options.TTL != 0 {
+		ttl = strconv.FormatInt(options.TTL.Milliseconds(), 10) + " * time.Millisecond"
+	}
+
+	body := code.Body{
+		code.IfBlockStmt{
+			Condition: []code.Statement{code.RawStmt("mongox.InTransaction(" + ctxName + ") ")},
+			Body:      code.Body{code.RawStmt("return " + call)},
+		},
+	}
+	args := []string{scope, strconv.Quote(method.Name)}
+	if TenantScoped(st) {
+		// the results of the tenants are cached by the keys of the tenants
+		body = append(body, code.RawStmt("cacheTenant, _ := mongox.TenantFromContext("+ctxName+")"))
+		args = append(args, "cacheTenant")
+	}
+	args = append(args, params[1:]...)
+	return cachedMethodRender(st, method, append(body,
+		code.RawStmt("key := mongox.CacheKey("+strings.Join(args, ", ")+")"),
+		code.RawStmt("if value, ok := r.cache.Get(key); ok {\n\treturn value.("+method.Returns[0].RealName()+"), nil\n}"),
+		code.RawStmt("result, err := "+call),
+		code.RawStmt("if err != nil {\n\treturn result, err\n}"),
+		code.RawStmt("r.cache.Set(key, result, "+ttl+")"),
+		code.RawStmt("return result, nil"),
+	))
+}
+
+// invalidateCodegen returns the write method which invalidates the cached results after the write, the write
+// of one document by the id keeps the cached results of the other documents. The write in a mongox.UnitOfWork
+// invalidates them after the commit, otherwise the results read before the commit would be cached again.
+func invalidateCodegen(st *extract.IdlExtractStruct, method *extract.InterfaceMethod) *template.MethodRender {
+	params, call := cacheCallCodegen(st, method)
+
+	results := make([]string, 0, len(method.Returns))
+	for i := range method.Returns {
+		results = append(results, "result"+strconv.Itoa(i))
+	}
+
+	var invalidate []code.Statement
+	switch {
+	case method.CacheIDParamName != "":
+		invalidate = []code.Statement{
+			code.RawStmt("r.cache.DeletePrefix(mongox.CacheIDScope(" + strconv.Quote(st.Name) + ", " + method.CacheIDParamName + "))"),
+			code.RawStmt("r.cache.DeletePrefix(mongox.CacheScope(" + strconv.Quote(st.Name) + "))"),
+		}
+	case strings.HasPrefix(method.ParsedTokens, "Insert"):
+		invalidate = []code.Statement{
+			code.RawStmt("r.cache.DeletePrefix(mongox.CacheScope(" + strconv.Quote(st.Name) + "))"),
+		}
+	default:
+		invalidate = []code.Statement{
+			code.RawStmt("r.cache.DeletePrefix(mongox.CacheRepositoryScope(" + strconv.Quote(st.Name) + "))"),
+		}
+	}
+
+	if len(method.Params) != 0 && method.Params[0].Type.RealName() == "context.Context" {
+		hook := make([]string, 0, len(invalidate))
+		for _, stmt := range invalidate {
+			hook = append(hook, "\t"+stmt.Code())
+		}
+		invalidate = []code.Statement{
+			code.RawStmt("mongox.AfterCommit(" + params[0] + ", func() {\n" + strings.Join(hook, "\n") + "\n})"),
+		}
+	}
+
+	if len(results) == 0 {
+		return cachedMethodRender(st, method, append(code.Body{code.RawStmt(call)}, invalidate...))
+	}
+	body := code.Body{code.RawStmt(strings.Join(results, ", ") + " := " + call)}
+	body = append(body, invalidate...)
+	body = append(body, code.RawStmt("return "+strings.Join(results, ", ")))
+	return cachedMethodRender(st, method, body)
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"testing"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+func TestInvalidateCodegen(t *testing.T) {
+	operations := parseIDL(t, `namespace go video
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: string Title (go.tag="bson:\"title\"")
+}
+(
+    mongo.cache = "FindById"
+    mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"
+    mongo.UpdateTitleByIdEqual = "UpdateTitle(ctx context.Context, title string, id int64) (bool, error)"
+    mongo.InsertOne = "InsertOne(ctx context.Context, v *video.Video) (interface{}, error)"
+)
+`)
+	methods := GetCachedMethodRenders(operations[0].BelongedToStruct)
+	checkCode(t, renderMethods(t, []*template.MethodRender{findMethod(t, methods, "UpdateTitle")}),
+		"result0, result1 := r.VideoRepository.UpdateTitle(ctx, title, id)",
+		"mongox.AfterCommit(ctx, func() {",
+		`r.cache.DeletePrefix(mongox.CacheIDScope("Video", id))`,
+		`r.cache.DeletePrefix(mongox.CacheScope("Video"))`,
+		"})",
+		"return result0, result1")
+	checkCode(t, renderMethods(t, []*template.MethodRender{findMethod(t, methods, "InsertOne")}),
+		"mongox.AfterCommit(ctx, func() {",
+		`r.cache.DeletePrefix(mongox.CacheScope("Video"))`)
+}
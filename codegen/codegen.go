@@ -32,10 +32,15 @@ import (
 	"golang.org/x/tools/go/ast/astutil"
 )
 
-func HandleCodegen(ifOperations []*parse.InterfaceOperation) (methodRenders [][]*template.MethodRender) {
+// HandleCodegen returns the methods of the repositories, the update and delete methods record their
+// counts on the tracing spans if tracing is true.
+func HandleCodegen(ifOperations []*parse.InterfaceOperation, tracing bool) (methodRenders [][]*template.MethodRender) {
 	for _, ifOperation := range ifOperations {
 		methods := make([]*template.MethodRender, 0)
+		intercepted := Intercepted(ifOperation.BelongedToStruct)
+		routed := tenantRoutedFlag(ifOperation.BelongedToStruct)
 		for _, operation := range ifOperation.Operations {
+			count := len(methods)
 			switch operation.GetOperationName() {
 			case parse.Insert:
 				insert := operation.(*parse.InsertParse)
@@ -49,7 +54,7 @@ func HandleCodegen(ifOperations []*parse.InterfaceOperation) (methodRenders [][]
 					},
 					Params:     insert.BelongedToMethod.Params,
 					Returns:    insert.BelongedToMethod.Returns,
-					MethodBody: insertCodegen(insert),
+					MethodBody: insertCodegen(insert, collectionSequence("r.collection")),
 				}
 				methods = append(methods, method)
 
@@ -65,7 +70,7 @@ func HandleCodegen(ifOperations []*parse.InterfaceOperation) (methodRenders [][]
 					},
 					Params:     find.BelongedToMethod.Params,
 					Returns:    find.BelongedToMethod.Returns,
-					MethodBody: findCodegen(find),
+					MethodBody: findCodegen(find, routed),
 				}
 				methods = append(methods, method)
 
@@ -81,7 +86,7 @@ func HandleCodegen(ifOperations []*parse.InterfaceOperation) (methodRenders [][]
 					},
 					Params:     update.BelongedToMethod.Params,
 					Returns:    update.BelongedToMethod.Returns,
-					MethodBody: updateCodegen(update),
+					MethodBody: updateCodegen(update, routed),
 				}
 				methods = append(methods, method)
 
@@ -97,7 +102,7 @@ func HandleCodegen(ifOperations []*parse.InterfaceOperation) (methodRenders [][]
 					},
 					Params:     del.BelongedToMethod.Params,
 					Returns:    del.BelongedToMethod.Returns,
-					MethodBody: deleteCodegen(del),
+					MethodBody: deleteCodegen(del, routed),
 				}
 				methods = append(methods, method)
 
@@ -113,7 +118,7 @@ func HandleCodegen(ifOperations []*parse.InterfaceOperation) (methodRenders [][]
 					},
 					Params:     count.BelongedToMethod.Params,
 					Returns:    count.BelongedToMethod.Returns,
-					MethodBody: countCodegen(count),
+					MethodBody: countCodegen(count, routed),
 				}
 				methods = append(methods, method)
 
@@ -129,7 +134,7 @@ func HandleCodegen(ifOperations []*parse.InterfaceOperation) (methodRenders [][]
 					},
 					Params:     bulk.BelongedToMethod.Params,
 					Returns:    bulk.BelongedToMethod.Returns,
-					MethodBody: bulkCodegen(bulk),
+					MethodBody: bulkCodegen(bulk, routed, collectionSequence("r.collection")),
 				}
 				methods = append(methods, method)
 
@@ -145,12 +150,37 @@ func HandleCodegen(ifOperations []*parse.InterfaceOperation) (methodRenders [][]
 					},
 					Params:     ta.BelongedToMethod.Params,
 					Returns:    ta.BelongedToMethod.Returns,
-					MethodBody: taCodegen(ta),
+					MethodBody: taCodegen(ta, routed),
+				}
+				methods = append(methods, method)
+
+			case parse.Watch:
+				watch := operation.(*parse.WatchParse)
+				method := &template.MethodRender{
+					Name: watch.BelongedToMethod.Name,
+					MethodReceiver: code.MethodReceiver{
+						Name: "r",
+						Type: code.StarExprType{
+							RealType: code.IdentType(ifOperation.BelongedToStruct.Name + "RepositoryMongo"),
+						},
+					},
+					Params:     watch.BelongedToMethod.Params,
+					Returns:    watch.BelongedToMethod.Returns,
+					MethodBody: watchCodegen(watch, routed),
 				}
 				methods = append(methods, method)
 
 			default:
 			}
+			if len(methods) > count {
+				tenantCheckCodegen(ifOperation.BelongedToStruct, methods[count])
+			}
+			if intercepted && len(methods) > count {
+				if tracing {
+					traceCountsCodegen(operation, methods[count])
+				}
+				methods = append(methods[:count], interceptCodegen(ifOperation.BelongedToStruct, operation, methods[count])...)
+			}
 		}
 		methodRenders = append(methodRenders, methods)
 	}
@@ -161,6 +191,15 @@ var BaseMongoImports = map[string]string{
 	"context": "",
 }
 
+// ErrorsImports are the imports of the errors file generated with every repository.
+var ErrorsImports = map[string]string{
+	"errors":                            "",
+	"fmt":                               "",
+	"regexp":                            "",
+	"strings":                           "",
+	"go.mongodb.org/mongo-driver/mongo": "",
+}
+
 func AddMongoImports(data string) (string, error) {
 	fSet := token.NewFileSet()
 	file, err := parser.ParseFile(fSet, "", data, parser.ParseComments)
@@ -168,7 +207,7 @@ func AddMongoImports(data string) (string, error) {
 		return "", err
 	}
 
-	flagBson, flagMongo, flagOption := false, false, false
+	flagBson, flagMongo, flagOption, flagTime := false, false, false, false
 	ast.Inspect(file, func(n ast.Node) bool {
 		if importSpec, ok := n.(*ast.ImportSpec); ok && importSpec.Path.Value == "go.mongodb.org/mongo-driver/bson" {
 			flagBson = true
@@ -182,6 +221,10 @@ func AddMongoImports(data string) (string, error) {
 			flagOption = true
 			return false
 		}
+		if importSpec, ok := n.(*ast.ImportSpec); ok && importSpec.Path.Value == "time" {
+			flagTime = true
+			return false
+		}
 		return true
 	})
 
@@ -200,6 +243,18 @@ func AddMongoImports(data string) (string, error) {
 			astutil.AddNamedImport(fSet, file, "", "go.mongodb.org/mongo-driver/mongo/options")
 		}
 	}
+	if strings.Contains(data, "time.Now()") || strings.Contains(data, "time.Duration") ||
+		strings.Contains(data, "time.Millisecond") {
+		if !flagTime {
+			astutil.AddNamedImport(fSet, file, "", "time")
+		}
+	}
+	// the packages of the transaction options
+	for _, pkg := range []string{"readconcern", "writeconcern", "readpref"} {
+		if strings.Contains(data, pkg+".") {
+			astutil.AddImport(fSet, file, "go.mongodb.org/mongo-driver/mongo/"+pkg)
+		}
+	}
 
 	buf := new(bytes.Buffer)
 	if err = printer.Fprint(buf, fSet, file); err != nil {
@@ -209,8 +264,11 @@ func AddMongoImports(data string) (string, error) {
 	return buf.String(), nil
 }
 
-func GetFuncRender(extractStruct *extract.IdlExtractStruct) *template.FuncRender {
-	return &template.FuncRender{
+// GetFuncRender returns the constructor of the repository, the constructor of the intercepted
+// repository accepts the functional options which register the interceptors, and returns the
+// cached repository if the cache option is set.
+func GetFuncRender(extractStruct *extract.IdlExtractStruct, intercepted bool) *template.FuncRender {
+	fr := &template.FuncRender{
 		Name: "New" + extractStruct.Name + "Repository",
 		Params: code.Params{
 			code.Param{
@@ -230,10 +288,26 @@ func GetFuncRender(extractStruct *extract.IdlExtractStruct) *template.FuncRender
 			code.RawStmt("return &" + extractStruct.Name + "RepositoryMongo{\n\tcollection: collection,\n}"),
 		},
 	}
+	if intercepted {
+		fr.Params = append(fr.Params, code.Param{
+			Name: "opts",
+			Type: code.IdentType("..." + extractStruct.Name + "RepositoryOption"),
+		})
+		fr.FuncBody = code.Body{
+			code.RawStmt("r := &" + extractStruct.Name + "RepositoryMongo{\n\tcollection: collection,\n}"),
+			code.RawStmt("for _, opt := range opts {\n\topt(r)\n}"),
+		}
+		if len(extractStruct.CacheOptions) != 0 {
+			fr.FuncBody = append(fr.FuncBody, code.RawStmt("if r.cache != nil {\n\treturn NewCached"+
+				extractStruct.Name+"Repository(r, r.cache)\n}"))
+		}
+		fr.FuncBody = append(fr.FuncBody, code.RawStmt("return r"))
+	}
+	return fr
 }
 
-func GetStructRender(extractStruct *extract.IdlExtractStruct) *template.StructRender {
-	return &template.StructRender{
+func GetStructRender(extractStruct *extract.IdlExtractStruct, intercepted bool) *template.StructRender {
+	sr := &template.StructRender{
 		Name: extractStruct.Name + "RepositoryMongo",
 		StructFields: code.StructFields{
 			code.StructField{
@@ -247,4 +321,37 @@ func GetStructRender(extractStruct *extract.IdlExtractStruct) *template.StructRe
 			},
 		},
 	}
+	if intercepted {
+		sr.StructFields = append(sr.StructFields, code.StructField{
+			Name: "interceptors",
+			Type: code.SliceType{
+				ElementType: code.SelectorExprType{
+					X:   extract.MongoxPkgName,
+					Sel: "Interceptor",
+				},
+			},
+		})
+	}
+	if intercepted && len(extractStruct.CacheOptions) != 0 {
+		sr.StructFields = append(sr.StructFields, code.StructField{
+			Name: "cache",
+			Type: code.SelectorExprType{
+				X:   extract.MongoxPkgName,
+				Sel: "Cache",
+			},
+		})
+	}
+	if TenantRouted(extractStruct) {
+		sr.StructFields = append(sr.StructFields, code.StructField{
+			Name: "tenantResolver",
+			Type: code.SelectorExprType{
+				X:   extract.MongoxPkgName,
+				Sel: "TenantResolver",
+			},
+		}, code.StructField{
+			Name: "tenantRouted",
+			Type: code.IdentType("bool"),
+		})
+	}
+	return sr
 }
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/cloudwego/thriftgo/plugin"
+	"github.com/hertz-contrib/thrift-gen-mongo/args"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// parseIDL extracts and parses the structs of the IDL.
+func parseIDL(t *testing.T, idl string) []*parse.InterfaceOperation {
+	t.Helper()
+	ast, err := parser.ParseString("video.thrift", idl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := &extract.ThriftMeta{
+		Req: &plugin.Request{AST: ast},
+		Args: &args.Arguments{
+			PackagePrefix: "test/biz/model",
+			ModelDir:      "biz/model",
+			DaoDir:        "biz/dao",
+		},
+		ImportPaths: make([]string, 0, 10),
+	}
+	structs, err := meta.ParseThriftIdl()
+	if err != nil {
+		t.Fatal(err)
+	}
+	operations, err := parse.HandleOperations(structs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return operations
+}
+
+// renderMethods returns the formatted code of the methods.
+func renderMethods(t *testing.T, methods []*template.MethodRender) string {
+	t.Helper()
+	buffer := bytes.NewBufferString("package video\n")
+	for _, method := range methods {
+		if err := method.RenderObj(buffer); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source, err := format.Source(buffer.Bytes())
+	if err != nil {
+		t.Fatalf("%s\n%s", err, buffer.String())
+	}
+	return string(source)
+}
+
+// checkCode checks that the code contains every line of want, the lines are compared without the indents.
+func checkCode(t *testing.T, source string, want ...string) {
+	t.Helper()
+	lines := map[string]bool{}
+	for _, line := range strings.Split(source, "\n") {
+		lines[strings.TrimSpace(line)] = true
+	}
+	for _, line := range want {
+		if !lines[line] {
+			t.Errorf("missing %q in\n%s", line, source)
+		}
+	}
+}
+
+// checkNoCode checks that the code contains none of the lines.
+func checkNoCode(t *testing.T, source string, lines ...string) {
+	t.Helper()
+	for _, line := range strings.Split(source, "\n") {
+		for _, unwanted := range lines {
+			if strings.TrimSpace(line) == unwanted {
+				t.Errorf("unexpected %q in\n%s", unwanted, source)
+			}
+		}
+	}
+}
+
+// findMethod returns the method of the name.
+func findMethod(t *testing.T, methods []*template.MethodRender, name string) *template.MethodRender {
+	t.Helper()
+	for _, method := range methods {
+		if method.Name == name {
+			return method
+		}
+	}
+	t.Fatalf("method %s is not generated", name)
+	return nil
+}
@@ -21,23 +21,51 @@ import (
 	"github.com/hertz-contrib/thrift-gen-mongo/parse"
 )
 
-func countCodegen(count *parse.CountParse) []code.Statement {
+func countCodegen(count *parse.CountParse, routed bool) []code.Statement {
+	stmts := maxTimeCodegen(count.CtxParamName, count.MaxTimeParamName)
+	if count.Estimated && count.Query.ConnectionOpTree == nil {
+		return append(stmts, countResultCodegen(estimatedCountCodegen(count))...)
+	}
+	if count.Estimated && routed {
+		// the collection of the tenant holds the documents of the tenant only, it is counted with the metadata
+		stmts = append(stmts, code.IfBlockStmt{
+			Condition: []code.Statement{code.RawStmt("r.tenantRouted ")},
+			Body:      countResultCodegen(estimatedCountCodegen(count)),
+		})
+	}
+
+	args := code.ListCommaStmt{
+		code.RawStmt(count.CtxParamName),
+		operationFilter(count, routed),
+	}
+	if countOptions := countOptionsCodegen(count); countOptions != nil {
+		args = append(args, countOptions)
+	}
+	return append(stmts, countResultCodegen(code.CallStmt{
+		Caller:   code.RawStmt("r.collection"),
+		CallName: "CountDocuments",
+		Args:     args,
+	})...)
+}
+
+func estimatedCountCodegen(count *parse.CountParse) code.CallStmt {
+	return code.CallStmt{
+		Caller:   code.RawStmt("r.collection"),
+		CallName: "EstimatedDocumentCount",
+		Args:     code.ListCommaStmt{code.RawStmt(count.CtxParamName)},
+	}
+}
+
+func countResultCodegen(countCall code.CallStmt) []code.Statement {
 	return []code.Statement{
 		code.DeclColonStmt{
 			Left: code.ListCommaStmt{
 				code.RawStmt("result"),
 				code.RawStmt("err"),
 			},
-			Right: code.CallStmt{
-				Caller:   code.RawStmt("r.collection"),
-				CallName: "CountDocuments",
-				Args: code.ListCommaStmt{
-					code.RawStmt(count.CtxParamName),
-					queryCodegen(count.Query),
-				},
-			},
+			Right: countCall,
 		},
-		code.RawStmt("if err != nil {\n\treturn 0, err\n}"),
+		code.RawStmt("if err != nil {\n\treturn 0, translateError(err)\n}"),
 		code.ReturnStmt{
 			ListCommaStmt: code.ListCommaStmt{
 				code.RawStmt("int(result)"),
@@ -46,3 +74,40 @@ func countCodegen(count *parse.CountParse) []code.Statement {
 		},
 	}
 }
+
+// maxTimeCodegen bounds the operation by the deadline of the context named by ctxName,
+// the maxTimeMS option is deprecated since the deadline of the context is sent as the server time limit.
+func maxTimeCodegen(ctxName, maxTimeName string) []code.Statement {
+	if maxTimeName == "" {
+		return nil
+	}
+	return []code.Statement{
+		code.RawStmt("if " + maxTimeName + " > 0 {\n\tvar cancel context.CancelFunc\n\t" + ctxName + ", cancel = " +
+			"context.WithTimeout(" + ctxName + ", " + maxTimeName + ")\n\tdefer cancel()\n}"),
+	}
+}
+
+func countOptionsCodegen(count *parse.CountParse) code.Statement {
+	if count.LimitParamName == "" && count.SkipParamName == "" {
+		return nil
+	}
+
+	chainCall := make(code.ChainStmt, 0, 5)
+	baseChain := chainCall.ChainCall(code.Chain{
+		CallName: "options.Count",
+		Args:     code.ListCommaStmt{},
+	})
+	if count.LimitParamName != "" {
+		baseChain = baseChain.ChainCall(code.Chain{
+			CallName: "SetLimit",
+			Args:     code.ListCommaStmt{code.RawStmt(count.LimitParamName)},
+		})
+	}
+	if count.SkipParamName != "" {
+		baseChain = baseChain.ChainCall(code.Chain{
+			CallName: "SetSkip",
+			Args:     code.ListCommaStmt{code.RawStmt(count.SkipParamName)},
+		})
+	}
+	return baseChain
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+func TestCountOptions(t *testing.T) {
+	operations := parseIDL(t, `namespace go video
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: i32 Status (go.tag="bson:\"status\"")
+}
+(
+    mongo.CountLimitSkipMaxTimeByStatusEqual = "CountByStatus(ctx context.Context, limit int64, skip int64, maxTime time.Duration, status int32) (int, error)"
+    mongo.EstimatedCountMaxTimeAll = "EstimatedCount(ctx context.Context, maxTime time.Duration) (int, error)"
+)
+`)
+	methods := HandleCodegen(operations, false)[0]
+
+	count := renderMethods(t, []*template.MethodRender{findMethod(t, methods, "countByStatus")})
+	checkCode(t, count,
+		"ctx, cancel = context.WithTimeout(ctx, maxTime)",
+		"}, options.Count().SetLimit(limit).SetSkip(skip))")
+
+	estimated := renderMethods(t, []*template.MethodRender{findMethod(t, methods, "estimatedCount")})
+	checkCode(t, estimated,
+		"ctx, cancel = context.WithTimeout(ctx, maxTime)",
+		"result, err := r.collection.EstimatedDocumentCount(ctx)")
+}
+
+const countIDL = `namespace go video
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: i32 Status (go.tag="bson:\"status\"")
+    3: string TenantId (go.tag="bson:\"tenant_id\"")
+}
+(
+    mongo.tenant = "TenantId"
+    mongo.tenant_resolver = "true"
+    mongo.CountLimitMaxTimeByStatusEqual = "CountByStatus(ctx context.Context, limit int64, maxTime time.Duration, status int32) (int, error)"
+    mongo.EstimatedCountMaxTimeAll = "EstimatedCount(ctx context.Context, maxTime time.Duration) (int, error)"
+    mongo.TransactionCountMaxTimeByStatusEqual = "CountInTransaction(ctx context.Context, client *mongo.Client, maxTime time.Duration, status int32, guard func(int) error) error"
+)
+`
+
+func TestCountCodegen(t *testing.T) {
+	operations := parseIDL(t, countIDL)
+	deadline := []string{
+		"if maxTime > 0 {",
+		"var cancel context.CancelFunc",
+		"ctx, cancel = context.WithTimeout(ctx, maxTime)",
+		"defer cancel()",
+	}
+
+	methods := HandleCodegen(operations, false)[0]
+	count := renderMethods(t, []*template.MethodRender{findMethod(t, methods, "countByStatus")})
+	checkCode(t, count, append(deadline,
+		"result, err := r.collection.CountDocuments(ctx, bson.M{",
+		`"$and": mongox.RoutedTenantConditions(ctx, "tenant_id", r.tenantRouted),`,
+		"}, options.Count().SetLimit(limit))")...)
+	checkNoCode(t, count, "if r.tenantRouted {")
+
+	estimated := renderMethods(t, []*template.MethodRender{findMethod(t, methods, "estimatedCount")})
+	checkCode(t, estimated, append(deadline,
+		"if r.tenantRouted {",
+		"result, err := r.collection.EstimatedDocumentCount(ctx)",
+		"result, err := r.collection.CountDocuments(ctx, bson.M{",
+		`"$and": mongox.RoutedTenantConditions(ctx, "tenant_id", r.tenantRouted),`)...)
+
+	// the interceptors see the filter the method counts by
+	wrapper := renderMethods(t, []*template.MethodRender{findMethod(t, methods, "EstimatedCount")})
+	checkCode(t, wrapper,
+		"Filter: bson.M{",
+		`"$and": mongox.RoutedTenantConditions(ctx, "tenant_id", r.tenantRouted),`)
+
+	transaction := renderMethods(t, []*template.MethodRender{findMethod(t, methods, "countInTransaction")})
+	checkCode(t, transaction,
+		"count1Context := context.Context(sessionContext)",
+		"count1Context, cancel = context.WithTimeout(count1Context, maxTime)",
+		"count1, err := r.collection.CountDocuments(count1Context, bson.M{")
+
+	memory := renderMethods(t, []*template.MethodRender{findMethod(t, HandleMemoryCodegen(operations)[0], "EstimatedCount")})
+	checkCode(t, memory,
+		"result, err := r.collection.CountDocuments(ctx, bson.M{",
+		`"$and": mongox.TenantConditions(ctx, "tenant_id"),`)
+	checkNoCode(t, memory, "if r.tenantRouted {")
+
+	for _, source := range []string{count, estimated, transaction, memory} {
+		if strings.Contains(source, "SetMaxTime") {
+			t.Errorf("unexpected SetMaxTime in\n%s", source)
+		}
+	}
+}
@@ -21,7 +21,11 @@ import (
 	"github.com/hertz-contrib/thrift-gen-mongo/parse"
 )
 
-func deleteCodegen(delete *parse.DeleteParse) []code.Statement {
+func deleteCodegen(delete *parse.DeleteParse, routed bool) []code.Statement {
+	if update, ok := softDeleteCodegen(delete); ok {
+		return softDeleteMethodCodegen(delete, update, routed)
+	}
+
 	if delete.OperateMode == parse.OperateOne {
 		return []code.Statement{
 			code.DeclColonStmt{
@@ -34,11 +38,11 @@ func deleteCodegen(delete *parse.DeleteParse) []code.Statement {
 					CallName: "DeleteOne",
 					Args: code.ListCommaStmt{
 						code.RawStmt(delete.CtxParamName),
-						queryCodegen(delete.Query),
+						operationFilter(delete, routed),
 					},
 				},
 			},
-			code.RawStmt("if err != nil {\n\treturn false, err\n}"),
+			code.RawStmt("if err != nil {\n\treturn false, translateError(err)\n}"),
 			code.ReturnStmt{
 				ListCommaStmt: code.ListCommaStmt{
 					code.RawStmt("result.DeletedCount > 0"),
@@ -58,11 +62,11 @@ func deleteCodegen(delete *parse.DeleteParse) []code.Statement {
 					CallName: "DeleteMany",
 					Args: code.ListCommaStmt{
 						code.RawStmt(delete.CtxParamName),
-						queryCodegen(delete.Query),
+						operationFilter(delete, routed),
 					},
 				},
 			},
-			code.RawStmt("if err != nil {\n\treturn 0, err\n}"),
+			code.RawStmt("if err != nil {\n\treturn 0, translateError(err)\n}"),
 			code.ReturnStmt{
 				ListCommaStmt: code.ListCommaStmt{
 					code.RawStmt("int(result.DeletedCount)"),
@@ -72,3 +76,89 @@ func deleteCodegen(delete *parse.DeleteParse) []code.Statement {
 		}
 	}
 }
+
+// softDeleteCodegen returns the update document which marks the documents as deleted,
+// ok is false if the documents should be removed.
+func softDeleteCodegen(delete *parse.DeleteParse) (update code.MapStmt, ok bool) {
+	field := delete.BelongedToMethod.BelongedToStruct.SoftDeleteField
+	if delete.Hard || field == nil {
+		return code.MapStmt{}, false
+	}
+
+	value := code.RawStmt("true")
+	if field.Type.RealName() == "int64" {
+		value = "time.Now().UnixMilli()"
+	}
+
+	return code.MapStmt{
+		Name: "bson.M",
+		Pair: []code.MapPair{
+			{
+				Key: code.RawStmt("$set"),
+				Value: code.MapStmt{
+					Name: "bson.M",
+					Pair: []code.MapPair{
+						{
+							Key:   code.RawStmt(field.Tag.Get("bson")),
+							Value: value,
+						},
+					},
+				},
+			},
+		},
+	}, true
+}
+
+func softDeleteMethodCodegen(delete *parse.DeleteParse, update code.MapStmt, routed bool) []code.Statement {
+	if delete.OperateMode == parse.OperateOne {
+		return []code.Statement{
+			code.DeclColonStmt{
+				Left: code.ListCommaStmt{
+					code.RawStmt("result"),
+					code.RawStmt("err"),
+				},
+				Right: code.CallStmt{
+					Caller:   code.RawStmt("r.collection"),
+					CallName: "UpdateOne",
+					Args: code.ListCommaStmt{
+						code.RawStmt(delete.CtxParamName),
+						operationFilter(delete, routed),
+						update,
+					},
+				},
+			},
+			code.RawStmt("if err != nil {\n\treturn false, translateError(err)\n}"),
+			code.ReturnStmt{
+				ListCommaStmt: code.ListCommaStmt{
+					code.RawStmt("result.MatchedCount > 0"),
+					code.RawStmt("nil"),
+				},
+			},
+		}
+	} else {
+		return []code.Statement{
+			code.DeclColonStmt{
+				Left: code.ListCommaStmt{
+					code.RawStmt("result"),
+					code.RawStmt("err"),
+				},
+				Right: code.CallStmt{
+					Caller:   code.RawStmt("r.collection"),
+					CallName: "UpdateMany",
+					Args: code.ListCommaStmt{
+						code.RawStmt(delete.CtxParamName),
+						operationFilter(delete, routed),
+						update,
+					},
+				},
+			},
+			code.RawStmt("if err != nil {\n\treturn 0, translateError(err)\n}"),
+			code.ReturnStmt{
+				ListCommaStmt: code.ListCommaStmt{
+					code.RawStmt("int(result.MatchedCount)"),
+					code.RawStmt("nil"),
+				},
+			},
+		}
+	}
+}
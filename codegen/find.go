@@ -23,7 +23,7 @@ import (
 	"github.com/hertz-contrib/thrift-gen-mongo/parse"
 )
 
-func findCodegen(find *parse.FindParse) []code.Statement {
+func findCodegen(find *parse.FindParse, routed bool) []code.Statement {
 	if find.OperateMode == parse.OperateOne {
 		return []code.Statement{
 			code.DeclVarStmt{
@@ -39,19 +39,19 @@ func findCodegen(find *parse.FindParse) []code.Statement {
 							CallName: "FindOne",
 							Args: code.ListCommaStmt{
 								code.RawStmt(find.CtxParamName),
-								queryCodegen(find.Query),
+								operationFilter(find, routed),
 								findOptionsCodegen(find),
 							},
 						},
 						CallName: "Decode",
 						Args: code.ListCommaStmt{
-							code.RawStmt("entity"),
+							code.RawStmt("&entity"),
 						},
 					},
 					code.RawStmt("; err != nil "),
 				},
 				Body: code.Body{
-					code.RawStmt("return nil, err"),
+					code.RawStmt("return nil, translateError(err)"),
 				},
 			},
 			code.ReturnStmt{
@@ -73,12 +73,12 @@ func findCodegen(find *parse.FindParse) []code.Statement {
 					CallName: "Find",
 					Args: code.ListCommaStmt{
 						code.RawStmt(find.CtxParamName),
-						queryCodegen(find.Query),
+						operationFilter(find, routed),
 						findOptionsCodegen(find),
 					},
 				},
 			},
-			code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+			code.RawStmt("if err != nil {\n\treturn nil, translateError(err)\n}"),
 			code.DeclVarStmt{
 				Name: "entities",
 				Type: find.ReturnType,
@@ -97,7 +97,7 @@ func findCodegen(find *parse.FindParse) []code.Statement {
 					code.RawStmt("; err != nil "),
 				},
 				Body: code.Body{
-					code.RawStmt("return nil, err"),
+					code.RawStmt("return nil, translateError(err)"),
 				},
 			},
 			code.ReturnStmt{
@@ -0,0 +1,34 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import "testing"
+
+func TestFindOneDecode(t *testing.T) {
+	operations := parseIDL(t, `namespace go video
+
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id,omitempty\"")
+}
+(
+    mongo.FindByIdEqual = "FindByIdEqual(ctx context.Context, id int64) (*video.Video, error)"
+)
+`)
+	source := renderMethods(t, HandleCodegen(operations, false)[0])
+	// the result is decoded into the entity the pointer of which is returned
+	checkCode(t, source, "}, options.FindOne().SetSort(bson.M{})).Decode(&entity); err != nil {")
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+)
+
+// insertIDCodegen generates the id of the document named by obj when it is zero,
+// param is the method's param name of the document or the documents.
+func insertIDCodegen(insert *parse.InsertParse, param, obj string) []code.Statement {
+	st := insert.BelongedToMethod.BelongedToStruct
+	if st.IDStrategy == "" || st.IDStrategy == extract.IDStrategySequence ||
+		!isStructParam(insert.BelongedToMethod, param, st) {
+		return nil
+	}
+
+	return []code.Statement{
+		code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.RawStmt(obj + "." + st.IDField.Name + " == " + zeroValue(st.IDField.Type.RealName()) + " "),
+			},
+			Body: code.Body{
+				code.RawStmt(obj + "." + st.IDField.Name + " = newID()"),
+			},
+		},
+	}
+}
+
+// sequenceReserver returns the call which reserves n ids of the sequence and returns the last reserved id.
+type sequenceReserver func(ctxName, n string) string
+
+// collectionSequence reserves the ids in the database of the collection.
+func collectionSequence(collection string) sequenceReserver {
+	return func(ctxName, n string) string {
+		return "nextSequence(" + ctxName + ", " + collection + ", " + n + ")"
+	}
+}
+
+// sequenceCodegen assigns the ids of the sequence strategy to the documents named by param before they are inserted
+// into the collection, reserve reserves the ids of the sequence, onError handles the err.
+func sequenceCodegen(insert *parse.InsertParse, param string, reserve sequenceReserver, onError string) []code.Statement {
+	st := insert.BelongedToMethod.BelongedToStruct
+	if st.IDStrategy != extract.IDStrategySequence || !isStructParam(insert.BelongedToMethod, param, st) {
+		return nil
+	}
+
+	ctxName := insert.BelongedToMethod.Params[0].Name
+	assign := func(obj string) code.Statement {
+		return code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.RawStmt(obj + "." + st.IDField.Name + " == 0 "),
+			},
+			Body: code.Body{
+				code.RawStmt("seq, err := " + reserve(ctxName, "1")),
+				code.RawStmt("if err != nil {\n\t" + onError + "\n}"),
+				code.RawStmt(obj + "." + st.IDField.Name + " = seq"),
+			},
+		}
+	}
+
+	if insert.OperateMode == parse.OperateOne {
+		return []code.Statement{assign(param)}
+	}
+	if !st.SequenceBatch {
+		return []code.Statement{
+			code.ForRangeBlockStmt{
+				RangeName: param,
+				Value:     "model",
+				Body:      code.Body{assign("model")},
+			},
+		}
+	}
+
+	// reserve the ids of the documents with one increment
+	reserved := param + "Reserve"
+	zeroID := code.RawStmt("model." + st.IDField.Name + " == 0 ")
+	return []code.Statement{
+		code.DeclVarStmt{
+			Name: reserved,
+			Type: code.IdentType("int64"),
+		},
+		code.ForRangeBlockStmt{
+			RangeName: param,
+			Value:     "model",
+			Body: code.Body{
+				code.IfBlockStmt{
+					Condition: []code.Statement{zeroID},
+					Body:      code.Body{code.RawStmt(reserved + "++")},
+				},
+			},
+		},
+		code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.RawStmt(reserved + " > 0 "),
+			},
+			Body: code.Body{
+				code.RawStmt("seq, err := " + reserve(ctxName, reserved)),
+				code.RawStmt("if err != nil {\n\t" + onError + "\n}"),
+				code.RawStmt("seq -= " + reserved),
+				code.ForRangeBlockStmt{
+					RangeName: param,
+					Value:     "model",
+					Body: code.Body{
+						code.IfBlockStmt{
+							Condition: []code.Statement{zeroID},
+							Body: code.Body{
+								code.RawStmt("seq++"),
+								code.RawStmt("model." + st.IDField.Name + " = seq"),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// IDImports returns the imports of the id file generated with the repository of the strategy.
+func IDImports(strategy string) map[string]string {
+	switch strategy {
+	case extract.IDStrategyObjectID:
+		return map[string]string{
+			"go.mongodb.org/mongo-driver/bson/primitive": "",
+		}
+	case extract.IDStrategyUUIDv4:
+		return map[string]string{
+			"crypto/rand":  "",
+			"encoding/hex": "",
+		}
+	case extract.IDStrategyUUIDv7:
+		return map[string]string{
+			"crypto/rand":     "",
+			"encoding/binary": "",
+			"encoding/hex":    "",
+			"time":            "",
+		}
+	case extract.IDStrategySnowflake:
+		return map[string]string{
+			"sync": "",
+			"time": "",
+		}
+	case extract.IDStrategySequence:
+		return map[string]string{
+			"context":                                   "",
+			"go.mongodb.org/mongo-driver/bson":          "",
+			"go.mongodb.org/mongo-driver/mongo":         "",
+			"go.mongodb.org/mongo-driver/mongo/options": "",
+		}
+	default:
+		return map[string]string{}
+	}
+}
+
+func zeroValue(typeName string) string {
+	switch typeName {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	case "int", "int8", "int16", "int32", "int64", "float32", "float64":
+		return "0"
+	default:
+		return "nil"
+	}
+}
+
+// typedZeroValue returns the zero value of the type, the composite literals are not used
+// since the named type may be an interface or a pointer.
+func typedZeroValue(t code.Type) string {
+	switch t := t.(type) {
+	case code.StarExprType, code.SliceType, code.MapType, code.InterfaceType, code.FuncType:
+		return "nil"
+	case code.IdentType:
+		switch name := t.RealName(); name {
+		case "error", "interface{}", "any":
+			return "nil"
+		case "string", "bool", "int", "int8", "int16", "int32", "int64", "float32", "float64":
+			return zeroValue(name)
+		case "uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune":
+			return "0"
+		}
+	}
+	return "*new(" + t.RealName() + ")"
+}
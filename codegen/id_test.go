@@ -0,0 +1,68 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import "testing"
+
+func TestInsertTypedID(t *testing.T) {
+	operations := parseIDL(t, `namespace go video
+
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+}
+(
+    mongo.id = "Id"
+    mongo.id_strategy = "snowflake"
+    mongo.InsertOne = "InsertOne(ctx context.Context, v *video.Video) (int64, error)"
+    mongo.InsertMany = "InsertMany(ctx context.Context, vs []*video.Video) ([]int64, error)"
+)
+`)
+	source := renderMethods(t, HandleCodegen(operations, false)[0])
+	// the zero ids are generated before the insert, the ids of the struct are returned
+	checkCode(t, source,
+		"func (r *VideoRepositoryMongo) InsertOne(ctx context.Context, v *video.Video) (int64, error) {",
+		"if v.Id == 0 {",
+		"v.Id = newID()",
+		"return v.Id, nil",
+		"if model.Id == 0 {",
+		"model.Id = newID()",
+		"ids = append(ids, model.Id)",
+	)
+}
+
+func TestInsertSequenceBatch(t *testing.T) {
+	operations := parseIDL(t, `namespace go video
+
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+}
+(
+    mongo.id = "Id"
+    mongo.id_strategy = "sequence"
+    mongo.sequence_batch = "true"
+    mongo.InsertMany = "InsertMany(ctx context.Context, vs []*video.Video) ([]int64, error)"
+)
+`)
+	source := renderMethods(t, HandleCodegen(operations, false)[0])
+	// the ids of the documents without one are reserved with one increment of the sequence
+	checkCode(t, source,
+		"vsReserve++",
+		"seq, err := nextSequence(ctx, r.collection, vsReserve)",
+		"seq -= vsReserve",
+		"model.Id = seq",
+	)
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// EnsureIndexesMethod is the repository method which creates the indexes declared by the annotations.
+var EnsureIndexesMethod = code.InterfaceMethod{
+	Name: "EnsureIndexes",
+	Params: code.Params{
+		code.Param{
+			Name: "ctx",
+			Type: code.SelectorExprType{X: "context", Sel: "Context"},
+		},
+	},
+	Returns: code.Returns{code.IdentType("error")},
+}
+
+// IndexImports returns the imports of the index file generated with the repository of the struct.
+func IndexImports(st *extract.IdlExtractStruct) map[string]string {
+	imports := map[string]string{
+		"context":                           "",
+		"go.mongodb.org/mongo-driver/bson":  "",
+		"go.mongodb.org/mongo-driver/mongo": "",
+	}
+	for _, index := range st.Indexes {
+		if indexOptionsCodegen(index) != "" {
+			imports["go.mongodb.org/mongo-driver/mongo/options"] = ""
+		}
+	}
+	return imports
+}
+
+// GetEnsureIndexesRender returns the EnsureIndexes method of the repository, the indexes are created
+// in the order of the annotations.
+func GetEnsureIndexesRender(st *extract.IdlExtractStruct) *template.MethodRender {
+	models := "[]mongo.IndexModel{\n"
+	for _, index := range st.Indexes {
+		keys := make([]string, 0, len(index.Keys))
+		for _, key := range index.Keys {
+			value := key.Kind
+			if value == extract.IndexText || value == extract.Index2dsphere {
+				value = strconv.Quote(value)
+			}
+			keys = append(keys, "{Key: "+strconv.Quote(key.BsonPath)+", Value: "+value+"}")
+		}
+		models += "{\nKeys: bson.D{" + strings.Join(keys, ", ") + "},\n"
+		if options := indexOptionsCodegen(index); options != "" {
+			models += "Options: " + options + ",\n"
+		}
+		models += "},\n"
+	}
+	models += "}"
+
+	render := &template.MethodRender{
+		Name:    EnsureIndexesMethod.Name,
+		Comment: "// EnsureIndexes creates the indexes declared by the annotations of the " + st.Name + ", the existing indexes are kept.",
+		MethodReceiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{
+				RealType: code.IdentType(st.Name + "RepositoryMongo"),
+			},
+		},
+		Params:  EnsureIndexesMethod.Params,
+		Returns: EnsureIndexesMethod.Returns,
+		MethodBody: code.Body{
+			code.RawStmt("_, err := r.collection.Indexes().CreateMany(ctx, " + models + ")"),
+			code.RawStmt("return translateError(err)"),
+		},
+	}
+	if route := tenantRouteCodegen(st, render); route != nil {
+		render.MethodBody = append(code.Body{route}, render.MethodBody...)
+	}
+	return render
+}
+
+func indexOptionsCodegen(index *extract.Index) string {
+	setters := ""
+	if index.Name != "" {
+		setters += ".SetName(" + strconv.Quote(index.Name) + ")"
+	}
+	if index.Unique {
+		setters += ".SetUnique(true)"
+	}
+	if index.Sparse {
+		setters += ".SetSparse(true)"
+	}
+	if index.TTL != "" {
+		setters += ".SetExpireAfterSeconds(" + index.TTL + ")"
+	}
+	if len(index.Partial) != 0 {
+		setters += ".SetPartialFilterExpression(" + partialFilterCodegen(index.Partial) + ")"
+	}
+	if setters == "" {
+		return ""
+	}
+	return "options.Index()" + setters
+}
+
+// partialFilterCodegen merges the conditions of the same field into one document.
+func partialFilterCodegen(conditions []*extract.IndexCondition) string {
+	paths := make([]string, 0, len(conditions))
+	operators := make(map[string][]string, len(conditions))
+	for _, condition := range conditions {
+		if _, ok := operators[condition.BsonPath]; !ok {
+			paths = append(paths, condition.BsonPath)
+		}
+		operators[condition.BsonPath] = append(operators[condition.BsonPath],
+			strconv.Quote(condition.MongoOperator())+": "+condition.Value)
+	}
+
+	pairs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		pairs = append(pairs, strconv.Quote(path)+": bson.M{"+strings.Join(operators[path], ", ")+"}")
+	}
+	return "bson.M{" + strings.Join(pairs, ", ") + "}"
+}
@@ -21,33 +21,64 @@ import (
 	"github.com/hertz-contrib/thrift-gen-mongo/parse"
 )
 
-func insertCodegen(insert *parse.InsertParse) []code.Statement {
+// insertCodegen returns the body of the insert method, reserve reserves the ids of the sequence strategy.
+func insertCodegen(insert *parse.InsertParse, reserve sequenceReserver) []code.Statement {
 	if insert.OperateMode == parse.OperateOne {
-		return []code.Statement{
+		onError := "return nil, err"
+		if insert.TypedID {
+			onError = "return " + zeroValue(insert.BelongedToMethod.BelongedToStruct.IDField.Type.RealName()) + ", err"
+		}
+		stmts := insertValidateCodegen(insert, insert.MethodParamNames[1], onError)
+		stmts = append(stmts, insertPrepareCodegen(insert, insert.MethodParamNames[1], insert.MethodParamNames[1])...)
+		stmts = append(stmts, sequenceCodegen(insert, insert.MethodParamNames[1], reserve, onError)...)
+		insertOne := code.CallStmt{
+			Caller:   code.RawStmt("r.collection"),
+			CallName: "InsertOne",
+			Args: code.ListCommaStmt{
+				code.RawStmt(insert.MethodParamNames[0]),
+				code.RawStmt(insert.MethodParamNames[1]),
+			},
+		}
+		if insert.TypedID {
+			idField := insert.BelongedToMethod.BelongedToStruct.IDField
+			return append(stmts, []code.Statement{
+				code.DeclColonStmt{
+					Left: code.ListCommaStmt{
+						code.RawStmt("_"),
+						code.RawStmt("err"),
+					},
+					Right: insertOne,
+				},
+				code.RawStmt("if err != nil {\n\treturn " + zeroValue(idField.Type.RealName()) + ", translateError(err)\n}"),
+				code.ReturnStmt{
+					ListCommaStmt: code.ListCommaStmt{
+						code.RawStmt(insert.MethodParamNames[1] + "." + idField.Name),
+						code.RawStmt("nil"),
+					},
+				},
+			}...)
+		}
+
+		return append(stmts, []code.Statement{
 			code.DeclColonStmt{
 				Left: code.ListCommaStmt{
 					code.RawStmt("result"),
 					code.RawStmt("err"),
 				},
-				Right: code.CallStmt{
-					Caller:   code.RawStmt("r.collection"),
-					CallName: "InsertOne",
-					Args: code.ListCommaStmt{
-						code.RawStmt(insert.MethodParamNames[0]),
-						code.RawStmt(insert.MethodParamNames[1]),
-					},
-				},
+				Right: insertOne,
 			},
-			code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+			code.RawStmt("if err != nil {\n\treturn nil, translateError(err)\n}"),
 			code.ReturnStmt{
 				ListCommaStmt: code.ListCommaStmt{
 					code.RawStmt("result.InsertedID"),
 					code.RawStmt("nil"),
 				},
 			},
-		}
+		}...)
 	} else {
-		return []code.Statement{
+		stmts := insertValidateCodegen(insert, insert.MethodParamNames[1], "return nil, err")
+		stmts = append(stmts, sequenceCodegen(insert, insert.MethodParamNames[1], reserve, "return nil, err")...)
+		stmts = append(stmts,
 			code.DeclVarStmt{
 				Name: "entities",
 				Type: code.SliceType{
@@ -57,31 +88,177 @@ func insertCodegen(insert *parse.InsertParse) []code.Statement {
 			code.ForRangeBlockStmt{
 				RangeName: insert.MethodParamNames[1],
 				Value:     "model",
-				Body: []code.Statement{
-					code.RawStmt("entities = append(entities, model)"),
-				},
+				Body: append(insertPrepareCodegen(insert, insert.MethodParamNames[1], "model"),
+					code.RawStmt("entities = append(entities, model)")),
 			},
+		)
+		if insert.BelongedToMethod.BelongedToStruct.ChunkSize > 0 {
+			return append(stmts, insertChunksCodegen(insert)...)
+		}
+		insertMany := code.CallStmt{
+			Caller:   code.RawStmt("r.collection"),
+			CallName: "InsertMany",
+			Args:     insertManyArgsCodegen(insert, insert.MethodParamNames[0]),
+		}
+		if insert.TypedID {
+			stmts = append(stmts,
+				code.DeclColonStmt{
+					Left: code.ListCommaStmt{
+						code.RawStmt("_"),
+						code.RawStmt("err"),
+					},
+					Right: insertMany,
+				},
+				code.RawStmt("if err != nil {\n\treturn nil, translateError(err)\n}"),
+			)
+			return append(stmts, typedIDsCodegen(insert)...)
+		}
+
+		return append(stmts, []code.Statement{
 			code.DeclColonStmt{
 				Left: code.ListCommaStmt{
 					code.RawStmt("result"),
 					code.RawStmt("err"),
 				},
-				Right: code.CallStmt{
-					Caller:   code.RawStmt("r.collection"),
-					CallName: "InsertMany",
-					Args: code.ListCommaStmt{
-						code.RawStmt(insert.MethodParamNames[0]),
-						code.RawStmt("entities"),
-					},
-				},
+				Right: insertMany,
 			},
-			code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+			code.RawStmt("if err != nil {\n\treturn nil, translateError(err)\n}"),
 			code.ReturnStmt{
 				ListCommaStmt: code.ListCommaStmt{
 					code.RawStmt("result.InsertedIDs"),
 					code.RawStmt("nil"),
 				},
 			},
+		}...)
+	}
+}
+
+// insertChunksCodegen inserts the entities by the chunks of the chunk size annotation, the inserted ids
+// of the chunks are aggregated in the order of the entities.
+func insertChunksCodegen(insert *parse.InsertParse) []code.Statement {
+	args := insertManyArgsCodegen(insert, insert.MethodParamNames[0])
+	args[1] = code.RawStmt("entities[start:end]")
+	insertMany := code.CallStmt{
+		Caller:   code.RawStmt("r.collection"),
+		CallName: "InsertMany",
+		Args:     args,
+	}
+
+	progress := "nil"
+	if insert.ProgressParamName != "" {
+		progress = insert.ProgressParamName
+	}
+	ordered := "true"
+	if insert.Unordered {
+		ordered = "false"
+	}
+
+	var write code.Body
+	if insert.TypedID {
+		write = code.Body{
+			code.DeclColonStmt{
+				Left: code.ListCommaStmt{
+					code.RawStmt("_"),
+					code.RawStmt("err"),
+				},
+				Right: insertMany,
+			},
+			code.RawStmt("return err"),
 		}
+	} else {
+		write = code.Body{
+			code.DeclColonStmt{
+				Left: code.ListCommaStmt{
+					code.RawStmt("result"),
+					code.RawStmt("err"),
+				},
+				Right: insertMany,
+			},
+			code.RawStmt("if err != nil {\n\treturn err\n}"),
+			code.RawStmt("ids = append(ids, result.InsertedIDs...)"),
+			code.RawStmt("return nil"),
+		}
+	}
+
+	stmts := make([]code.Statement, 0, 5)
+	if !insert.TypedID {
+		stmts = append(stmts, code.RawStmt("ids := make([]interface{}, 0, len(entities))"))
+	}
+	stmts = append(stmts,
+		code.DeclColonStmt{
+			Left: code.ListCommaStmt{code.RawStmt("err")},
+			Right: code.CallStmt{
+				CallName: "writeChunks",
+				Args: code.ListCommaStmt{
+					code.RawStmt("len(entities)"),
+					code.RawStmt(ordered),
+					code.RawStmt(progress),
+					code.AnonymousFuncStmt{
+						Params: code.Params{
+							{Name: "start", Type: code.IdentType("int")},
+							{Name: "end", Type: code.IdentType("int")},
+						},
+						Returns: code.Returns{code.IdentType("error")},
+						Body:    write,
+					},
+				},
+			},
+		},
+		code.RawStmt("if err != nil {\n\treturn nil, err\n}"),
+	)
+	if insert.TypedID {
+		return append(stmts, typedIDsCodegen(insert)...)
+	}
+	return append(stmts, code.ReturnStmt{
+		ListCommaStmt: code.ListCommaStmt{
+			code.RawStmt("ids"),
+			code.RawStmt("nil"),
+		},
+	})
+}
+
+// typedIDsCodegen returns the id fields of the inserted models.
+func typedIDsCodegen(insert *parse.InsertParse) []code.Statement {
+	idField := insert.BelongedToMethod.BelongedToStruct.IDField
+	return []code.Statement{
+		code.RawStmt("ids := make([]" + idField.Type.RealName() + ", 0, len(" + insert.MethodParamNames[1] + "))"),
+		code.ForRangeBlockStmt{
+			RangeName: insert.MethodParamNames[1],
+			Value:     "model",
+			Body: code.Body{
+				code.RawStmt("ids = append(ids, model." + idField.Name + ")"),
+			},
+		},
+		code.ReturnStmt{
+			ListCommaStmt: code.ListCommaStmt{
+				code.RawStmt("ids"),
+				code.RawStmt("nil"),
+			},
+		},
+	}
+}
+
+// insertPrepareCodegen prepares the document named by obj before it is inserted,
+// param is the method's param name of the document or the documents.
+func insertPrepareCodegen(insert *parse.InsertParse, param, obj string) []code.Statement {
+	stmts := append(tenantStampCodegen(insert.BelongedToMethod, param, obj), insertTimestampCodegen(insert, param, obj)...)
+	return append(stmts, insertIDCodegen(insert, param, obj)...)
+}
+
+func insertManyArgsCodegen(insert *parse.InsertParse, ctxName string) code.ListCommaStmt {
+	args := code.ListCommaStmt{
+		code.RawStmt(ctxName),
+		code.RawStmt("entities"),
+	}
+	if insert.Unordered {
+		chainCall := make(code.ChainStmt, 0, 5)
+		args = append(args, chainCall.ChainCall(code.Chain{
+			CallName: "options.InsertMany",
+			Args:     code.ListCommaStmt{},
+		}).ChainCall(code.Chain{
+			CallName: "SetOrdered",
+			Args:     code.ListCommaStmt{code.RawStmt("false")},
+		}))
 	}
+	return args
 }
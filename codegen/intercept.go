@@ -0,0 +1,130 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// InterceptorImports are the imports of the interceptor file generated in the shared package.
+var InterceptorImports = map[string]string{
+	"context": "",
+}
+
+// Intercepted reports whether the methods of the repository run through the interceptors, the repository
+// file generated before the interceptors are supported has no interceptors and is kept as it is.
+func Intercepted(st *extract.IdlExtractStruct) bool {
+	return !st.Update || interceptorsField.Match(st.UpdateCurdFileContent)
+}
+
+// the fields of the repository are aligned by gofmt
+var interceptorsField = regexp.MustCompile(`\binterceptors\s+\[\]mongox\.Interceptor\b`)
+
+// interceptCodegen moves the body of the method into the unexported method, the exported method runs it
+// through the interceptors of the repository, it is called directly when there are no interceptors.
+func interceptCodegen(st *extract.IdlExtractStruct, operation parse.Operation,
+	method *template.MethodRender,
+) []*template.MethodRender {
+	returns := method.Returns
+	if len(method.Params) == 0 || method.Params[0].Type.RealName() != "context.Context" ||
+		len(returns) == 0 || returns[len(returns)-1].RealName() != "error" {
+		return []*template.MethodRender{method}
+	}
+
+	inner := *method
+	inner.Name = lowerFirst(method.Name)
+	inner.Comment = ""
+	ctxName := method.Params[0].Name
+
+	params := make([]string, 0, len(method.Params))
+	for _, param := range method.Params {
+		params = append(params, param.Name)
+	}
+	variadic := ""
+	if strings.HasPrefix(method.Params[len(method.Params)-1].Type.RealName(), "...") {
+		variadic = "..."
+	}
+	call := "r." + inner.Name + "(" + strings.Join(params, ", ") + variadic + ")"
+
+	results := make([]string, 0, len(returns))
+	body := code.Body{
+		code.IfBlockStmt{
+			Condition: []code.Statement{code.RawStmt("len(r.interceptors) == 0 ")},
+			Body:      code.Body{code.RawStmt("return " + call)},
+		},
+	}
+	if route := tenantRouteCodegen(st, method); route != nil {
+		body = append(code.Body{route}, body...)
+	}
+	for i, t := range returns[:len(returns)-1] {
+		result := "result" + strconv.Itoa(i)
+		results = append(results, result)
+		body = append(body, code.DeclVarStmt{Name: result, Type: t})
+	}
+	assign := "return " + call
+	if len(results) != 0 {
+		assign = "var err error\n" + strings.Join(results, ", ") + ", err = " + call + "\nreturn err"
+	}
+
+	filter := operationFilter(operation, tenantRoutedFlag(st))
+	if filter == nil {
+		filter = code.RawStmt("nil")
+	}
+	invocation := "&mongox.Invocation{\nCollection: r.collection.Name(),\nRepository: " + strconv.Quote(st.Name) +
+		",\nMethod: " + strconv.Quote(method.Name) +
+		",\nOperation: " + strconv.Quote(operation.GetOperationName()) +
+		",\nFilter: " + filter.Code() +
+		",\nArgs: []interface{}{" + strings.Join(params[1:], ", ") + "},\n}"
+	body = append(body,
+		code.RawStmt("err := mongox.Intercept("+ctxName+", r.interceptors, "+invocation+
+			", func("+ctxName+" context.Context) error {\n"+assign+"\n})"),
+		code.RawStmt("return "+strings.Join(append(results, "err"), ", ")),
+	)
+
+	wrapper := *method
+	wrapper.MethodBody = body
+	return []*template.MethodRender{&wrapper, &inner}
+}
+
+// operationFilter returns the filter document of the operation, it is nil if the operation has no filter.
+// The methods filter by it and the interceptors see it as the filter of the invocation.
+func operationFilter(operation parse.Operation, routed bool) code.Statement {
+	switch operation := operation.(type) {
+	case *parse.FindParse:
+		return queryCodegen(operation.Query, routed)
+	case *parse.UpdateParse:
+		return queryCodegen(operation.Query, routed)
+	case *parse.DeleteParse:
+		return queryCodegen(operation.Query, routed)
+	case *parse.CountParse:
+		if operation.Estimated && operation.Query.ConnectionOpTree == nil {
+			return nil
+		}
+		return queryCodegen(operation.Query, routed)
+	case *parse.WatchParse:
+		return queryCodegen(operation.Query, routed)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,235 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// MemoryImports are the imports of the memory file generated in the shared package.
+var MemoryImports = map[string]string{
+	"bytes":                            "",
+	"context":                          "",
+	"errors":                           "",
+	"fmt":                              "",
+	"sort":                             "",
+	"strconv":                          "",
+	"strings":                          "",
+	"sync":                             "",
+	"go.mongodb.org/mongo-driver/bson": "",
+	"go.mongodb.org/mongo-driver/bson/primitive": "",
+	"go.mongodb.org/mongo-driver/mongo":          "",
+	"go.mongodb.org/mongo-driver/mongo/options":  "",
+}
+
+// MemoryRepositoryImports are the imports of the in-memory repository, the imports of the params are added by the caller.
+var MemoryRepositoryImports = map[string]string{
+	"context": "",
+}
+
+// MemoryRepositoryParamImports are the packages which may be referenced by the in-memory repository,
+// they are imported if they are used.
+var MemoryRepositoryParamImports = []string{
+	"time",
+	"go.mongodb.org/mongo-driver/bson",
+	"go.mongodb.org/mongo-driver/bson/primitive",
+	"go.mongodb.org/mongo-driver/mongo",
+	"go.mongodb.org/mongo-driver/mongo/options",
+}
+
+// memorySequence reserves the ids of the sequence strategy in the in-memory collection.
+func memorySequence(ctxName, n string) string {
+	return "r.collection.NextSequence(" + ctxName + ", " + n + ")"
+}
+
+// HandleMemoryCodegen returns the methods of the in-memory repositories, the methods which existed before
+// the update are included since the in-memory repository is generated with all the methods. The bodies
+// are generated as the bodies of the MongoDB repositories over the in-memory collection.
+func HandleMemoryCodegen(ifOperations []*parse.InterfaceOperation) (methodRenders [][]*template.MethodRender) {
+	for _, ifOperation := range ifOperations {
+		st := ifOperation.BelongedToStruct
+		operations := append(ifOperation.PreOperations[:len(ifOperation.PreOperations):len(ifOperation.PreOperations)],
+			ifOperation.Operations...)
+		methods := make([]*template.MethodRender, 0, len(operations)+1)
+		for _, operation := range operations {
+			switch operation := operation.(type) {
+			case *parse.InsertParse:
+				methods = append(methods, memoryMethodRender(st, operation.BelongedToMethod,
+					insertCodegen(operation, memorySequence)))
+			case *parse.FindParse:
+				methods = append(methods, memoryMethodRender(st, operation.BelongedToMethod, findCodegen(operation, false)))
+			case *parse.UpdateParse:
+				methods = append(methods, memoryMethodRender(st, operation.BelongedToMethod, updateCodegen(operation, false)))
+			case *parse.DeleteParse:
+				methods = append(methods, memoryMethodRender(st, operation.BelongedToMethod, deleteCodegen(operation, false)))
+			case *parse.CountParse:
+				methods = append(methods, memoryMethodRender(st, operation.BelongedToMethod, countCodegen(operation, false)))
+			case *parse.BulkParse:
+				methods = append(methods, memoryMethodRender(st, operation.BelongedToMethod,
+					bulkCodegen(operation, false, memorySequence)))
+			case *parse.TransactionParse:
+				if !memoryTransactional(operation) {
+					methods = append(methods, memoryUnsupportedCodegen(st, operation.BelongedToMethod))
+					break
+				}
+				methods = append(methods, memoryMethodRender(st, operation.BelongedToMethod, memoryTaCodegen(operation)))
+			case *parse.WatchParse:
+				methods = append(methods, memoryUnsupportedCodegen(st, operation.BelongedToMethod))
+			}
+		}
+		if len(st.Indexes) != 0 {
+			methods = append(methods, memoryEnsureIndexesCodegen(st))
+		}
+		if st.ShardKey != nil {
+			methods = append(methods, memoryShardCollectionCodegen(st))
+		}
+		methods = append(methods, memoryBulkCodegen(st))
+		methodRenders = append(methodRenders, methods)
+	}
+	return
+}
+
+func memoryMethodRender(st *extract.IdlExtractStruct, method *extract.InterfaceMethod, body code.Body) *template.MethodRender {
+	render := &template.MethodRender{
+		Name: method.Name,
+		MethodReceiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{
+				RealType: code.IdentType(st.Name + "RepositoryMemory"),
+			},
+		},
+		Params:     method.Params,
+		Returns:    method.Returns,
+		MethodBody: body,
+	}
+	tenantCheckCodegen(st, render)
+	return render
+}
+
+// memoryTransactional reports whether the steps of the transaction write the collection of the repository only,
+// the other collections are passed as *mongo.Collection which the in-memory repository can not roll back.
+func memoryTransactional(transaction *parse.TransactionParse) bool {
+	for _, operation := range transaction.TransactionOperations {
+		if operation.CollectionParamName != "r.collection" {
+			return false
+		}
+	}
+	return true
+}
+
+// memoryTaCodegen runs the steps of the transaction by the Transaction of the in-memory collection,
+// which rolls back the writes of the steps if a step fails, the errors are translated after the transaction
+// as runTransaction does. The session and transaction options are ignored.
+func memoryTaCodegen(transaction *parse.TransactionParse) code.Body {
+	reserver := func(string) sequenceReserver {
+		return memorySequence
+	}
+	return append(taValidateCodegen(transaction), code.ReturnStmt{
+		ListCommaStmt: code.ListCommaStmt{
+			code.CallStmt{
+				CallName: "translateError",
+				Args: code.ListCommaStmt{
+					code.CallStmt{
+						Caller:   code.RawStmt("r.collection"),
+						CallName: "Transaction",
+						Args: code.ListCommaStmt{
+							code.RawStmt(transaction.CtxParamName),
+							code.AnonymousFuncStmt{
+								Params: code.Params{
+									code.Param{
+										Name: "sessionContext",
+										Type: code.SelectorExprType{
+											X:   "context",
+											Sel: "Context",
+										},
+									},
+								},
+								Returns: code.Returns{
+									code.IdentType("error"),
+								},
+								Body: taBodyCodegen(transaction, false, reserver),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+// memoryUnsupportedCodegen returns the method which needs the MongoDB server, it returns mongox.ErrUnsupported.
+func memoryUnsupportedCodegen(st *extract.IdlExtractStruct, method *extract.InterfaceMethod) *template.MethodRender {
+	results := make([]string, 0, len(method.Returns))
+	for _, ret := range method.Returns[:len(method.Returns)-1] {
+		results = append(results, zeroValue(ret.RealName()))
+	}
+	results = append(results, "mongox.ErrUnsupported")
+	return memoryMethodRender(st, method, code.Body{
+		code.RawStmt("return " + strings.Join(results, ", ")),
+	})
+}
+
+// memoryEnsureIndexesCodegen returns the EnsureIndexes method of the in-memory repository, only the unique
+// indexes are created since the other indexes do not change the results.
+func memoryEnsureIndexesCodegen(st *extract.IdlExtractStruct) *template.MethodRender {
+	body := code.Body{}
+	for _, index := range st.Indexes {
+		if !index.Unique {
+			continue
+		}
+		name := index.Name
+		keys := make([]string, 0, len(index.Keys))
+		defaultName := make([]string, 0, len(index.Keys)*2)
+		for _, key := range index.Keys {
+			keys = append(keys, strconv.Quote(key.BsonPath))
+			defaultName = append(defaultName, key.BsonPath, key.Kind)
+		}
+		if name == "" {
+			name = strings.Join(defaultName, "_")
+		}
+		fields := "Name: " + strconv.Quote(name) + ",\nKeys: []string{" + strings.Join(keys, ", ") + "},\n"
+		if index.Sparse {
+			fields += "Sparse: true,\n"
+		}
+		if len(index.Partial) != 0 {
+			fields += "Partial: " + partialFilterCodegen(index.Partial) + ",\n"
+		}
+		body = append(body, code.RawStmt("if err := r.collection.CreateUniqueIndex(mongox.MemoryIndex{\n"+fields+
+			"}); err != nil {\n\treturn err\n}"))
+	}
+	body = append(body, code.RawStmt("return nil"))
+
+	return &template.MethodRender{
+		Name:    EnsureIndexesMethod.Name,
+		Comment: "// EnsureIndexes enforces the unique indexes declared by the annotations of the " + st.Name + ".",
+		MethodReceiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{
+				RealType: code.IdentType(st.Name + "RepositoryMemory"),
+			},
+		},
+		Params:     EnsureIndexesMethod.Params,
+		Returns:    EnsureIndexesMethod.Returns,
+		MethodBody: body,
+	}
+}
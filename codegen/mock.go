@@ -0,0 +1,142 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// MockRecorderImports are the imports of the mock file generated in the shared package.
+var MockRecorderImports = map[string]string{
+	"fmt":     "",
+	"reflect": "",
+	"strings": "",
+	"sync":    "",
+}
+
+// MockParamImports are the packages which may be referenced by the params of the mocks,
+// they are imported if they are used.
+var MockParamImports = []string{
+	"context",
+	"time",
+	"go.mongodb.org/mongo-driver/bson",
+	"go.mongodb.org/mongo-driver/bson/primitive",
+	"go.mongodb.org/mongo-driver/mongo",
+	"go.mongodb.org/mongo-driver/mongo/options",
+}
+
+// GetMockRender returns the mock of the repository interface, every method has the Func field which stubs it.
+func GetMockRender(st *extract.IdlExtractStruct, methods code.InterfaceMethods) *template.MockRender {
+	fields := make(code.StructFields, 0, len(methods))
+	for _, method := range methods {
+		fields = append(fields, code.StructField{
+			Name: method.Name + "Func",
+			Type: code.IdentType(mockFuncType(method)),
+		})
+	}
+	return &template.MockRender{
+		StructName: st.Name,
+		Fields:     fields,
+	}
+}
+
+// GetMockMethodRenders returns the methods of the mock and the Return methods which stub them with the values.
+func GetMockMethodRenders(st *extract.IdlExtractStruct, methods code.InterfaceMethods) []*template.MethodRender {
+	renders := make([]*template.MethodRender, 0, len(methods)*2)
+	receiver := code.MethodReceiver{
+		Name: "m",
+		Type: code.StarExprType{
+			RealType: code.IdentType(st.Name + "RepositoryMock"),
+		},
+	}
+	for _, method := range methods {
+		params := make([]string, 0, len(method.Params))
+		for _, param := range method.Params {
+			params = append(params, param.Name)
+		}
+		// the context is not recorded
+		recorded := params
+		if len(method.Params) != 0 && method.Params[0].Type.RealName() == "context.Context" {
+			recorded = params[1:]
+		}
+		variadic := ""
+		if len(method.Params) != 0 && strings.HasPrefix(method.Params[len(method.Params)-1].Type.RealName(), "...") {
+			variadic = "..."
+		}
+		zeros := make([]string, 0, len(method.Returns))
+		for _, ret := range method.Returns {
+			zeros = append(zeros, typedZeroValue(ret))
+		}
+		call := "m." + method.Name + "Func(" + strings.Join(params, ", ") + variadic + ")"
+
+		body := code.Body{
+			code.RawStmt("m.Record(" + strings.Join(append([]string{strconv.Quote(method.Name)}, recorded...), ", ") + ")"),
+		}
+		if len(method.Returns) == 0 {
+			body = append(body, code.RawStmt("if m."+method.Name+"Func != nil {\n\t"+call+"\n}"))
+		} else {
+			body = append(body,
+				code.RawStmt("if m."+method.Name+"Func != nil {\n\treturn "+call+"\n}"),
+				code.RawStmt("return "+strings.Join(zeros, ", ")),
+			)
+		}
+		renders = append(renders, &template.MethodRender{
+			Name:           method.Name,
+			MethodReceiver: receiver,
+			Params:         method.Params,
+			Returns:        method.Returns,
+			MethodBody:     body,
+		})
+
+		if len(method.Returns) == 0 {
+			continue
+		}
+		results := make([]string, 0, len(method.Returns))
+		resultParams := make(code.Params, 0, len(method.Returns))
+		for i, ret := range method.Returns {
+			result := "result" + strconv.Itoa(i)
+			results = append(results, result)
+			resultParams = append(resultParams, code.Param{Name: result, Type: ret})
+		}
+		renders = append(renders, &template.MethodRender{
+			Name:           "Return" + method.Name,
+			Comment:        "// Return" + method.Name + " stubs " + method.Name + " to return the values.",
+			MethodReceiver: receiver,
+			Params:         resultParams,
+			Returns:        code.Returns{receiver.Type},
+			MethodBody: code.Body{
+				code.RawStmt("m." + method.Name + "Func = " + mockFuncType(method) + " {\n\treturn " +
+					strings.Join(results, ", ") + "\n}"),
+				code.RawStmt("return m"),
+			},
+		})
+	}
+	return renders
+}
+
+func mockFuncType(method code.InterfaceMethod) string {
+	funcType := "func" + method.Params.GetCode()
+	if len(method.Returns) != 0 {
+		funcType += " " + method.Returns.GetCode()
+	}
+	return funcType
+}
@@ -17,12 +17,17 @@
 package codegen
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/hertz-contrib/thrift-gen-mongo/code"
 	"github.com/hertz-contrib/thrift-gen-mongo/parse"
 )
 
-func queryCodegen(query *parse.Query) code.Statement {
-	if query.QueryMode == parse.All {
+// queryCodegen returns the filter document of the query, the tenant conditions are dropped at run time
+// if routed and the repository is routed to the collection of the tenant.
+func queryCodegen(query *parse.Query, routed bool) code.Statement {
+	if query.ConnectionOpTree == nil {
 		return code.MapStmt{
 			Name: "bson.M",
 			Pair: []code.MapPair{},
@@ -31,32 +36,32 @@ func queryCodegen(query *parse.Query) code.Statement {
 		return code.MapStmt{
 			Name: "bson.M",
 			Pair: []code.MapPair{
-				dfsCodegen(query.ConnectionOpTree),
+				dfsCodegen(query.ConnectionOpTree, routed),
 			},
 		}
 	}
 }
 
-func dfsCodegen(node *parse.ConnectionOpTree) code.MapPair {
+func dfsCodegen(node *parse.ConnectionOpTree, routed bool) code.MapPair {
 	// leaves node
 	if node.LeftChildren == nil {
-		return comparatorCodegen(node)
+		return comparatorCodegen(node, routed)
 	} else {
 		// none-leaves node
 		return code.MapPair{
-			Key: code.RawStmt(node.Name),
+			Key: code.RawStmt("$" + strings.ToLower(node.Name)),
 			Value: code.SliceStmt{
 				Name: "[]bson.M",
 				Values: []code.MapPair{
-					dfsCodegen(node.LeftChildren),
-					dfsCodegen(node.RightChildren),
+					dfsCodegen(node.LeftChildren, routed),
+					dfsCodegen(node.RightChildren, routed),
 				},
 			},
 		}
 	}
 }
 
-func comparatorCodegen(node *parse.ConnectionOpTree) code.MapPair {
+func comparatorCodegen(node *parse.ConnectionOpTree, routed bool) code.MapPair {
 	switch parse.QueryComparator(node.Name) {
 	case parse.Equal:
 		return singleMapCodegen(node.MongoFieldName, node.ParamNames[0])
@@ -74,8 +79,17 @@ func comparatorCodegen(node *parse.ConnectionOpTree) code.MapPair {
 		return twoMapParamsCodegen(node.MongoFieldName, "$gte", node.ParamNames[0],
 			"$lte", node.ParamNames[1])
 	case parse.NotBetween:
-		return twoMapParamsCodegen(node.MongoFieldName, "$lt", node.ParamNames[0],
-			"$gt", node.ParamNames[1])
+		// a value out of the range is either below the lower or above the upper bound
+		return code.MapPair{
+			Key: code.RawStmt("$or"),
+			Value: code.SliceStmt{
+				Name: "[]bson.M",
+				Values: []code.MapPair{
+					oneMapParamCodegen(node.MongoFieldName, "$lt", node.ParamNames[0]),
+					oneMapParamCodegen(node.MongoFieldName, "$gt", node.ParamNames[1]),
+				},
+			},
+		}
 	case parse.In:
 		return oneMapParamCodegen(node.MongoFieldName, "$in", node.ParamNames[0])
 	case parse.NotIn:
@@ -88,6 +102,12 @@ func comparatorCodegen(node *parse.ConnectionOpTree) code.MapPair {
 		return oneMapParamCodegen(node.MongoFieldName, "$exists", "1")
 	case parse.NotExists:
 		return oneMapParamCodegen(node.MongoFieldName, "$exists", "0")
+	case parse.Tenant:
+		if routed {
+			return singleMapCodegen("$and", "mongox.RoutedTenantConditions("+node.ParamNames[0]+", "+
+				strconv.Quote(node.MongoFieldName)+", r.tenantRouted)")
+		}
+		return singleMapCodegen("$and", "mongox.TenantConditions("+node.ParamNames[0]+", "+strconv.Quote(node.MongoFieldName)+")")
 	default:
 	}
 
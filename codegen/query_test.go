@@ -0,0 +1,99 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+)
+
+func TestQueryCodegen(t *testing.T) {
+	leaf := func(name, field string, params ...string) *parse.ConnectionOpTree {
+		return &parse.ConnectionOpTree{Name: name, MongoFieldName: field, ParamNames: params}
+	}
+	node := func(name parse.QueryConnectionOp, left, right *parse.ConnectionOpTree) *parse.ConnectionOpTree {
+		return &parse.ConnectionOpTree{Name: string(name), LeftChildren: left, RightChildren: right}
+	}
+	tests := []struct {
+		name   string
+		query  *parse.Query
+		routed bool
+		want   string
+	}{
+		{
+			name:  "all",
+			query: &parse.Query{QueryMode: parse.All},
+			want:  `bson.M{}`,
+		},
+		{
+			name:  "all with a condition",
+			query: &parse.Query{QueryMode: parse.All, ConnectionOpTree: leaf(string(parse.NotEqual), "removed", "true")},
+			want:  `bson.M{"removed": bson.M{"$ne": true}}`,
+		},
+		{
+			name:  "and",
+			query: &parse.Query{QueryMode: parse.By, ConnectionOpTree: node(parse.And, leaf(string(parse.Equal), "_id", "id"), leaf(string(parse.Equal), "status", "status"))},
+			want:  `bson.M{"$and": []bson.M{{"_id": id}, {"status": status}}}`,
+		},
+		{
+			name: "or in and",
+			query: &parse.Query{QueryMode: parse.By, ConnectionOpTree: node(parse.And,
+				node(parse.Or, leaf(string(parse.Equal), "_id", "id"), leaf(string(parse.In), "status", "statuses")),
+				leaf(string(parse.In), "deleted_at", "bson.A{nil, 0}"))},
+			want: `bson.M{"$and": []bson.M{{"$or": []bson.M{{"_id": id}, {"status": bson.M{"$in": statuses}}}}, ` +
+				`{"deleted_at": bson.M{"$in": bson.A{nil, 0}}}}}`,
+		},
+		{
+			name:  "between",
+			query: &parse.Query{QueryMode: parse.By, ConnectionOpTree: leaf(string(parse.Between), "views", "low", "high")},
+			want:  `bson.M{"views": bson.M{"$gte": low, "$lte": high}}`,
+		},
+		{
+			name:  "not between",
+			query: &parse.Query{QueryMode: parse.By, ConnectionOpTree: leaf(string(parse.NotBetween), "views", "low", "high")},
+			want:  `bson.M{"$or": []bson.M{{"views": bson.M{"$lt": low}}, {"views": bson.M{"$gt": high}}}}`,
+		},
+		{
+			name:  "tenant",
+			query: &parse.Query{QueryMode: parse.By, ConnectionOpTree: node(parse.And, leaf(string(parse.Equal), "_id", "id"), leaf(string(parse.Tenant), "tenant_id", "ctx"))},
+			want:  `bson.M{"$and": []bson.M{{"_id": id}, {"$and": mongox.TenantConditions(ctx, "tenant_id")}}}`,
+		},
+		{
+			name:   "routed tenant",
+			query:  &parse.Query{QueryMode: parse.By, ConnectionOpTree: node(parse.And, leaf(string(parse.Equal), "_id", "id"), leaf(string(parse.Tenant), "tenant_id", "ctx"))},
+			routed: true,
+			want:   `bson.M{"$and": []bson.M{{"_id": id}, {"$and": mongox.RoutedTenantConditions(ctx, "tenant_id", r.tenantRouted)}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := format.Source([]byte("package video\nvar _ = " + queryCodegen(tt.query, tt.routed).Code()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the statement is compared without the whitespaces and the trailing commas of the formatting
+			got := strings.Join(strings.Fields(strings.TrimPrefix(string(source), "package video\n\nvar _ = ")), "")
+			got = strings.ReplaceAll(got, ",}", "}")
+			if got != strings.Join(strings.Fields(tt.want), "") {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// SchemaImports are the imports of the schema file generated with every repository.
+var SchemaImports = map[string]string{
+	"context":                           "",
+	"errors":                            "",
+	"go.mongodb.org/mongo-driver/bson":  "",
+	"go.mongodb.org/mongo-driver/mongo": "",
+	"go.mongodb.org/mongo-driver/mongo/options": "",
+}
+
+// GetSchemaRender returns the names and the $jsonSchema validator of the collection of the struct,
+// the constructors from the database accept the options of the repository.
+func GetSchemaRender(st *extract.IdlExtractStruct) *template.SchemaRender {
+	return &template.SchemaRender{
+		StructName:     st.Name,
+		CollectionName: st.CollectionName,
+		DatabaseName:   st.DatabaseName,
+		Schema:         objectSchema(st, false, map[*extract.IdlExtractStruct]bool{}),
+		Intercepted:    Intercepted(st),
+	}
+}
+
+// objectSchema returns the schema of the struct, the recursive struct is not expanded again.
+func objectSchema(st *extract.IdlExtractStruct, nullable bool, visiting map[*extract.IdlExtractStruct]bool) string {
+	pairs := []string{`"bsonType": ` + bsonTypes(nullable, "object")}
+	if st == nil || visiting[st] {
+		return "bson.M{" + strings.Join(pairs, ", ") + "}"
+	}
+	visiting[st] = true
+	defer delete(visiting, st)
+
+	required := make([]string, 0, len(st.StructFields))
+	properties := make([]string, 0, len(st.StructFields))
+	for _, field := range st.StructFields {
+		name := field.Tag.Get("bson")
+		if name == "" || name == "-" {
+			continue
+		}
+		if !field.OmitEmpty {
+			required = append(required, strconv.Quote(name))
+		}
+		properties = append(properties, strconv.Quote(name)+": "+fieldSchema(field, visiting))
+	}
+	if len(required) != 0 {
+		pairs = append(pairs, `"required": bson.A{`+strings.Join(required, ", ")+"}")
+	}
+	pairs = append(pairs, `"properties": bson.M{`+"\n"+strings.Join(properties, ",\n")+",\n}")
+	return "bson.M{\n" + strings.Join(pairs, ",\n") + ",\n}"
+}
+
+// fieldSchema returns the schema of the field, the nil pointers, slices and maps are encoded as null.
+func fieldSchema(field *extract.StructField, visiting map[*extract.IdlExtractStruct]bool) string {
+	nullable := field.IsPointer()
+	if validation := field.Validation; validation != nil && validation.Required {
+		nullable = false
+	}
+	if field.IsBelongedToStruct {
+		return objectSchema(field.BelongedToStruct, nullable, visiting)
+	}
+
+	t := field.Type
+	if star, ok := t.(code.StarExprType); ok {
+		t = star.RealType
+	}
+	switch t.(type) {
+	case code.SliceType, code.MapType:
+		if t.RealName() != "[]byte" {
+			nullable = field.Validation == nil || !field.Validation.Required
+		}
+	}
+	pairs := typeSchema(t, field, nullable, visiting)
+	return "bson.M{" + strings.Join(append(pairs, validationSchema(field, nullable)...), ", ") + "}"
+}
+
+func typeSchema(t code.Type, field *extract.StructField, nullable bool,
+	visiting map[*extract.IdlExtractStruct]bool,
+) []string {
+	switch t := t.(type) {
+	case code.SliceType:
+		if t.RealName() == "[]byte" {
+			return []string{`"bsonType": ` + bsonTypes(nullable, "binData")}
+		}
+		return []string{
+			`"bsonType": ` + bsonTypes(nullable, "array"),
+			`"items": ` + elementSchema(t.ElementType, field, visiting),
+		}
+	case code.MapType:
+		return []string{
+			`"bsonType": ` + bsonTypes(nullable, "object"),
+			`"additionalProperties": ` + elementSchema(t.ValueType, field, visiting),
+		}
+	case code.SelectorExprType:
+		if t.RealName() == "time.Time" {
+			return []string{`"bsonType": ` + bsonTypes(nullable, "date")}
+		}
+		// the enum, the values of the enum annotation take precedence
+		values := make([]string, 0, len(field.EnumValues))
+		if field.Validation != nil && len(field.Validation.Enum) != 0 {
+			return []string{`"bsonType": ` + bsonTypes(nullable, "int", "long")}
+		}
+		for _, value := range field.EnumValues {
+			values = append(values, strconv.FormatInt(value, 10))
+		}
+		pairs := []string{`"bsonType": ` + bsonTypes(nullable, "int", "long")}
+		if len(values) != 0 && nullable {
+			values = append(values, "nil")
+		}
+		if len(values) != 0 {
+			pairs = append(pairs, `"enum": bson.A{`+strings.Join(values, ", ")+"}")
+		}
+		return pairs
+	}
+
+	switch t.RealName() {
+	case "string":
+		return []string{`"bsonType": ` + bsonTypes(nullable, "string")}
+	case "bool":
+		return []string{`"bsonType": ` + bsonTypes(nullable, "bool")}
+	case "int8", "int16", "int32":
+		return []string{`"bsonType": ` + bsonTypes(nullable, "int")}
+	case "int64":
+		return []string{`"bsonType": ` + bsonTypes(nullable, "int", "long")}
+	case "float64":
+		return []string{`"bsonType": ` + bsonTypes(nullable, "double")}
+	default:
+		return nil
+	}
+}
+
+// elementSchema returns the schema of the elements of the list, set or map field,
+// the nested lists and maps are nullable as the fields.
+func elementSchema(t code.Type, field *extract.StructField, visiting map[*extract.IdlExtractStruct]bool) string {
+	if star, ok := t.(code.StarExprType); ok {
+		if _, ok = star.RealType.(code.SelectorExprType); ok {
+			return objectSchema(field.ElementStruct, false, visiting)
+		}
+		t = star.RealType
+	}
+	nullable := false
+	switch t.(type) {
+	case code.SliceType, code.MapType:
+		nullable = t.RealName() != "[]byte"
+	}
+	return "bson.M{" + strings.Join(typeSchema(t, field, nullable, visiting), ", ") + "}"
+}
+
+func bsonTypes(nullable bool, types ...string) string {
+	if nullable {
+		types = append(types, "null")
+	}
+	if len(types) == 1 {
+		return strconv.Quote(types[0])
+	}
+	quoted := make([]string, 0, len(types))
+	for _, t := range types {
+		quoted = append(quoted, strconv.Quote(t))
+	}
+	return "bson.A{" + strings.Join(quoted, ", ") + "}"
+}
+
+// validationSchema returns the keywords of the validation annotations of the field,
+// the enum of the nullable field allows null since it applies to all the types.
+func validationSchema(field *extract.StructField, nullable bool) []string {
+	validation := field.Validation
+	if validation == nil {
+		return nil
+	}
+
+	// the unset values of the optional fields are valid as in the Validate method, they are the alternatives
+	// of anyOf so that the other keywords keep the annotated values, the second anyOf is combined by allOf
+	unset := !validation.Required && !field.IsPointer()
+	pairs := make([]string, 0, 4)
+	anyOf := -1
+	addAnyOf := func(alternatives string) {
+		if anyOf < 0 {
+			anyOf = len(pairs)
+			pairs = append(pairs, `"anyOf": `+alternatives)
+			return
+		}
+		pairs[anyOf] = `"allOf": bson.A{bson.M{` + pairs[anyOf] + `}, bson.M{"anyOf": ` + alternatives + `}}`
+	}
+	if validation.Min != "" {
+		pairs = append(pairs, `"minimum": `+validation.Min)
+	}
+	if validation.Max != "" {
+		pairs = append(pairs, `"maximum": `+validation.Max)
+	}
+	minLen, maxLen := `"minLength"`, `"maxLength"`
+	switch {
+	case strings.HasPrefix(field.Type.RealName(), "[]"):
+		minLen, maxLen = `"minItems"`, `"maxItems"`
+	case strings.HasPrefix(field.Type.RealName(), "map["):
+		minLen, maxLen = `"minProperties"`, `"maxProperties"`
+	}
+	if validation.MinLen != "" {
+		if unset {
+			addAnyOf(`bson.A{bson.M{` + maxLen + `: 0}, bson.M{` + minLen + ": " + validation.MinLen + "}}")
+		} else {
+			pairs = append(pairs, minLen+": "+validation.MinLen)
+		}
+	}
+	if validation.MaxLen != "" {
+		pairs = append(pairs, maxLen+": "+validation.MaxLen)
+	}
+	if validation.Pattern != "" {
+		pattern := validation.Pattern
+		if unset {
+			pattern = "^$|" + pattern
+		}
+		pairs = append(pairs, `"pattern": `+strconv.Quote(pattern))
+	}
+	if len(validation.Enum) != 0 {
+		values := validation.Enum
+		if nullable {
+			values = append(values[:len(values):len(values)], "nil")
+		}
+		enum := `"enum": bson.A{` + strings.Join(values, ", ") + "}"
+		zero := "0"
+		if field.HasLen() {
+			zero = `""`
+		}
+		listed := false
+		for _, value := range validation.Enum {
+			listed = listed || value == zero
+		}
+		if unset && !listed {
+			addAnyOf(`bson.A{bson.M{` + enum + `}, bson.M{"enum": bson.A{` + zero + `}}}`)
+		} else {
+			pairs = append(pairs, enum)
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+)
+
+func TestStructSchema(t *testing.T) {
+	st := parseIDL(t, `namespace go video
+struct Author {
+    1: string Name (go.tag="bson:\"name\"")
+}
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: optional string Title (go.tag="bson:\"title,omitempty\"")
+    3: list<string> Tags (go.tag="bson:\"tags\"")
+    4: map<string, i64> Views (go.tag="bson:\"views\"")
+    5: Author Author (go.tag="bson:\"author\"")
+    6: double Score (go.tag="bson:\"score\"")
+}
+(
+    mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"
+)
+`)[0].BelongedToStruct
+	buffer := &bytes.Buffer{}
+	if err := GetSchemaRender(st).RenderObj(buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	checkCode(t, buffer.String(),
+		`"required": bson.A{"_id", "tags", "views", "author", "score"},`,
+		`"_id": bson.M{"bsonType": bson.A{"int", "long"}},`,
+		`"title": bson.M{"bsonType": bson.A{"string", "null"}},`,
+		`"tags": bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}},`,
+		`"views": bson.M{"bsonType": bson.A{"object", "null"}, "additionalProperties": bson.M{"bsonType": bson.A{"int", "long"}}},`,
+		`"bsonType": bson.A{"object", "null"},`,
+		`"required": bson.A{"name"},`,
+		`"name": bson.M{"bsonType": "string"},`,
+		`"score": bson.M{"bsonType": "double"},`)
+}
+
+func TestValidationSchema(t *testing.T) {
+	tests := []struct {
+		name       string
+		field      *extract.StructField
+		validation extract.FieldValidation
+		want       []string
+	}{
+		{
+			name:       "enum",
+			field:      &extract.StructField{Type: code.IdentType("int32")},
+			validation: extract.FieldValidation{Enum: []string{"1", "2"}},
+			want:       []string{`"anyOf": bson.A{bson.M{"enum": bson.A{1, 2}}, bson.M{"enum": bson.A{0}}}`},
+		},
+		{
+			name:       "enum with zero",
+			field:      &extract.StructField{Type: code.IdentType("int32")},
+			validation: extract.FieldValidation{Enum: []string{"0", "1"}},
+			want:       []string{`"enum": bson.A{0, 1}`},
+		},
+		{
+			name:       "required enum",
+			field:      &extract.StructField{Type: code.IdentType("string")},
+			validation: extract.FieldValidation{Required: true, Enum: []string{`"en"`}},
+			want:       []string{`"enum": bson.A{"en"}`},
+		},
+		{
+			name:       "optional enum",
+			field:      &extract.StructField{Type: code.IdentType("string"), Optional: true},
+			validation: extract.FieldValidation{Enum: []string{`"en"`}},
+			want:       []string{`"enum": bson.A{"en", nil}`},
+		},
+		{
+			name:       "pattern",
+			field:      &extract.StructField{Type: code.IdentType("string")},
+			validation: extract.FieldValidation{Pattern: "^[a-z]+$"},
+			want:       []string{`"pattern": "^$|^[a-z]+$"`},
+		},
+		{
+			name:       "required pattern",
+			field:      &extract.StructField{Type: code.IdentType("string")},
+			validation: extract.FieldValidation{Required: true, Pattern: "^[a-z]+$"},
+			want:       []string{`"pattern": "^[a-z]+$"`},
+		},
+		{
+			name:       "min len",
+			field:      &extract.StructField{Type: code.SliceType{ElementType: code.IdentType("string")}},
+			validation: extract.FieldValidation{MinLen: "1", MaxLen: "3"},
+			want:       []string{`"anyOf": bson.A{bson.M{"maxItems": 0}, bson.M{"minItems": 1}}`, `"maxItems": 3`},
+		},
+		{
+			name:       "min len enum",
+			field:      &extract.StructField{Type: code.IdentType("string")},
+			validation: extract.FieldValidation{MinLen: "2", Enum: []string{`"en"`}},
+			want: []string{
+				`"allOf": bson.A{bson.M{"anyOf": bson.A{bson.M{"maxLength": 0}, bson.M{"minLength": 2}}}, ` +
+					`bson.M{"anyOf": bson.A{bson.M{"enum": bson.A{"en"}}, bson.M{"enum": bson.A{""}}}}}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.Validation = &tt.validation
+			if got := validationSchema(tt.field, tt.field.IsPointer()); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchemaConstructors(t *testing.T) {
+	st := parseIDL(t, `namespace go video
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+}
+(
+    mongo.database = "db"
+    mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"
+)
+`)[0].BelongedToStruct
+	render := func() string {
+		buffer := &bytes.Buffer{}
+		if err := GetSchemaRender(st).RenderObj(buffer); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.String()
+	}
+
+	checkCode(t, render(),
+		"func NewVideoRepositoryFromDB(db *mongo.Database, opts ...VideoRepositoryOption) VideoRepository {",
+		"return NewVideoRepository(db.Collection(CollectionName), opts...)",
+		"func NewVideoRepositoryFromClient(client *mongo.Client, opts ...VideoRepositoryOption) VideoRepository {")
+
+	// the repository file generated before the interceptors are supported only has the collection options
+	st.Update, st.UpdateCurdFileContent = true, []byte("type VideoRepositoryMongo struct {\n\tcollection *mongo.Collection\n}")
+	checkCode(t, render(),
+		"type VideoRepositoryOption func(r *VideoRepositoryMongo)",
+		"func WithVideoCollectionOptions(opts ...*options.CollectionOptions) VideoRepositoryOption {",
+		"func NewVideoRepositoryFromDB(db *mongo.Database, opts ...VideoRepositoryOption) VideoRepository {",
+		"return NewVideoRepository(r.collection)",
+		"func NewVideoRepositoryFromClient(client *mongo.Client, opts ...VideoRepositoryOption) VideoRepository {")
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// ShardCollectionMethod is the repository method which shards the collection by the shard key annotation.
+var ShardCollectionMethod = code.InterfaceMethod{
+	Name: "ShardCollection",
+	Params: code.Params{
+		code.Param{
+			Name: "ctx",
+			Type: code.SelectorExprType{X: "context", Sel: "Context"},
+		},
+	},
+	Returns: code.Returns{code.IdentType("error")},
+}
+
+// ShardImports are the imports of the shard file generated with the repository of the struct.
+var ShardImports = map[string]string{
+	"context":                          "",
+	"go.mongodb.org/mongo-driver/bson": "",
+}
+
+// GetShardCollectionRender returns the ShardCollection method of the repository, it runs the shardCollection
+// command of the admin database, the sharding of the database must be enabled before it.
+func GetShardCollectionRender(st *extract.IdlExtractStruct) *template.MethodRender {
+	keys := make([]string, 0, len(st.ShardKey.Keys))
+	for _, key := range st.ShardKey.Keys {
+		value := key.Kind
+		if value == extract.IndexHashed {
+			value = strconv.Quote(value)
+		}
+		keys = append(keys, "{Key: "+strconv.Quote(key.BsonPath)+", Value: "+value+"}")
+	}
+	command := "bson.D{\n" +
+		"{Key: \"shardCollection\", Value: db.Name() + \".\" + r.collection.Name()},\n" +
+		"{Key: \"key\", Value: bson.D{" + strings.Join(keys, ", ") + "}},\n"
+	if st.ShardKey.Unique {
+		command += "{Key: \"unique\", Value: true},\n"
+	}
+	command += "}"
+
+	render := &template.MethodRender{
+		Name:    ShardCollectionMethod.Name,
+		Comment: "// ShardCollection shards the collection by the shard key declared by the annotation of the " + st.Name + ".",
+		MethodReceiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{
+				RealType: code.IdentType(st.Name + "RepositoryMongo"),
+			},
+		},
+		Params:  ShardCollectionMethod.Params,
+		Returns: ShardCollectionMethod.Returns,
+		MethodBody: code.Body{
+			code.RawStmt("db := r.collection.Database()"),
+			code.RawStmt("err := db.Client().Database(\"admin\").RunCommand(ctx, " + command + ").Err()"),
+			code.RawStmt("return translateError(err)"),
+		},
+	}
+	if route := tenantRouteCodegen(st, render); route != nil {
+		render.MethodBody = append(code.Body{route}, render.MethodBody...)
+	}
+	return render
+}
+
+// memoryShardCollectionCodegen returns the ShardCollection method of the in-memory repository,
+// it does nothing since the in-memory collection is not sharded.
+func memoryShardCollectionCodegen(st *extract.IdlExtractStruct) *template.MethodRender {
+	return &template.MethodRender{
+		Name:    ShardCollectionMethod.Name,
+		Comment: "// ShardCollection does nothing, the in-memory collection of the " + st.Name + " is not sharded.",
+		MethodReceiver: code.MethodReceiver{
+			Name: "r",
+			Type: code.StarExprType{
+				RealType: code.IdentType(st.Name + "RepositoryMemory"),
+			},
+		},
+		Params:     ShardCollectionMethod.Params,
+		Returns:    ShardCollectionMethod.Returns,
+		MethodBody: code.Body{code.RawStmt("return nil")},
+	}
+}
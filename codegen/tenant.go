@@ -0,0 +1,141 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// TenantImports are the imports of the tenant file generated in the shared package.
+var TenantImports = map[string]string{
+	"context":                           "",
+	"errors":                            "",
+	"go.mongodb.org/mongo-driver/bson":  "",
+	"go.mongodb.org/mongo-driver/mongo": "",
+}
+
+// TenantScoped reports whether the operations of the repository are scoped by the tenant of the context.
+func TenantScoped(st *extract.IdlExtractStruct) bool {
+	return st.TenantField != nil || st.TenantResolver
+}
+
+// TenantRouted reports whether the methods of the repository are routed to the collection of the tenant,
+// the repository file generated before the routing is supported has no resolver and is kept as it is.
+func TenantRouted(st *extract.IdlExtractStruct) bool {
+	return st.TenantResolver && Intercepted(st) &&
+		(!st.Update || tenantResolverField.Match(st.UpdateCurdFileContent))
+}
+
+var tenantResolverField = regexp.MustCompile(`\btenantResolver\s+mongox\.TenantResolver\b`)
+
+// tenantRoutedFlag reports whether the repository records that the resolver routed it to a collection
+// other than the shared one, the repository file generated before the flag is supported always filters.
+func tenantRoutedFlag(st *extract.IdlExtractStruct) bool {
+	return TenantRouted(st) && (!st.Update || tenantRoutedField.Match(st.UpdateCurdFileContent))
+}
+
+var tenantRoutedField = regexp.MustCompile(`\btenantRouted\s+bool\b`)
+
+// tenantCheckCodegen refuses to run the method if the context carries no tenant and does not bypass
+// the tenant scope, the methods without the context or the error are kept as they are.
+func tenantCheckCodegen(st *extract.IdlExtractStruct, method *template.MethodRender) {
+	if !TenantScoped(st) {
+		return
+	}
+	returns := method.Returns
+	if len(method.Params) == 0 || method.Params[0].Type.RealName() != "context.Context" ||
+		len(returns) == 0 || returns[len(returns)-1].RealName() != "error" {
+		return
+	}
+
+	results := make([]string, 0, len(returns))
+	for _, ret := range returns[:len(returns)-1] {
+		results = append(results, typedZeroValue(ret))
+	}
+	check := code.RawStmt("if err := mongox.CheckTenant(" + method.Params[0].Name + "); err != nil {\n\treturn " +
+		strings.Join(append(results, "err"), ", ") + "\n}")
+	method.MethodBody = append(code.Body{check}, method.MethodBody...)
+}
+
+// tenantRouteCodegen returns the statement which calls the method of the repository of the tenant
+// if the repository has the resolver, it is nil if the repository is not routed.
+func tenantRouteCodegen(st *extract.IdlExtractStruct, method *template.MethodRender) code.Statement {
+	if !TenantRouted(st) {
+		return nil
+	}
+
+	ctxName := method.Params[0].Name
+	params := make([]string, 0, len(method.Params))
+	for _, param := range method.Params {
+		params = append(params, param.Name)
+	}
+	variadic := ""
+	if strings.HasPrefix(method.Params[len(method.Params)-1].Type.RealName(), "...") {
+		variadic = "..."
+	}
+	results := make([]string, 0, len(method.Returns))
+	for _, ret := range method.Returns[:len(method.Returns)-1] {
+		results = append(results, typedZeroValue(ret))
+	}
+	return code.IfBlockStmt{
+		Condition: []code.Statement{code.RawStmt("r.tenantResolver != nil ")},
+		Body: code.Body{
+			code.RawStmt("tenantRepository, err := r.forTenant(" + ctxName + ")"),
+			code.RawStmt("if err != nil {\n\treturn " + strings.Join(append(results, "err"), ", ") + "\n}"),
+			code.RawStmt("return tenantRepository." + method.Name + "(" + strings.Join(params, ", ") + variadic + ")"),
+		},
+	}
+}
+
+// tenantStampCodegen stamps the tenant of the context on the document named by obj,
+// param is the method's param name of the document or the documents.
+func tenantStampCodegen(method *extract.InterfaceMethod, param, obj string) []code.Statement {
+	st := method.BelongedToStruct
+	if st.TenantField == nil || !isStructParam(method, param, st) {
+		return nil
+	}
+	return []code.Statement{
+		code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.RawStmt("tenant, ok := mongox.TenantFromContext(" + method.Params[0].Name + "); ok "),
+			},
+			Body: code.Body{code.RawStmt(obj + "." + st.TenantField.Name + " = tenant")},
+		},
+	}
+}
+
+// updatePrepareCodegen prepares the whole structure before it is updated.
+func updatePrepareCodegen(update *parse.UpdateParse) []code.Statement {
+	stmts := make([]code.Statement, 0, 4)
+	if update.UpdateStructObjName != "" {
+		stmts = append(stmts, tenantStampCodegen(update.BelongedToMethod, update.UpdateStructObjName,
+			update.UpdateStructObjName)...)
+	}
+	return append(stmts, updateTimestampCodegen(update)...)
+}
+
+// replacePrepareCodegen prepares the replacement before it is written.
+func replacePrepareCodegen(replace *parse.ReplaceParse) []code.Statement {
+	stmts := tenantStampCodegen(replace.BelongedToMethod, replace.ReplaceStructObjName, replace.ReplaceStructObjName)
+	return append(stmts, replaceTimestampCodegen(replace)...)
+}
@@ -0,0 +1,209 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+)
+
+const (
+	nowMilli = "time.Now().UnixMilli()"
+	// nowDate is truncated to the milliseconds of the bson date, the document read back equals the written one
+	nowDate = "time.Now().Truncate(time.Millisecond)"
+)
+
+// timestampNow returns the current time of the timestamp field.
+func timestampNow(field *extract.StructField) string {
+	if field.IsTime() {
+		return nowDate
+	}
+	return nowMilli
+}
+
+// timestampUnset returns the condition that the timestamp field of the obj is not set.
+func timestampUnset(obj string, field *extract.StructField) string {
+	if field.IsTime() {
+		return obj + "." + field.Name + ".IsZero() "
+	}
+	return obj + "." + field.Name + " == 0 "
+}
+
+// insertTimestampCodegen populates the timestamp fields of the document named by obj before it is inserted,
+// param is the method's param name of the document or the documents.
+func insertTimestampCodegen(insert *parse.InsertParse, param, obj string) []code.Statement {
+	st := insert.BelongedToMethod.BelongedToStruct
+	if !isStructParam(insert.BelongedToMethod, param, st) {
+		return nil
+	}
+
+	stmts := make([]code.Statement, 0, 2)
+	if st.CreatedAtField != nil {
+		stmts = append(stmts, code.RawStmt(obj+"."+st.CreatedAtField.Name+" = "+timestampNow(st.CreatedAtField)))
+		if st.UpdatedAtField != nil {
+			stmts = append(stmts, code.RawStmt(obj+"."+st.UpdatedAtField.Name+" = "+obj+"."+st.CreatedAtField.Name))
+		}
+	} else if st.UpdatedAtField != nil {
+		stmts = append(stmts, code.RawStmt(obj+"."+st.UpdatedAtField.Name+" = "+timestampNow(st.UpdatedAtField)))
+	}
+	return stmts
+}
+
+// updateTimestampCodegen populates the updated-at field of the whole structure before it is updated,
+// the upsert of the whole structure builds its update document in advance, the created-at timestamp
+// is only set on insert when the structure does not carry one, otherwise $set and $setOnInsert conflict.
+func updateTimestampCodegen(update *parse.UpdateParse) []code.Statement {
+	st := update.BelongedToMethod.BelongedToStruct
+	obj := update.UpdateStructObjName
+	if obj == "" || !isStructParam(update.BelongedToMethod, obj, st) {
+		return nil
+	}
+
+	stmts := make([]code.Statement, 0, 3)
+	if st.UpdatedAtField != nil {
+		stmts = append(stmts, code.RawStmt(obj+"."+st.UpdatedAtField.Name+" = "+timestampNow(st.UpdatedAtField)))
+	}
+	if update.Upsert && st.CreatedAtField != nil {
+		now := code.RawStmt(timestampNow(st.CreatedAtField))
+		if st.UpdatedAtField != nil {
+			now = code.RawStmt(obj + "." + st.UpdatedAtField.Name)
+		}
+		stmts = append(stmts,
+			code.DeclColonStmt{
+				Left: code.ListCommaStmt{code.RawStmt(upsertDocName(update))},
+				Right: code.MapStmt{
+					Name: "bson.M",
+					Pair: []code.MapPair{
+						{
+							Key:   code.RawStmt("$set"),
+							Value: code.RawStmt(obj),
+						},
+					},
+				},
+			},
+			code.IfBlockStmt{
+				Condition: []code.Statement{
+					code.RawStmt(timestampUnset(obj, st.CreatedAtField)),
+				},
+				Body: code.Body{
+					code.RawStmt(upsertDocName(update) + "[\"$setOnInsert\"] = " + code.MapStmt{
+						Name: "bson.M",
+						Pair: []code.MapPair{
+							{
+								Key:   code.RawStmt(st.CreatedAtField.Tag.Get("bson")),
+								Value: now,
+							},
+						},
+					}.Code()),
+				},
+			},
+		)
+	}
+	return stmts
+}
+
+// replaceTimestampCodegen populates the updated-at field of the replacement, the replacement keeps
+// the created-at timestamp carried by the structure, the upsert sets it when the structure does not carry one.
+func replaceTimestampCodegen(replace *parse.ReplaceParse) []code.Statement {
+	st := replace.BelongedToMethod.BelongedToStruct
+	obj := replace.ReplaceStructObjName
+	if !isStructParam(replace.BelongedToMethod, obj, st) {
+		return nil
+	}
+
+	stmts := make([]code.Statement, 0, 2)
+	if st.UpdatedAtField != nil {
+		stmts = append(stmts, code.RawStmt(obj+"."+st.UpdatedAtField.Name+" = "+timestampNow(st.UpdatedAtField)))
+	}
+	if replace.Upsert && st.CreatedAtField != nil {
+		now := code.RawStmt(timestampNow(st.CreatedAtField))
+		if st.UpdatedAtField != nil {
+			now = code.RawStmt(obj + "." + st.UpdatedAtField.Name)
+		}
+		stmts = append(stmts, code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.RawStmt(timestampUnset(obj, st.CreatedAtField)),
+			},
+			Body: code.Body{
+				code.RawStmt(obj + "." + st.CreatedAtField.Name + " = " + now.Code()),
+			},
+		})
+	}
+	return stmts
+}
+
+// updateTimestampPairs adds the timestamp fields into the update document of the field updates.
+func updateTimestampPairs(update *parse.UpdateParse, pairs []code.MapPair) []code.MapPair {
+	st := update.BelongedToMethod.BelongedToStruct
+	if st.UpdatedAtField != nil && !hasUpdateField(update, st.UpdatedAtField) {
+		setPairs := pairs[0].Value.(code.MapStmt)
+		setPairs.Pair = append(setPairs.Pair, code.MapPair{
+			Key:   code.RawStmt(st.UpdatedAtField.Tag.Get("bson")),
+			Value: code.RawStmt(timestampNow(st.UpdatedAtField)),
+		})
+		pairs[0].Value = setPairs
+	}
+	if update.Upsert && st.CreatedAtField != nil && !hasUpdateField(update, st.CreatedAtField) {
+		pairs = append(pairs, code.MapPair{
+			Key: code.RawStmt("$setOnInsert"),
+			Value: code.MapStmt{
+				Name: "bson.M",
+				Pair: []code.MapPair{
+					{
+						Key:   code.RawStmt(st.CreatedAtField.Tag.Get("bson")),
+						Value: code.RawStmt(timestampNow(st.CreatedAtField)),
+					},
+				},
+			},
+		})
+	}
+	return pairs
+}
+
+// upsertDocName returns the variable name of the update document built in advance,
+// it is empty if the update document is generated inline.
+func upsertDocName(update *parse.UpdateParse) string {
+	st := update.BelongedToMethod.BelongedToStruct
+	if !update.Upsert || st.CreatedAtField == nil || update.UpdateStructObjName == "" ||
+		!isStructParam(update.BelongedToMethod, update.UpdateStructObjName, st) {
+		return ""
+	}
+	return update.UpdateStructObjName + "Update"
+}
+
+func hasUpdateField(update *parse.UpdateParse, field *extract.StructField) bool {
+	for _, updateField := range update.UpdateFields {
+		if updateField.MongoFieldName == field.Tag.Get("bson") {
+			return true
+		}
+	}
+	return false
+}
+
+// isStructParam reports whether the param is the struct of the repository or the slice of it,
+// the operations of a transaction may write the documents of other collections.
+func isStructParam(method *extract.InterfaceMethod, name string, st *extract.IdlExtractStruct) bool {
+	for _, param := range method.Params {
+		if param.Name == name {
+			return strings.HasSuffix(param.Type.RealName(), "."+st.Name)
+		}
+	}
+	return false
+}
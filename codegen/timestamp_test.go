@@ -0,0 +1,98 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"testing"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+const timestampIDL = `namespace go video
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: string Title (go.tag="bson:\"title\"")
+    3: i32 Status (go.tag="bson:\"status\"")
+    4: i64 CreatedAt (go.tag="bson:\"created_at,omitempty\"")
+    5: i64 UpdatedAt (go.tag="bson:\"updated_at\"")
+}
+(
+    mongo.created_at = "CreatedAt"
+    mongo.updated_at = "UpdatedAt"
+    mongo.InsertOne = "InsertOne(ctx context.Context, v *video.Video) (interface{}, error)"
+    mongo.UpdateUpsertTitleByIdEqual = "UpsertTitle(ctx context.Context, title string, id int64) (bool, error)"
+    mongo.UpdateUpsertByStatusEqual = "UpsertByStatus(ctx context.Context, v *video.Video, status int32) (int, error)"
+)
+`
+
+func TestTimestampCodegen(t *testing.T) {
+	tests := []struct {
+		name         string
+		date         bool
+		insert       []string
+		upsertTitle  []string
+		upsertStruct []string
+		bulk         [2]string
+	}{
+		{
+			name:         "milliseconds",
+			insert:       []string{"v.CreatedAt = time.Now().UnixMilli()", "v.UpdatedAt = v.CreatedAt"},
+			upsertTitle:  []string{`"updated_at": time.Now().UnixMilli(),`, `"created_at": time.Now().UnixMilli(),`},
+			upsertStruct: []string{"v.UpdatedAt = time.Now().UnixMilli()", "if v.CreatedAt == 0 {", `"created_at": v.UpdatedAt,`},
+			bulk:         [2]string{"time.Now().UnixMilli()", "doc.CreatedAt == 0"},
+		},
+		{
+			name:   "dates",
+			date:   true,
+			insert: []string{"v.CreatedAt = time.Now().Truncate(time.Millisecond)", "v.UpdatedAt = v.CreatedAt"},
+			upsertTitle: []string{
+				`"updated_at": time.Now().Truncate(time.Millisecond),`,
+				`"created_at": time.Now().Truncate(time.Millisecond),`,
+			},
+			upsertStruct: []string{
+				"v.UpdatedAt = time.Now().Truncate(time.Millisecond)",
+				"if v.CreatedAt.IsZero() {",
+				`"created_at": v.UpdatedAt,`,
+			},
+			bulk: [2]string{"time.Now().Truncate(time.Millisecond)", "doc.CreatedAt.IsZero()"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operations := parseIDL(t, timestampIDL)
+			if tt.date {
+				st := operations[0].BelongedToStruct
+				st.CreatedAtField.Type = code.SelectorExprType{X: "time", Sel: "Time"}
+				st.UpdatedAtField.Type = code.SelectorExprType{X: "time", Sel: "Time"}
+			}
+			methods := HandleCodegen(operations, false)[0]
+			render := func(name string) string {
+				return renderMethods(t, []*template.MethodRender{findMethod(t, methods, name)})
+			}
+
+			checkCode(t, render("insertOne"), tt.insert...)
+			checkCode(t, render("upsertTitle"), tt.upsertTitle...)
+			checkCode(t, render("upsertByStatus"), tt.upsertStruct...)
+
+			bulk := GetBulkBuilderRender(operations[0].BelongedToStruct, false)
+			if got := [2]string{bulk.TimestampNow, bulk.CreatedAtUnset}; got != tt.bulk {
+				t.Errorf("got bulk timestamps %v, want %v", got, tt.bulk)
+			}
+		})
+	}
+}
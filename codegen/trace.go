@@ -0,0 +1,67 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// TraceImports are the imports of the trace file generated in the shared package.
+var TraceImports = map[string]string{
+	"context":                          "",
+	"encoding/json":                    "",
+	"go.mongodb.org/mongo-driver/bson": "",
+}
+
+// OtelImports are the imports of the OpenTelemetry adapter, the mongox package is added by the caller.
+var OtelImports = map[string]string{
+	"context":                            "",
+	"go.opentelemetry.io/otel":           "",
+	"go.opentelemetry.io/otel/attribute": "",
+	"go.opentelemetry.io/otel/codes":     "",
+	"go.opentelemetry.io/otel/trace":     "",
+}
+
+// traceCountsCodegen records the matched and modified documents of the update and delete methods
+// and the result counts of the bulk methods on the span, the statement is inserted before the return
+// of the method.
+func traceCountsCodegen(operation parse.Operation, method *template.MethodRender) {
+	record, ctxName, counts := "mongox.RecordCounts(", "", ""
+	switch operation := operation.(type) {
+	case *parse.UpdateParse:
+		ctxName, counts = operation.CtxParamName, "result.MatchedCount, result.ModifiedCount"
+	case *parse.DeleteParse:
+		ctxName, counts = operation.CtxParamName, "result.DeletedCount, result.DeletedCount"
+		if _, ok := softDeleteCodegen(operation); ok {
+			counts = "result.MatchedCount, result.ModifiedCount"
+		}
+	case *parse.BulkParse:
+		record, ctxName = "mongox.RecordBulkCounts(", operation.CtxParamName
+		counts = "result.InsertedCount, result.MatchedCount, result.ModifiedCount, result.DeletedCount, result.UpsertedCount"
+	default:
+		return
+	}
+
+	body := method.MethodBody
+	if len(body) == 0 {
+		return
+	}
+	method.MethodBody = append(body[:len(body)-1:len(body)-1],
+		code.RawStmt(record+ctxName+", "+counts+")"), body[len(body)-1])
+}
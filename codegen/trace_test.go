@@ -0,0 +1,47 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"testing"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+func TestTraceCountsCodegen(t *testing.T) {
+	operations := parseIDL(t, `namespace go video
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: string Title (go.tag="bson:\"title\"")
+}
+(
+    mongo.UpdateTitleByIdEqual = "UpdateTitle(ctx context.Context, title string, id int64) (bool, error)"
+    mongo.HardDeleteByIdEqual = "DeleteById(ctx context.Context, id int64) (bool, error)"
+    mongo.BulkInsertOneDeleteOneByIdEqual = "Write(ctx context.Context, v *video.Video, id int64) (*mongo.BulkWriteResult, error)"
+)
+`)
+	methods := HandleCodegen(operations, true)[0]
+	tests := map[string]string{
+		"updateTitle": "mongox.RecordCounts(ctx, result.MatchedCount, result.ModifiedCount)",
+		"deleteById":  "mongox.RecordCounts(ctx, result.DeletedCount, result.DeletedCount)",
+		"write": "mongox.RecordBulkCounts(ctx, result.InsertedCount, result.MatchedCount, result.ModifiedCount, " +
+			"result.DeletedCount, result.UpsertedCount)",
+	}
+	for name, record := range tests {
+		checkCode(t, renderMethods(t, []*template.MethodRender{findMethod(t, methods, name)}), record)
+	}
+}
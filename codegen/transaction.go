@@ -17,90 +17,290 @@
 package codegen
 
 import (
+	"strconv"
+
 	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
 	"github.com/hertz-contrib/thrift-gen-mongo/parse"
 )
 
-func taCodegen(transaction *parse.TransactionParse) []code.Statement {
-	taOperations := taOperationsCodegen(transaction)
-	body := code.Body{
-		code.RawStmt("if err := sessionContext.StartTransaction(); err != nil {\n\treturn err\n}\n"),
-	}
-	for _, taOperation := range taOperations {
-		body = append(body, taOperation)
-		body = append(body, code.RawStmt("\n"))
+// TransactionImports are the imports of the transaction file generated with the repository.
+var TransactionImports = map[string]string{
+	"context":                           "",
+	"errors":                            "",
+	"fmt":                               "",
+	"go.mongodb.org/mongo-driver/mongo": "",
+	"go.mongodb.org/mongo-driver/mongo/options": "",
+}
+
+// UnitOfWorkImports are the imports of the unit of work file generated in the shared package.
+var UnitOfWorkImports = map[string]string{
+	"context":                           "",
+	"errors":                            "",
+	"sync":                              "",
+	"go.mongodb.org/mongo-driver/mongo": "",
+	"go.mongodb.org/mongo-driver/mongo/options": "",
+}
+
+// DefaultTransactionMaxAttempts is the default value of the generated mongox.DefaultMaxAttempts.
+const DefaultTransactionMaxAttempts = 3
+
+// taCodegen runs the operations by runTransaction, which retries them on the transient transaction errors,
+// the operations return the errors without aborting, the transaction is aborted by mongox.WithTransaction.
+func taCodegen(transaction *parse.TransactionParse, routed bool) []code.Statement {
+	body := taBodyCodegen(transaction, routed, collectionSequence)
+	stmts := taValidateCodegen(transaction)
+	sessionOptions, transactionOptions := code.RawStmt("nil"), code.RawStmt("nil")
+	method := transaction.BelongedToMethod
+	if options := method.BelongedToStruct.TransactionOptions[method.Name]; options != nil {
+		var maxCommitTime []code.Statement
+		sessionOptions, transactionOptions, maxCommitTime = taOptionsCodegen(options)
+		stmts = append(stmts, maxCommitTime...)
 	}
-	body = append(body, code.RawStmt("return sessionContext.CommitTransaction(context.Background())"))
 
-	return []code.Statement{
-		code.IfBlockStmt{
-			Condition: []code.Statement{
-				code.DeclColonStmt{
-					Left: code.ListCommaStmt{
-						code.RawStmt("err"),
-					},
-					Right: code.CallStmt{
-						Caller:   code.RawStmt(transaction.ClientParamName),
-						CallName: "UseSession",
-						Args: code.ListCommaStmt{
-							code.RawStmt(transaction.CtxParamName),
-							code.AnonymousFuncStmt{
-								Params: code.Params{
-									code.Param{
-										Name: "sessionContext",
-										Type: code.SelectorExprType{
-											X:   "mongo",
-											Sel: "SessionContext",
-										},
-									},
+	return append(stmts, code.ReturnStmt{
+		ListCommaStmt: code.ListCommaStmt{
+			code.CallStmt{
+				CallName: "runTransaction",
+				Args: code.ListCommaStmt{
+					code.RawStmt(transaction.CtxParamName),
+					code.RawStmt(transaction.ClientParamName),
+					sessionOptions,
+					transactionOptions,
+					code.AnonymousFuncStmt{
+						Params: code.Params{
+							code.Param{
+								Name: "sessionContext",
+								Type: code.SelectorExprType{
+									X:   "mongo",
+									Sel: "SessionContext",
 								},
-								Returns: code.Returns{
-									code.IdentType("error"),
-								},
-								Body: body,
 							},
 						},
+						Returns: code.Returns{
+							code.IdentType("error"),
+						},
+						Body: body,
 					},
 				},
-				code.RawStmt("; err != nil "),
-			},
-			Body: code.Body{
-				code.RawStmt("return err"),
 			},
 		},
-		code.RawStmt("return nil"),
+	})
+}
+
+// taBodyCodegen returns the body of the function which runs the steps of the transaction.
+func taBodyCodegen(transaction *parse.TransactionParse, routed bool,
+	reserver func(collection string) sequenceReserver,
+) code.Body {
+	body := code.Body{}
+	for _, step := range taOperationsCodegen(transaction, routed, reserver) {
+		// the steps are separated by a blank line
+		body = append(append(body, step...), code.RawStmt(""))
 	}
+	return append(body, code.RawStmt("return nil"))
 }
 
-func taOperationsCodegen(transaction *parse.TransactionParse) []code.Statement {
-	operations := make([]code.Statement, 0, 10)
-	for _, operation := range transaction.TransactionOperations {
+// taOptionsCodegen returns the session options and the transaction options of the Transaction method,
+// the max commit time is declared in advance since SetMaxCommitTime takes its address.
+func taOptionsCodegen(options *extract.TransactionOptions) (code.RawStmt, code.RawStmt, []code.Statement) {
+	sessionOptions := code.RawStmt("nil")
+	if options.CausalConsistency != nil {
+		sessionOptions = code.RawStmt("options.Session().SetCausalConsistency(" +
+			strconv.FormatBool(*options.CausalConsistency) + ")")
+	}
+
+	var stmts []code.Statement
+	setters := ""
+	if options.ReadConcern != "" {
+		setters += ".SetReadConcern(readconcern." + options.ReadConcernFunc() + "())"
+	}
+	if options.WriteConcern == "majority" {
+		setters += ".SetWriteConcern(writeconcern.Majority())"
+	} else if options.WriteConcern != "" {
+		setters += ".SetWriteConcern(&writeconcern.WriteConcern{W: " + options.WriteConcern + "})"
+	}
+	if options.ReadPreference != "" {
+		setters += ".SetReadPreference(readpref." + options.ReadPreferenceFunc() + "())"
+	}
+	if options.MaxCommitTime != 0 {
+		stmts = append(stmts, code.RawStmt("maxCommitTime := "+
+			strconv.FormatInt(options.MaxCommitTime.Milliseconds(), 10)+" * time.Millisecond"))
+		setters += ".SetMaxCommitTime(&maxCommitTime)"
+	}
+	transactionOptions := code.RawStmt("nil")
+	if setters != "" {
+		transactionOptions = code.RawStmt("options.Transaction()" + setters)
+	}
+	return sessionOptions, transactionOptions, stmts
+}
+
+// taOperationsCodegen returns the statements of every step of the transaction,
+// reserver returns the reserver of the ids of the sequence in the collection of the step.
+func taOperationsCodegen(transaction *parse.TransactionParse, routed bool,
+	reserver func(collection string) sequenceReserver,
+) [][]code.Statement {
+	operations := make([][]code.Statement, 0, len(transaction.TransactionOperations))
+	for index, operation := range transaction.TransactionOperations {
+		if operation.Operation.GetOperationName() == parse.Find {
+			operations = append(operations, taFindCodegen(operation, index+1, routed))
+		}
+		if operation.Operation.GetOperationName() == parse.Count {
+			operations = append(operations, taCountCodegen(operation, index+1, routed))
+		}
 		if operation.Operation.GetOperationName() == parse.Insert {
-			operations = append(operations, taInsertCodegen(operation)...)
+			operations = append(operations, taInsertCodegen(operation, reserver(operation.CollectionParamName)))
 		}
 		if operation.Operation.GetOperationName() == parse.Update {
-			operations = append(operations, taUpdateCodegen(operation))
+			operations = append(operations, taUpdateCodegen(operation, routed))
 		}
 		if operation.Operation.GetOperationName() == parse.Delete {
-			operations = append(operations, taDeleteCodegen(operation))
+			operations = append(operations, []code.Statement{taDeleteCodegen(operation, routed)})
 		}
 		if operation.Operation.GetOperationName() == parse.Bulk {
-			operations = append(operations, taBulkCodegen(operation)...)
+			operations = append(operations, taBulkCodegen(operation, routed, reserver(operation.CollectionParamName)))
 		}
 	}
 	return operations
 }
 
-func taInsertCodegen(tsOperation parse.TransactionOperation) []code.Statement {
+// taFindCodegen reads the documents of the Find step into the result, the Find One step which found
+// no document and the guard which returned an error abort the transaction with *TransactionAbortError.
+func taFindCodegen(tsOperation parse.TransactionOperation, step int, routed bool) []code.Statement {
+	find := tsOperation.Operation.(*parse.FindParse)
+	st := find.BelongedToMethod.BelongedToStruct
+	model := st.ModelPackage + "." + st.Name
+	if find.OperateMode == parse.OperateOne {
+		return append([]code.Statement{
+			code.RawStmt(tsOperation.ResultName + " := &" + model + "{}"),
+			code.IfBlockStmt{
+				Condition: []code.Statement{
+					code.RawStmt("err := "),
+					code.CallStmt{
+						Caller: code.CallStmt{
+							Caller:   code.RawStmt(tsOperation.CollectionParamName),
+							CallName: "FindOne",
+							Args: code.ListCommaStmt{
+								code.RawStmt("sessionContext"),
+								queryCodegen(find.Query, routed),
+							},
+						},
+						CallName: "Decode",
+						Args: code.ListCommaStmt{
+							code.RawStmt(tsOperation.ResultName),
+						},
+					},
+					code.RawStmt("; err != nil "),
+				},
+				Body: code.Body{
+					code.RawStmt("return readError(" + strconv.Itoa(step) + ", \"Find One\", err)"),
+				},
+			},
+		}, taGuardCodegen(tsOperation, step, "Find One", tsOperation.ResultName)...)
+	}
+
+	cursor := "cursor" + strconv.Itoa(step)
+	return append([]code.Statement{
+		code.DeclColonStmt{
+			Left: code.ListCommaStmt{
+				code.RawStmt(cursor),
+				code.RawStmt("err"),
+			},
+			Right: code.CallStmt{
+				Caller:   code.RawStmt(tsOperation.CollectionParamName),
+				CallName: "Find",
+				Args: code.ListCommaStmt{
+					code.RawStmt("sessionContext"),
+					queryCodegen(find.Query, routed),
+				},
+			},
+		},
+		code.RawStmt("if err != nil {\n\treturn err\n}"),
+		code.DeclVarStmt{
+			Name: tsOperation.ResultName,
+			Type: code.SliceType{ElementType: code.IdentType("*" + model)},
+		},
+		code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.RawStmt("err = " + cursor + ".All(sessionContext, &" + tsOperation.ResultName + ")"),
+				code.RawStmt("; err != nil "),
+			},
+			Body: code.Body{
+				code.RawStmt("return err"),
+			},
+		},
+	}, taGuardCodegen(tsOperation, step, "Find Many", tsOperation.ResultName)...)
+}
+
+func taCountCodegen(tsOperation parse.TransactionOperation, step int, routed bool) []code.Statement {
+	count := tsOperation.Operation.(*parse.CountParse)
+	var stmts []code.Statement
+	ctxName := "sessionContext"
+	if count.MaxTimeParamName != "" {
+		ctxName = tsOperation.ResultName + "Context"
+		stmts = append(stmts, code.RawStmt(ctxName+" := context.Context(sessionContext)"))
+		stmts = append(stmts, maxTimeCodegen(ctxName, count.MaxTimeParamName)...)
+	}
+	args := code.ListCommaStmt{
+		code.RawStmt(ctxName),
+		queryCodegen(count.Query, routed),
+	}
+	if countOptions := countOptionsCodegen(count); countOptions != nil {
+		args = append(args, countOptions)
+	}
+	return append(append(stmts,
+		code.DeclColonStmt{
+			Left: code.ListCommaStmt{
+				code.RawStmt(tsOperation.ResultName),
+				code.RawStmt("err"),
+			},
+			Right: code.CallStmt{
+				Caller:   code.RawStmt(tsOperation.CollectionParamName),
+				CallName: "CountDocuments",
+				Args:     args,
+			},
+		},
+		code.RawStmt("if err != nil {\n\treturn err\n}"),
+	), taGuardCodegen(tsOperation, step, "Count", "int("+tsOperation.ResultName+")")...)
+}
+
+func taGuardCodegen(tsOperation parse.TransactionOperation, step int, operation, result string) []code.Statement {
+	if tsOperation.GuardParamName == "" {
+		return nil
+	}
+	return []code.Statement{
+		code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.RawStmt("err := " + tsOperation.GuardParamName + "(" + result + ")"),
+				code.RawStmt("; err != nil "),
+			},
+			Body: code.Body{
+				code.RawStmt("return &TransactionAbortError{Step: " + strconv.Itoa(step) + ", Operation: \"" +
+					operation + "\", Err: err}"),
+			},
+		},
+	}
+}
+
+func taInsertCodegen(tsOperation parse.TransactionOperation, reserve sequenceReserver) []code.Statement {
 	insert := tsOperation.Operation.(*parse.InsertParse)
 	if insert.OperateMode == parse.OperateOne {
-		return getInsertCode(tsOperation, insert, "InsertOne", insert.MethodParamNames[0])
+		return getInsertCode(tsOperation, insert, "InsertOne", insert.MethodParamNames[0], reserve)
 	} else {
-		return getInsertCode(tsOperation, insert, "InsertMany", "entities")
+		return getInsertCode(tsOperation, insert, "InsertMany", "entities", reserve)
 	}
 }
 
-func getInsertCode(tsOperation parse.TransactionOperation, insert *parse.InsertParse, callName, param string) []code.Statement {
+func getInsertCode(tsOperation parse.TransactionOperation, insert *parse.InsertParse, callName, param string,
+	reserve sequenceReserver,
+) []code.Statement {
+	args := code.ListCommaStmt{
+		code.RawStmt("sessionContext"),
+		code.RawStmt(param),
+	}
+	if insert.OperateMode == parse.OperateMany {
+		args = insertManyArgsCodegen(insert, "sessionContext")
+	}
+
 	baseInsertCode := code.IfBlockStmt{
 		Condition: []code.Statement{
 			code.DeclColonStmt{
@@ -111,23 +311,22 @@ func getInsertCode(tsOperation parse.TransactionOperation, insert *parse.InsertP
 				Right: code.CallStmt{
 					Caller:   code.RawStmt(tsOperation.CollectionParamName),
 					CallName: callName,
-					Args: code.ListCommaStmt{
-						code.RawStmt("sessionContext"),
-						code.RawStmt(param),
-					},
+					Args:     args,
 				},
 			},
 			code.RawStmt("; err != nil "),
 		},
 		Body: code.Body{
-			code.RawStmt(abortTa),
+			code.RawStmt("return err"),
 		},
 	}
 
 	if insert.OperateMode == parse.OperateOne {
-		return []code.Statement{baseInsertCode}
+		stmts := append(insertPrepareCodegen(insert, param, param),
+			sequenceCodegen(insert, param, reserve, "return err")...)
+		return append(stmts, baseInsertCode)
 	} else {
-		return []code.Statement{
+		return append(sequenceCodegen(insert, insert.MethodParamNames[0], reserve, "return err"),
 			code.DeclVarStmt{
 				Name: "entities",
 				Type: code.SliceType{
@@ -137,25 +336,24 @@ func getInsertCode(tsOperation parse.TransactionOperation, insert *parse.InsertP
 			code.ForRangeBlockStmt{
 				RangeName: insert.MethodParamNames[0],
 				Value:     "model",
-				Body: []code.Statement{
-					code.RawStmt("entities = append(entities, model)"),
-				},
+				Body: append(insertPrepareCodegen(insert, insert.MethodParamNames[0], "model"),
+					code.RawStmt("entities = append(entities, model)")),
 			},
 			baseInsertCode,
-		}
+		)
 	}
 }
 
-func taUpdateCodegen(tsOperation parse.TransactionOperation) code.Statement {
+func taUpdateCodegen(tsOperation parse.TransactionOperation, routed bool) []code.Statement {
 	update := tsOperation.Operation.(*parse.UpdateParse)
 	if update.OperateMode == parse.OperateOne {
-		return getUpdateCode(tsOperation, update, "UpdateOne")
+		return append(updatePrepareCodegen(update), getUpdateCode(tsOperation, update, "UpdateOne", routed))
 	} else {
-		return getUpdateCode(tsOperation, update, "UpdateMany")
+		return append(updatePrepareCodegen(update), getUpdateCode(tsOperation, update, "UpdateMany", routed))
 	}
 }
 
-func getUpdateCode(tsOperation parse.TransactionOperation, update *parse.UpdateParse, callName string) code.Statement {
+func getUpdateCode(tsOperation parse.TransactionOperation, update *parse.UpdateParse, callName string, routed bool) code.Statement {
 	chainCall := make(code.ChainStmt, 0, 5)
 	return code.IfBlockStmt{
 		Condition: []code.Statement{
@@ -169,7 +367,7 @@ func getUpdateCode(tsOperation parse.TransactionOperation, update *parse.UpdateP
 					CallName: callName,
 					Args: code.ListCommaStmt{
 						code.RawStmt("sessionContext"),
-						queryCodegen(update.Query),
+						queryCodegen(update.Query, routed),
 						updateFieldsCodegen(update),
 						chainCall.ChainCall(code.Chain{
 							CallName: "options.Update",
@@ -186,21 +384,56 @@ func getUpdateCode(tsOperation parse.TransactionOperation, update *parse.UpdateP
 			code.RawStmt("; err != nil "),
 		},
 		Body: code.Body{
-			code.RawStmt(abortTa),
+			code.RawStmt("return err"),
 		},
 	}
 }
 
-func taDeleteCodegen(tsOperation parse.TransactionOperation) code.Statement {
+func taDeleteCodegen(tsOperation parse.TransactionOperation, routed bool) code.Statement {
 	del := tsOperation.Operation.(*parse.DeleteParse)
+	if update, ok := softDeleteCodegen(del); ok {
+		if del.OperateMode == parse.OperateOne {
+			return getTaSoftDeleteCode(tsOperation, del, update, "UpdateOne", routed)
+		} else {
+			return getTaSoftDeleteCode(tsOperation, del, update, "UpdateMany", routed)
+		}
+	}
+
 	if del.OperateMode == parse.OperateOne {
-		return getTaDeleteCode(tsOperation, del, "DeleteOne")
+		return getTaDeleteCode(tsOperation, del, "DeleteOne", routed)
 	} else {
-		return getTaDeleteCode(tsOperation, del, "DeleteMany")
+		return getTaDeleteCode(tsOperation, del, "DeleteMany", routed)
+	}
+}
+
+func getTaDeleteCode(tsOperation parse.TransactionOperation, del *parse.DeleteParse, callName string, routed bool) code.Statement {
+	return code.IfBlockStmt{
+		Condition: []code.Statement{
+			code.DeclColonStmt{
+				Left: code.ListCommaStmt{
+					code.RawStmt("_"),
+					code.RawStmt("err"),
+				},
+				Right: code.CallStmt{
+					Caller:   code.RawStmt(tsOperation.CollectionParamName),
+					CallName: callName,
+					Args: code.ListCommaStmt{
+						code.RawStmt("sessionContext"),
+						queryCodegen(del.Query, routed),
+					},
+				},
+			},
+			code.RawStmt("; err != nil "),
+		},
+		Body: code.Body{
+			code.RawStmt("return err"),
+		},
 	}
 }
 
-func getTaDeleteCode(tsOperation parse.TransactionOperation, del *parse.DeleteParse, callName string) code.Statement {
+func getTaSoftDeleteCode(tsOperation parse.TransactionOperation, del *parse.DeleteParse, update code.MapStmt,
+	callName string, routed bool,
+) code.Statement {
 	return code.IfBlockStmt{
 		Condition: []code.Statement{
 			code.DeclColonStmt{
@@ -213,22 +446,23 @@ func getTaDeleteCode(tsOperation parse.TransactionOperation, del *parse.DeletePa
 					CallName: callName,
 					Args: code.ListCommaStmt{
 						code.RawStmt("sessionContext"),
-						queryCodegen(del.Query),
+						queryCodegen(del.Query, routed),
+						update,
 					},
 				},
 			},
 			code.RawStmt("; err != nil "),
 		},
 		Body: code.Body{
-			code.RawStmt(abortTa),
+			code.RawStmt("return err"),
 		},
 	}
 }
 
-func taBulkCodegen(tsOperation parse.TransactionOperation) []code.Statement {
+func taBulkCodegen(tsOperation parse.TransactionOperation, routed bool, reserve sequenceReserver) []code.Statement {
 	bulk := tsOperation.Operation.(*parse.BulkParse)
 
-	return []code.Statement{
+	return append(bulkPrepareCodegen(bulk, reserve, "return err"), []code.Statement{
 		code.DeclVarStmt{
 			Name: "models",
 			Type: code.SliceType{
@@ -238,7 +472,7 @@ func taBulkCodegen(tsOperation parse.TransactionOperation) []code.Statement {
 				},
 			},
 		},
-		bulkOperationsCodegen(bulk),
+		bulkOperationsCodegen(bulk, routed),
 		code.IfBlockStmt{
 			Condition: []code.Statement{
 				code.DeclColonStmt{
@@ -249,22 +483,14 @@ func taBulkCodegen(tsOperation parse.TransactionOperation) []code.Statement {
 					Right: code.CallStmt{
 						Caller:   code.RawStmt(tsOperation.CollectionParamName),
 						CallName: "BulkWrite",
-						Args: code.ListCommaStmt{
-							code.RawStmt("sessionContext"),
-							code.RawStmt("models"),
-						},
+						Args:     bulkWriteArgsCodegen(bulk, "sessionContext"),
 					},
 				},
 				code.RawStmt("; err != nil "),
 			},
 			Body: code.Body{
-				code.RawStmt(abortTa),
+				code.RawStmt("return err"),
 			},
 		},
-	}
-}
-
-var abortTa = `if err = sessionContext.AbortTransaction(context.Background()); err != nil {
-    return err
+	}...)
 }
-return err`
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+func TestTransactionSteps(t *testing.T) {
+	operations := parseIDL(t, `namespace go video
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: i32 Status (go.tag="bson:\"status\"")
+}
+(
+    mongo.TransactionCountByStatusEqualFindManyByStatusEqualDeleteManyByStatusEqual = "Purge(ctx context.Context, client *mongo.Client, status int32, checkCount func(int) error, s2 int32, checkAll func([]*video.Video) error, s3 int32) error"
+)
+`)
+	source := renderMethods(t, []*template.MethodRender{findMethod(t, HandleCodegen(operations, false)[0], "purge")})
+	// every step is followed by a blank line besides the one after the package clause,
+	// the statements of a step are not separated
+	if blank := strings.Count(source, "\n\n"); blank != 3+1 {
+		t.Errorf("got %d blank lines in\n%s", blank, source)
+	}
+	checkCode(t, source, "var found2 []*video.Video", "if err = cursor2.All(sessionContext, &found2); err != nil {")
+}
@@ -21,10 +21,11 @@ import (
 	"github.com/hertz-contrib/thrift-gen-mongo/parse"
 )
 
-func updateCodegen(update *parse.UpdateParse) []code.Statement {
+func updateCodegen(update *parse.UpdateParse, routed bool) []code.Statement {
 	chainCall := make(code.ChainStmt, 0, 5)
 	if update.OperateMode == parse.OperateOne {
-		return []code.Statement{
+		stmts := append(updateValidateCodegen(update, "return false, err"), updatePrepareCodegen(update)...)
+		return append(stmts, []code.Statement{
 			code.DeclColonStmt{
 				Left: code.ListCommaStmt{
 					code.RawStmt("result"),
@@ -35,7 +36,7 @@ func updateCodegen(update *parse.UpdateParse) []code.Statement {
 					CallName: "UpdateOne",
 					Args: code.ListCommaStmt{
 						code.RawStmt(update.CtxParamName),
-						queryCodegen(update.Query),
+						operationFilter(update, routed),
 						updateFieldsCodegen(update),
 						chainCall.ChainCall(code.Chain{
 							CallName: "options.Update",
@@ -49,16 +50,17 @@ func updateCodegen(update *parse.UpdateParse) []code.Statement {
 					},
 				},
 			},
-			code.RawStmt("if err != nil {\n\treturn false, err\n}"),
+			code.RawStmt("if err != nil {\n\treturn false, translateError(err)\n}"),
 			code.ReturnStmt{
 				ListCommaStmt: code.ListCommaStmt{
 					code.RawStmt("result.MatchedCount > 0"),
 					code.RawStmt("nil"),
 				},
 			},
-		}
+		}...)
 	} else {
-		return []code.Statement{
+		stmts := append(updateValidateCodegen(update, "return 0, err"), updatePrepareCodegen(update)...)
+		return append(stmts, []code.Statement{
 			code.DeclColonStmt{
 				Left: code.ListCommaStmt{
 					code.RawStmt("result"),
@@ -69,7 +71,7 @@ func updateCodegen(update *parse.UpdateParse) []code.Statement {
 					CallName: "UpdateMany",
 					Args: code.ListCommaStmt{
 						code.RawStmt(update.CtxParamName),
-						queryCodegen(update.Query),
+						operationFilter(update, routed),
 						updateFieldsCodegen(update),
 						chainCall.ChainCall(code.Chain{
 							CallName: "options.Update",
@@ -83,18 +85,18 @@ func updateCodegen(update *parse.UpdateParse) []code.Statement {
 					},
 				},
 			},
-			code.RawStmt("if err != nil {\n\treturn 0, err\n}"),
+			code.RawStmt("if err != nil {\n\treturn 0, translateError(err)\n}"),
 			code.ReturnStmt{
 				ListCommaStmt: code.ListCommaStmt{
 					code.RawStmt("int(result.MatchedCount)"),
 					code.RawStmt("nil"),
 				},
 			},
-		}
+		}...)
 	}
 }
 
-func updateFieldsCodegen(update *parse.UpdateParse) code.MapStmt {
+func updateFieldsCodegen(update *parse.UpdateParse) code.Statement {
 	if update.UpdateStructObjName == "" {
 		mapPairs := make([]code.MapPair, 0, 5)
 		for _, field := range update.UpdateFields {
@@ -105,7 +107,7 @@ func updateFieldsCodegen(update *parse.UpdateParse) code.MapStmt {
 		}
 		return code.MapStmt{
 			Name: "bson.M",
-			Pair: []code.MapPair{
+			Pair: updateTimestampPairs(update, []code.MapPair{
 				{
 					Key: code.RawStmt("$set"),
 					Value: code.MapStmt{
@@ -113,9 +115,12 @@ func updateFieldsCodegen(update *parse.UpdateParse) code.MapStmt {
 						Pair: mapPairs,
 					},
 				},
-			},
+			}),
 		}
 	} else {
+		if name := upsertDocName(update); name != "" {
+			return code.RawStmt(name)
+		}
 		return code.MapStmt{
 			Name: "bson.M",
 			Pair: []code.MapPair{
@@ -0,0 +1,298 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// ValidateImports are the imports of the validation file generated in the model package.
+var ValidateImports = map[string]string{
+	"fmt":     "",
+	"regexp":  "",
+	"strings": "",
+}
+
+// GetValidateRenders returns the Validate methods of the structs with validation annotations,
+// and the patterns used by the methods.
+func GetValidateRenders(structs []*extract.IdlExtractStruct) ([]*template.MethodRender, []template.PatternVar) {
+	methods := make([]*template.MethodRender, 0, len(structs))
+	patterns := make([]template.PatternVar, 0, 5)
+	for _, st := range structs {
+		fieldMethods := make([]*template.MethodRender, 0, len(st.StructFields))
+		body := code.Body{
+			code.DeclVarStmt{
+				Name: "fields",
+				Type: code.SliceType{ElementType: code.IdentType("ValidationFieldError")},
+			},
+		}
+		for _, field := range st.StructFields {
+			if field.Validation == nil {
+				continue
+			}
+			pattern := ""
+			if field.Validation.Pattern != "" {
+				pattern = lowerFirst(st.Name) + field.Name + "Pattern"
+				patterns = append(patterns, template.PatternVar{
+					Name:    pattern,
+					Pattern: strconv.Quote(field.Validation.Pattern),
+				})
+			}
+			stmts := fieldValidateCodegen(field, pattern)
+			body = append(body, stmts...)
+			fieldMethods = append(fieldMethods, validateMethod(st, field.Name,
+				"// Validate"+field.Name+" reports whether the "+field.Name+" of the "+st.Name+
+					" violates the validation annotations,\n// the repository validates the updates of the field with it.",
+				append(code.Body{body[0]}, stmts...)))
+		}
+
+		methods = append(methods, validateMethod(st, "",
+			"// Validate reports every field of the "+st.Name+" which violates the validation annotations.", body))
+		methods = append(methods, fieldMethods...)
+	}
+	return methods, patterns
+}
+
+// validateMethod returns the Validate method of the field, or of the struct if the field is empty,
+// the body declares the fields and appends the violations to it.
+func validateMethod(st *extract.IdlExtractStruct, field, comment string, body code.Body) *template.MethodRender {
+	body = append(body,
+		code.IfBlockStmt{
+			Condition: []code.Statement{code.RawStmt("len(fields) != 0 ")},
+			Body: code.Body{
+				code.RawStmt("return &ValidationError{Struct: " + strconv.Quote(st.Name) + ", Fields: fields}"),
+			},
+		},
+		code.RawStmt("return nil"),
+	)
+	return &template.MethodRender{
+		Name:    "Validate" + field,
+		Comment: comment,
+		MethodReceiver: code.MethodReceiver{
+			Name: "p",
+			Type: code.StarExprType{RealType: code.IdentType(st.Name)},
+		},
+		Params:     code.Params{},
+		Returns:    code.Returns{code.IdentType("error")},
+		MethodBody: body,
+	}
+}
+
+func fieldValidateCodegen(field *extract.StructField, pattern string) []code.Statement {
+	validation := field.Validation
+	obj := "p." + field.Name
+	value := obj
+	guard := ""
+	if field.IsPointer() {
+		value = "*" + obj
+		guard = obj + " != nil && "
+	}
+	// the unset values of the optional fields only fail the required rule
+	unsetGuard := guard
+	if !validation.Required && !field.IsPointer() {
+		if field.HasLen() {
+			unsetGuard = "len(" + obj + ") != 0 && "
+		} else {
+			unsetGuard = obj + " != 0 && "
+		}
+	}
+
+	stmts := make([]code.Statement, 0, 5)
+	fail := func(condition, rule, message string) {
+		stmts = append(stmts, code.IfBlockStmt{
+			Condition: []code.Statement{code.RawStmt(condition + " ")},
+			Body: code.Body{
+				code.RawStmt("fields = append(fields, ValidationFieldError{Field: " + strconv.Quote(field.Name) +
+					", Rule: " + strconv.Quote(rule) + ", Message: " + strconv.Quote(message) + "})"),
+			},
+		})
+	}
+
+	if validation.Required {
+		switch {
+		case field.IsPointer():
+			fail(obj+" == nil", "required", "is required")
+		case field.HasLen():
+			fail("len("+obj+") == 0", "required", "is required")
+		default:
+			fail(obj+" == 0", "required", "is required")
+		}
+	}
+	if validation.Min != "" {
+		fail(guard+value+" < "+validation.Min, "min", "should be greater than or equal to "+validation.Min)
+	}
+	if validation.Max != "" {
+		fail(guard+value+" > "+validation.Max, "max", "should be less than or equal to "+validation.Max)
+	}
+	if validation.MinLen != "" {
+		fail(unsetGuard+"len("+value+") < "+validation.MinLen, "min_len",
+			"length should be greater than or equal to "+validation.MinLen)
+	}
+	if validation.MaxLen != "" {
+		fail(guard+"len("+value+") > "+validation.MaxLen, "max_len",
+			"length should be less than or equal to "+validation.MaxLen)
+	}
+	if pattern != "" {
+		fail(unsetGuard+"!"+pattern+".MatchString("+value+")", "pattern", "should match "+validation.Pattern)
+	}
+	if len(validation.Enum) != 0 {
+		conditions := make([]string, 0, len(validation.Enum))
+		for _, item := range validation.Enum {
+			conditions = append(conditions, value+" != "+item)
+		}
+		fail(unsetGuard+strings.Join(conditions, " && "), "enum",
+			"should be one of "+strings.Join(validation.Enum, ", "))
+	}
+	return stmts
+}
+
+// validateCodegen validates the documents named by param before they are written, onError handles the err.
+func validateCodegen(method *extract.InterfaceMethod, param string, many bool, onError string) []code.Statement {
+	st := method.BelongedToStruct
+	if !st.HasValidation() || !isStructParam(method, param, st) {
+		return nil
+	}
+
+	validate := func(obj string) code.Statement {
+		return code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.DeclColonStmt{
+					Left: code.ListCommaStmt{code.RawStmt("err")},
+					Right: code.CallStmt{
+						Caller:   code.RawStmt(obj),
+						CallName: "Validate",
+						Args:     code.ListCommaStmt{},
+					},
+				},
+				code.RawStmt("; err != nil "),
+			},
+			Body: code.Body{code.RawStmt(onError)},
+		}
+	}
+	if !many {
+		return []code.Statement{validate(param)}
+	}
+	return []code.Statement{
+		code.ForRangeBlockStmt{
+			RangeName: param,
+			Value:     "model",
+			Body:      code.Body{validate("model")},
+		},
+	}
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func insertValidateCodegen(insert *parse.InsertParse, param, onError string) []code.Statement {
+	return validateCodegen(insert.BelongedToMethod, param, insert.OperateMode == parse.OperateMany, onError)
+}
+
+// updateValidateCodegen validates the structure or the params of the field updates, the param of the field update
+// is validated by the Validate method of the field.
+func updateValidateCodegen(update *parse.UpdateParse, onError string) []code.Statement {
+	if update.UpdateStructObjName != "" {
+		return validateCodegen(update.BelongedToMethod, update.UpdateStructObjName, false, onError)
+	}
+
+	st := update.BelongedToMethod.BelongedToStruct
+	stmts := make([]code.Statement, 0, len(update.UpdateFields))
+	for _, updateField := range update.UpdateFields {
+		field := validatedField(st, updateField.MongoFieldName)
+		if field == nil {
+			continue
+		}
+		// the param of the optional field is the value of the pointer
+		value := updateField.ParamName
+		if field.IsPointer() && !strings.HasPrefix(field.Type.RealName(), "*") {
+			value = "&" + value
+		}
+		model := "(&" + st.ModelPackage + "." + st.Name + "{" + field.Name + ": " + value + "})"
+		stmts = append(stmts, code.IfBlockStmt{
+			Condition: []code.Statement{
+				code.DeclColonStmt{
+					Left: code.ListCommaStmt{code.RawStmt("err")},
+					Right: code.CallStmt{
+						Caller:   code.RawStmt(model),
+						CallName: "Validate" + field.Name,
+						Args:     code.ListCommaStmt{},
+					},
+				},
+				code.RawStmt("; err != nil "),
+			},
+			Body: code.Body{code.RawStmt(onError)},
+		})
+	}
+	return stmts
+}
+
+// validatedField returns the field of the struct with validation annotations whose bson name is mongoFieldName,
+// the nested fields are not validated.
+func validatedField(st *extract.IdlExtractStruct, mongoFieldName string) *extract.StructField {
+	for _, field := range st.StructFields {
+		if field.Validation != nil && field.Tag.Get("bson") == mongoFieldName {
+			return field
+		}
+	}
+	return nil
+}
+
+// bulkValidateCodegen validates all the written structures of the bulk before any of them is prepared.
+func bulkValidateCodegen(bulk *parse.BulkParse, onError string) []code.Statement {
+	stmts := make([]code.Statement, 0, 5)
+	for _, operation := range bulk.Operations {
+		if operation.GetOperationName() == parse.Insert {
+			insert := operation.(*parse.InsertParse)
+			stmts = append(stmts, insertValidateCodegen(insert, insert.MethodParamNames[0], onError)...)
+		}
+		if operation.GetOperationName() == parse.Update {
+			stmts = append(stmts, updateValidateCodegen(operation.(*parse.UpdateParse), onError)...)
+		}
+		if operation.GetOperationName() == parse.Replace {
+			replace := operation.(*parse.ReplaceParse)
+			stmts = append(stmts, validateCodegen(replace.BelongedToMethod, replace.ReplaceStructObjName, false, onError)...)
+		}
+	}
+	return stmts
+}
+
+// taValidateCodegen validates all the written structures of the transaction before it is started.
+func taValidateCodegen(transaction *parse.TransactionParse) []code.Statement {
+	stmts := make([]code.Statement, 0, 5)
+	for _, operation := range transaction.TransactionOperations {
+		switch operation.Operation.GetOperationName() {
+		case parse.Insert:
+			insert := operation.Operation.(*parse.InsertParse)
+			stmts = append(stmts, insertValidateCodegen(insert, insert.MethodParamNames[0], "return err")...)
+		case parse.Update:
+			stmts = append(stmts, updateValidateCodegen(operation.Operation.(*parse.UpdateParse), "return err")...)
+		case parse.Bulk:
+			stmts = append(stmts, bulkValidateCodegen(operation.Operation.(*parse.BulkParse), "return err")...)
+		}
+	}
+	return stmts
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"testing"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+func TestGetValidateRenders(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		want  []string
+	}{
+		{
+			name:  "required string",
+			field: `string Title (go.tag="bson:\"title\"", mongo.required="true")`,
+			want:  []string{"if len(p.Title) == 0 {"},
+		},
+		{
+			name:  "required optional",
+			field: `optional string Title (go.tag="bson:\"title\"", mongo.required="true")`,
+			want:  []string{"if p.Title == nil {"},
+		},
+		{
+			name:  "required number",
+			field: `i32 Status (go.tag="bson:\"status\"", mongo.required="true")`,
+			want:  []string{"if p.Status == 0 {"},
+		},
+		{
+			name:  "min and max",
+			field: `optional i32 Score (go.tag="bson:\"score\"", mongo.min="0", mongo.max="100")`,
+			want:  []string{"if p.Score != nil && *p.Score < 0 {", "if p.Score != nil && *p.Score > 100 {"},
+		},
+		{
+			name:  "length",
+			field: `list<string> Tags (go.tag="bson:\"tags\"", mongo.min_len="1", mongo.max_len="5")`,
+			want:  []string{"if len(p.Tags) != 0 && len(p.Tags) < 1 {", "if len(p.Tags) > 5 {"},
+		},
+		{
+			name:  "pattern",
+			field: `string Title (go.tag="bson:\"title\"", mongo.pattern="^[a-z]+$")`,
+			want:  []string{"if len(p.Title) != 0 && !videoTitlePattern.MatchString(p.Title) {"},
+		},
+		{
+			name:  "required pattern",
+			field: `string Title (go.tag="bson:\"title\"", mongo.required="true", mongo.pattern="^[a-z]+$")`,
+			want:  []string{"if len(p.Title) == 0 {", "if !videoTitlePattern.MatchString(p.Title) {"},
+		},
+		{
+			name:  "optional pattern",
+			field: `optional string Title (go.tag="bson:\"title\"", mongo.pattern="^[a-z]+$")`,
+			want:  []string{"if p.Title != nil && !videoTitlePattern.MatchString(*p.Title) {"},
+		},
+		{
+			name:  "enum",
+			field: `string Lang (go.tag="bson:\"lang\"", mongo.enum="en, zh")`,
+			want:  []string{`if len(p.Lang) != 0 && p.Lang != "en" && p.Lang != "zh" {`},
+		},
+		{
+			name:  "number enum",
+			field: `i32 Status (go.tag="bson:\"status\"", mongo.enum="1, 2")`,
+			want:  []string{"if p.Status != 0 && p.Status != 1 && p.Status != 2 {"},
+		},
+		{
+			name:  "required enum",
+			field: `i32 Status (go.tag="bson:\"status\"", mongo.required="true", mongo.enum="1, 2")`,
+			want:  []string{"if p.Status == 0 {", "if p.Status != 1 && p.Status != 2 {"},
+		},
+		{
+			name:  "field method",
+			field: `i32 Status (go.tag="bson:\"status\"", mongo.min="1")`,
+			want:  []string{"func (p *Video) ValidateStatus() error {", "if p.Status < 1 {"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operations := parseIDL(t, "namespace go video\nstruct Video {\n"+
+				"1: i64 Id (go.tag=\"bson:\\\"_id\\\"\")\n2: "+tt.field+"\n}\n"+
+				"(mongo.InsertOne = \"InsertOne(ctx context.Context, v *video.Video) (interface{}, error)\")\n")
+			methods, _ := GetValidateRenders([]*extract.IdlExtractStruct{operations[0].BelongedToStruct})
+			checkCode(t, renderMethods(t, methods), tt.want...)
+		})
+	}
+}
+
+func TestGetValidatePatterns(t *testing.T) {
+	operations := parseIDL(t, `namespace go video
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: string Title (go.tag="bson:\"title\"", mongo.pattern="^[a-z]+$")
+    3: string Lang (go.tag="bson:\"lang\"")
+}
+(mongo.InsertOne = "InsertOne(ctx context.Context, v *video.Video) (interface{}, error)")
+`)
+	_, patterns := GetValidateRenders([]*extract.IdlExtractStruct{operations[0].BelongedToStruct})
+	if len(patterns) != 1 || patterns[0].Name != "videoTitlePattern" || patterns[0].Pattern != `"^[a-z]+$"` {
+		t.Fatalf("unexpected patterns %+v", patterns)
+	}
+}
+
+func TestValidateCodegen(t *testing.T) {
+	operations := parseIDL(t, `namespace go video
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: string Title (go.tag="bson:\"title\"", mongo.required="true")
+    3: optional i32 Score (go.tag="bson:\"score\"", mongo.min="0")
+}
+(
+    mongo.UpdateScoreByIdEqual = "UpdateScore(ctx context.Context, score int32, id int64) (bool, error)"
+    mongo.InsertOne = "InsertOne(ctx context.Context, v *video.Video) (interface{}, error)"
+    mongo.InsertMany = "InsertMany(ctx context.Context, vs []*video.Video) ([]interface{}, error)"
+    mongo.UpdateByIdEqual = "Update(ctx context.Context, v *video.Video, id int64) (bool, error)"
+    mongo.UpdateTitleByIdEqual = "UpdateTitle(ctx context.Context, title string, id int64) (bool, error)"
+    mongo.UpdateIdByIdEqual = "UpdateId(ctx context.Context, newId int64, id int64) (bool, error)"
+    mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"
+)
+`)
+	methods := HandleCodegen(operations, false)[0]
+	render := func(name string) string {
+		return renderMethods(t, []*template.MethodRender{findMethod(t, methods, name)})
+	}
+
+	checkCode(t, render("insertOne"), "if err := v.Validate(); err != nil {")
+	checkCode(t, render("insertMany"), "for _, model := range vs {", "if err := model.Validate(); err != nil {")
+	checkCode(t, render("update"), "if err := v.Validate(); err != nil {")
+	checkCode(t, render("updateTitle"), "if err := (&video.Video{Title: title}).ValidateTitle(); err != nil {")
+	checkCode(t, render("updateScore"), "if err := (&video.Video{Score: &score}).ValidateScore(); err != nil {")
+	checkNoCode(t, render("updateId"), "if err := (&video.Video{Id: newId}).ValidateId(); err != nil {")
+	checkNoCode(t, render("findById"), "if err := v.Validate(); err != nil {")
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// WatchImports are the imports of the watch file generated with the repository which has Watch methods.
+var WatchImports = map[string]string{
+	"context":                          "",
+	"go.mongodb.org/mongo-driver/bson": "",
+	"go.mongodb.org/mongo-driver/bson/primitive": "",
+	"go.mongodb.org/mongo-driver/mongo":          "",
+}
+
+// watchCodegen opens the change stream of the collection, the update events look up the current document.
+func watchCodegen(watch *parse.WatchParse, routed bool) []code.Statement {
+	match := make([]code.MapPair, 0, 2)
+	if len(watch.OperationTypes) != 0 {
+		operationTypes := make([]string, 0, len(watch.OperationTypes))
+		for _, operationType := range watch.OperationTypes {
+			operationTypes = append(operationTypes, strconv.Quote(operationType))
+		}
+		match = append(match, oneMapParamCodegen("operationType", "$in",
+			"bson.A{"+strings.Join(operationTypes, ", ")+"}"))
+	}
+	if watch.Query.ConnectionOpTree != nil {
+		match = append(match, dfsCodegen(watch.Query.ConnectionOpTree, routed))
+	}
+
+	pipeline := "mongo.Pipeline{}"
+	if len(match) != 0 {
+		pipeline = "mongo.Pipeline{\n{{Key: \"$match\", Value: " + code.MapStmt{
+			Name: "bson.M",
+			Pair: match,
+		}.Code() + "}},\n}"
+	}
+
+	args := code.ListCommaStmt{
+		code.RawStmt(watch.CtxParamName),
+		code.RawStmt(pipeline),
+		code.RawStmt("options.ChangeStream().SetFullDocument(options.UpdateLookup)"),
+	}
+	if watch.OptionsParamName != "" {
+		args = append(args, code.RawStmt(watch.OptionsParamName))
+	}
+
+	st := watch.BelongedToMethod.BelongedToStruct
+	return []code.Statement{
+		code.DeclColonStmt{
+			Left: code.ListCommaStmt{
+				code.RawStmt("stream"),
+				code.RawStmt("err"),
+			},
+			Right: code.CallStmt{
+				Caller:   code.RawStmt("r.collection"),
+				CallName: "Watch",
+				Args:     args,
+			},
+		},
+		code.RawStmt("if err != nil {\n\treturn nil, translateError(err)\n}"),
+		code.RawStmt("return &" + st.Name + "ChangeStream{stream: stream}, nil"),
+	}
+}
+
+// GetWatchRender returns the change stream of the struct.
+func GetWatchRender(st *extract.IdlExtractStruct) *template.WatchRender {
+	return &template.WatchRender{
+		StructName:   st.Name,
+		ModelPackage: st.ModelPackage,
+	}
+}
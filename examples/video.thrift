@@ -3,7 +3,67 @@ namespace go video
 struct Video {
     1: i64 Id (go.tag="bson:\"id,omitempty\"")
     2: binary Data (go.tag="bson:\"data,omitempty\"")
+    3: i64 DeletedAt (go.tag="bson:\"deleted_at,omitempty\"")
+    4: i64 CreatedAt (go.tag="bson:\"created_at,omitempty\"")
+    5: i64 UpdatedAt (go.tag="bson:\"updated_at\"")
+    6: string Title (go.tag="bson:\"title\"", mongo.required="true", mongo.max_len="64")
+    7: i32 Status (go.tag="bson:\"status\"", mongo.enum="1, 2")
 }
 (
+    mongo.id = "Id"
+    mongo.id_strategy = "snowflake"
+    mongo.soft_delete = "deleted_at"
+    mongo.created_at = "created_at"
+    mongo.updated_at = "updated_at"
+    mongo.chunk_size = "1000"
+    mongo.index = "Title; unique"
+    mongo.cache = "FindById: ttl=1m"
+    mongo.index = "Status asc, CreatedAt desc; partial=DeletedAt Equal 0, name=by_status"
     mongo.InsertVideo = "InsertVideo(ctx context.Context, video *video.Video) (interface{}, error)"
+    mongo.InsertOne = "InsertOne(ctx context.Context, v *video.Video) (int64, error)"
+    mongo.InsertMany = "InsertMany(ctx context.Context, videos []*video.Video, progress func(int, int)) ([]int64, error)"
+    mongo.InsertManyUnordered = "InsertUnordered(ctx context.Context, videos []*video.Video) ([]interface{}, error)"
+    mongo.BulkUnorderedInsertOneDeleteOneByIdEqual = "InsertAndDelete(ctx context.Context, v *video.Video, id int64) (*mongo.BulkWriteResult, error)"
+    mongo.BulkInsertOneReplaceOneUpsertByIdEqual = "Save(ctx context.Context, v *video.Video, v2 *video.Video, id int64) (*mongo.BulkWriteResult, error)"
+    mongo.UpdateTitleByIdEqual = "UpdateTitle(ctx context.Context, title string, id int64) (bool, error)"
+    mongo.TransactionInsertOneUpdateOneTitleByIdEqual = "InsertAndRename(ctx context.Context, client *mongo.Client, v *video.Video, title string, id int64) error"
+    mongo.TransactionFindOneByIdEqualUpdateOneTitleByIdEqualFound = "Rename(ctx context.Context, client *mongo.Client, id int64, guard func(*video.Video) error, title string) error"
+    mongo.transaction_options = "InsertAndRename: read_concern=snapshot, write_concern=majority, max_commit_time=2s"
+    mongo.CountLimitMaxTimeByStatusEqual = "CountByStatus(ctx context.Context, limit int64, maxTime time.Duration, status int32) (int, error)"
+    mongo.WatchInsertUpdateByStatusEqual = "WatchStatus(ctx context.Context, status int32, opts *options.ChangeStreamOptions) (*VideoChangeStream, error)"
+    mongo.DeleteByIdEqual = "DeleteById(ctx context.Context, id int64) (bool, error)"
+    mongo.HardDeleteByIdEqual = "HardDeleteById(ctx context.Context, id int64) (bool, error)"
+    mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"
+    mongo.FindWithDeletedByIdEqual = "FindWithDeletedById(ctx context.Context, id int64) (*video.Video, error)"
+)
+
+struct Comment {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: i64 VideoId (go.tag="bson:\"video_id\"")
+    3: string Content (go.tag="bson:\"content\"")
+}
+(
+    mongo.id = "Id"
+    mongo.id_strategy = "sequence"
+    mongo.sequence = "comment_id"
+    mongo.sequence_batch = "true"
+    mongo.shard_key = "VideoId hashed"
+    mongo.InsertMany = "InsertMany(ctx context.Context, comments []*video.Comment) ([]int64, error)"
+    mongo.EstimatedCountMaxTimeAll = "EstimatedCount(ctx context.Context, maxTime time.Duration) (int, error)"
+    mongo.FindByVideoIdEqual = "FindByVideoId(ctx context.Context, videoId int64) ([]*video.Comment, error)"
+)
+
+struct Playlist {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: string TenantId (go.tag="bson:\"tenant_id\"")
+    3: string Name (go.tag="bson:\"name\"")
+    4: list<i64> VideoIds (go.tag="bson:\"video_ids\"")
+}
+(
+    mongo.tenant = "tenant_id"
+    mongo.tenant_resolver = "true"
+    mongo.collection = "playlists"
+    mongo.database = "media"
+    mongo.InsertOne = "InsertOne(ctx context.Context, playlist *video.Playlist) (interface{}, error)"
+    mongo.FindByNameEqual = "FindByName(ctx context.Context, name string) ([]*video.Playlist, error)"
 )
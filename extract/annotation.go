@@ -0,0 +1,347 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/utils"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+const annotationPrefix = "mongo."
+
+// struct annotations which configure the generated repository instead of declaring methods
+const (
+	SoftDeleteAnnotation = "mongo.soft_delete"
+	CreatedAtAnnotation  = "mongo.created_at"
+	UpdatedAtAnnotation  = "mongo.updated_at"
+	IDAnnotation         = "mongo.id"
+	IDStrategyAnnotation = "mongo.id_strategy"
+
+	SequenceAnnotation           = "mongo.sequence"
+	SequenceCollectionAnnotation = "mongo.sequence_collection"
+	SequenceBatchAnnotation      = "mongo.sequence_batch"
+
+	ChunkSizeAnnotation = "mongo.chunk_size"
+
+	TenantAnnotation         = "mongo.tenant"
+	TenantResolverAnnotation = "mongo.tenant_resolver"
+
+	CollectionAnnotation = "mongo.collection"
+	DatabaseAnnotation   = "mongo.database"
+)
+
+// id strategies which generate the id of the document inserted without one
+const (
+	IDStrategyObjectID  = "objectid"
+	IDStrategyUUIDv4    = "uuidv4"
+	IDStrategyUUIDv7    = "uuidv7"
+	IDStrategySnowflake = "snowflake"
+	IDStrategyCustom    = "custom"
+	IDStrategySequence  = "sequence"
+)
+
+const defaultSequenceCollection = "counters"
+
+var structAnnotations = map[string]struct{}{
+	SoftDeleteAnnotation: {},
+	CreatedAtAnnotation:  {},
+	UpdatedAtAnnotation:  {},
+	IDAnnotation:         {},
+	IDStrategyAnnotation: {},
+
+	SequenceAnnotation:           {},
+	SequenceCollectionAnnotation: {},
+	SequenceBatchAnnotation:      {},
+
+	ChunkSizeAnnotation: {},
+
+	TenantAnnotation:         {},
+	TenantResolverAnnotation: {},
+
+	CollectionAnnotation: {},
+	DatabaseAnnotation:   {},
+
+	TransactionOptionsAnnotation: {},
+	IndexAnnotation:              {},
+	CacheAnnotation:              {},
+	ShardKeyAnnotation:           {},
+}
+
+// AnnotationInfo stores the struct annotations which configure the generated repository.
+type AnnotationInfo struct {
+	// SoftDeleteField is set by Delete instead of removing the document,
+	// it is a bool flag or an int64 deleted-at timestamp in milliseconds
+	SoftDeleteField *StructField
+
+	// CreatedAtField is populated by inserts and upserts, UpdatedAtField is populated by all writes,
+	// both of them are int64 timestamps in milliseconds or time.Time dates of the same type
+	CreatedAtField *StructField
+	UpdatedAtField *StructField
+
+	// IDField is returned by the Insert methods with typed id returns,
+	// it is generated by IDStrategy when it is zero, IDStrategy is empty if the ids are set by the caller
+	IDField    *StructField
+	IDStrategy string
+
+	// SequenceName is the document of the sequence strategy in the SequenceCollection,
+	// SequenceBatch reserves the ids of Insert Many with one increment
+	SequenceName       string
+	SequenceCollection string
+	SequenceBatch      bool
+
+	// ChunkSize splits the documents of Insert Many and the operations of the bulk builder into
+	// writes of at most ChunkSize, it is zero if they are written at once
+	ChunkSize int
+
+	// TenantField scopes the operations to the tenant of the context, the tenant is ANDed into the filters
+	// and stamped on the written documents. TenantResolver routes the operations to the collection of the
+	// tenant resolved by the resolver option of the repository
+	TenantField    *StructField
+	TenantResolver bool
+
+	// CollectionName is the name of the collection of the struct, it is the package name of the struct if not set.
+	// DatabaseName is the name of the database of the collection, it is empty if not set
+	CollectionName string
+	DatabaseName   string
+
+	// TransactionOptions stores the options of the Transaction methods by the method name
+	TransactionOptions map[string]*TransactionOptions
+
+	// Indexes are created by the EnsureIndexes method of the repository
+	Indexes []*Index
+
+	// CacheOptions stores the options of the cached Find methods by the method name
+	CacheOptions map[string]*CacheOptions
+
+	// ShardKey is checked against the queries of the One operations and the upserts,
+	// the collection is sharded by the ShardCollection method of the repository
+	ShardKey *ShardKey
+}
+
+// isMethodAnnotation reports whether the annotation declares a repository method.
+func isMethodAnnotation(key string) bool {
+	if strings.Index(key, annotationPrefix) != 0 || len(key) <= len(annotationPrefix) {
+		return false
+	}
+	_, ok := structAnnotations[key]
+	return !ok
+}
+
+func extractStructAnnotations(st *parser.StructLike, rawStruct *IdlExtractStruct) error {
+	for _, anno := range st.Annotations {
+		if _, ok := structAnnotations[anno.Key]; !ok {
+			continue
+		}
+		if anno.Key == TransactionOptionsAnnotation {
+			if err := extractTransactionOptions(st.Name, anno.GetValues(), rawStruct); err != nil {
+				return err
+			}
+			continue
+		}
+		if anno.Key == CacheAnnotation {
+			if err := extractCacheOptions(st.Name, anno.GetValues(), rawStruct); err != nil {
+				return err
+			}
+			continue
+		}
+		if anno.Key == IndexAnnotation {
+			if err := extractIndexes(st.Name, anno.GetValues(), rawStruct); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(anno.GetValues()) != 1 {
+			return fmt.Errorf("struct %s: annotation %s should have only one value", st.Name, anno.Key)
+		}
+		value := anno.GetValues()[0]
+
+		switch anno.Key {
+		case SoftDeleteAnnotation:
+			field, err := rawStruct.lookupField(value)
+			if err != nil {
+				return fmt.Errorf("struct %s: annotation %s: %s", st.Name, anno.Key, err.Error())
+			}
+			if t := field.Type.RealName(); t != "bool" && t != "int64" {
+				return fmt.Errorf("struct %s: annotation %s: the soft delete field %s should be bool or i64",
+					st.Name, anno.Key, field.Name)
+			}
+			rawStruct.SoftDeleteField = field
+
+		case CreatedAtAnnotation, UpdatedAtAnnotation:
+			field, err := rawStruct.lookupField(value)
+			if err != nil {
+				return fmt.Errorf("struct %s: annotation %s: %s", st.Name, anno.Key, err.Error())
+			}
+			if (field.Type.RealName() != "int64" && !field.IsTime()) || field.Optional {
+				return fmt.Errorf("struct %s: annotation %s: the timestamp field %s should be required or "+
+					"default i64 or time.Time", st.Name, anno.Key, field.Name)
+			}
+			if anno.Key == CreatedAtAnnotation {
+				// updating the whole structure must not overwrite the created-at timestamp with zero
+				if !field.OmitEmpty {
+					return fmt.Errorf("struct %s: annotation %s: the bson tag of field %s should have "+
+						"omitempty option", st.Name, anno.Key, field.Name)
+				}
+				rawStruct.CreatedAtField = field
+			} else {
+				rawStruct.UpdatedAtField = field
+			}
+			// the created-at timestamp of the inserts is copied into the updated-at one
+			if created, updated := rawStruct.CreatedAtField, rawStruct.UpdatedAtField; created != nil &&
+				updated != nil && created.IsTime() != updated.IsTime() {
+				return fmt.Errorf("struct %s: annotation %s: the timestamp fields %s and %s should have the same type",
+					st.Name, anno.Key, created.Name, updated.Name)
+			}
+
+		case IDAnnotation:
+			field, err := rawStruct.lookupField(value)
+			if err != nil {
+				return fmt.Errorf("struct %s: annotation %s: %s", st.Name, anno.Key, err.Error())
+			}
+			if t := field.Type.RealName(); (t != "string" && t != "int64" && t != "int32") || field.Optional {
+				return fmt.Errorf("struct %s: annotation %s: the id field %s should be required or "+
+					"default string, i64 or i32", st.Name, anno.Key, field.Name)
+			}
+			rawStruct.IDField = field
+
+		case IDStrategyAnnotation:
+			rawStruct.IDStrategy = strings.ToLower(strings.TrimSpace(value))
+
+		case SequenceAnnotation:
+			rawStruct.SequenceName = strings.TrimSpace(value)
+
+		case SequenceCollectionAnnotation:
+			rawStruct.SequenceCollection = strings.TrimSpace(value)
+
+		case SequenceBatchAnnotation:
+			batch, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("struct %s: annotation %s should be true or false", st.Name, anno.Key)
+			}
+			rawStruct.SequenceBatch = batch
+
+		case ChunkSizeAnnotation:
+			size, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || size <= 0 {
+				return fmt.Errorf("struct %s: annotation %s should be a positive integer", st.Name, anno.Key)
+			}
+			rawStruct.ChunkSize = size
+
+		case TenantAnnotation:
+			field, err := rawStruct.lookupField(value)
+			if err != nil {
+				return fmt.Errorf("struct %s: annotation %s: %s", st.Name, anno.Key, err.Error())
+			}
+			if field.Type.RealName() != "string" || field.Optional {
+				return fmt.Errorf("struct %s: annotation %s: the tenant field %s should be required or "+
+					"default string", st.Name, anno.Key, field.Name)
+			}
+			rawStruct.TenantField = field
+
+		case TenantResolverAnnotation:
+			resolver, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("struct %s: annotation %s should be true or false", st.Name, anno.Key)
+			}
+			rawStruct.TenantResolver = resolver
+
+		case CollectionAnnotation:
+			name := strings.TrimSpace(value)
+			if name == "" || strings.ContainsAny(name, "$\x00") || strings.HasPrefix(name, "system.") {
+				return fmt.Errorf("struct %s: annotation %s: invalid collection name %q", st.Name, anno.Key, name)
+			}
+			rawStruct.CollectionName = name
+
+		case DatabaseAnnotation:
+			name := strings.TrimSpace(value)
+			if name == "" || strings.ContainsAny(name, "/\\. \"$\x00") {
+				return fmt.Errorf("struct %s: annotation %s: invalid database name %q", st.Name, anno.Key, name)
+			}
+			rawStruct.DatabaseName = name
+
+		case ShardKeyAnnotation:
+			if err := extractShardKey(st.Name, value, rawStruct); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := checkIDStrategy(st, rawStruct); err != nil {
+		return err
+	}
+
+	return extractFieldValidations(st, rawStruct)
+}
+
+func checkIDStrategy(st *parser.StructLike, rawStruct *IdlExtractStruct) error {
+	if rawStruct.IDStrategy != IDStrategySequence &&
+		(rawStruct.SequenceName != "" || rawStruct.SequenceCollection != "" || rawStruct.SequenceBatch) {
+		return fmt.Errorf("struct %s: the sequence annotations require the %s strategy", st.Name, IDStrategySequence)
+	}
+	if rawStruct.IDStrategy == "" {
+		return nil
+	}
+	if rawStruct.IDField == nil {
+		return fmt.Errorf("struct %s: annotation %s requires annotation %s", st.Name, IDStrategyAnnotation, IDAnnotation)
+	}
+
+	t := rawStruct.IDField.Type.RealName()
+	switch rawStruct.IDStrategy {
+	case IDStrategyObjectID, IDStrategyUUIDv4, IDStrategyUUIDv7:
+		if t != "string" {
+			return fmt.Errorf("struct %s: annotation %s: the id field %s should be string for the %s strategy",
+				st.Name, IDStrategyAnnotation, rawStruct.IDField.Name, rawStruct.IDStrategy)
+		}
+	case IDStrategySnowflake, IDStrategySequence:
+		if t != "int64" {
+			return fmt.Errorf("struct %s: annotation %s: the id field %s should be i64 for the %s strategy",
+				st.Name, IDStrategyAnnotation, rawStruct.IDField.Name, rawStruct.IDStrategy)
+		}
+		if rawStruct.IDStrategy == IDStrategySequence {
+			if rawStruct.SequenceName == "" {
+				rawStruct.SequenceName = GetPkgName(rawStruct.Name)
+			}
+			if rawStruct.SequenceCollection == "" {
+				rawStruct.SequenceCollection = defaultSequenceCollection
+			}
+		}
+	case IDStrategyCustom:
+	default:
+		return fmt.Errorf("struct %s: annotation %s: unknown strategy %s, supports %s, %s, %s, %s, %s, %s",
+			st.Name, IDStrategyAnnotation, rawStruct.IDStrategy, IDStrategyObjectID, IDStrategyUUIDv4,
+			IDStrategyUUIDv7, IDStrategySnowflake, IDStrategySequence, IDStrategyCustom)
+	}
+
+	return nil
+}
+
+// lookupField finds the top level field which is named by an annotation value,
+// the value can be either the thrift field name or the generated go field name.
+func (st *IdlExtractStruct) lookupField(name string) (*StructField, error) {
+	camelName := utils.CamelString(strings.TrimSpace(name))
+	for _, field := range st.StructFields {
+		if field.Name == camelName {
+			return field, nil
+		}
+	}
+	return nil, fmt.Errorf("field %s with bson tag not found", name)
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CacheAnnotation caches the results of a Find method in the generated cached repository,
+// every value is "MethodName[: ttl=duration]" and the annotation may have a value for each method.
+// The ttl is a duration parsed by time.ParseDuration, such as 5m or 1500ms, it should be whole milliseconds.
+const CacheAnnotation = "mongo.cache"
+
+// CacheOptions are the options of the cached Find method.
+type CacheOptions struct {
+	// TTL is the time to live of the cached results, a Go duration of whole milliseconds,
+	// they do not expire if it is zero
+	TTL time.Duration
+}
+
+// extractCacheOptions parses the values of the cache annotation of the struct.
+func extractCacheOptions(structName string, values []string, rawStruct *IdlExtractStruct) error {
+	for _, value := range values {
+		methodName, items := value, ""
+		if index := strings.Index(value, ":"); index != -1 {
+			methodName, items = value[:index], value[index+1:]
+		}
+		methodName = strings.TrimSpace(methodName)
+		if methodName == "" {
+			return fmt.Errorf("struct %s: annotation %s: %q should be \"MethodName[: ttl=duration]\"",
+				structName, CacheAnnotation, value)
+		}
+		if _, ok := rawStruct.CacheOptions[methodName]; ok {
+			return fmt.Errorf("struct %s: annotation %s: the options of method %s are repeated",
+				structName, CacheAnnotation, methodName)
+		}
+
+		options := &CacheOptions{}
+		for _, item := range strings.Split(items, ",") {
+			if strings.TrimSpace(item) == "" {
+				continue
+			}
+			if err := options.set(item); err != nil {
+				return fmt.Errorf("struct %s: annotation %s: method %s: %s",
+					structName, CacheAnnotation, methodName, err.Error())
+			}
+		}
+		if rawStruct.CacheOptions == nil {
+			rawStruct.CacheOptions = map[string]*CacheOptions{}
+		}
+		rawStruct.CacheOptions[methodName] = options
+	}
+	return nil
+}
+
+func (co *CacheOptions) set(item string) error {
+	pair := strings.SplitN(item, "=", 2)
+	if len(pair) != 2 {
+		return fmt.Errorf("%q should be key=value", strings.TrimSpace(item))
+	}
+	key, value := strings.TrimSpace(pair[0]), strings.TrimSpace(pair[1])
+
+	switch key {
+	case "ttl":
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 || d%time.Millisecond != 0 {
+			return fmt.Errorf("the ttl %s should be a positive duration of whole milliseconds, "+
+				"such as 5m or 1500ms", value)
+		}
+		co.TTL = d
+	default:
+		return fmt.Errorf("unknown option %s", key)
+	}
+	return nil
+}
+
+// checkCacheOptions checks that the cache options are set on the Find methods of the struct.
+func checkCacheOptions(structName string, rawStruct *IdlExtractStruct) error {
+	for methodName := range rawStruct.CacheOptions {
+		found := false
+		for _, method := range append(rawStruct.PreIfMethods[:len(rawStruct.PreIfMethods):len(rawStruct.PreIfMethods)],
+			rawStruct.InterfaceInfo.Methods...) {
+			if method.Name == methodName && strings.HasPrefix(method.ParsedTokens, "Find") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("struct %s: annotation %s: %s is not a Find method",
+				structName, CacheAnnotation, methodName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractCacheOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   map[string]*CacheOptions
+		err    string
+	}{
+		{
+			name:   "ttl",
+			values: []string{"FindById: ttl=5m", "FindList: ttl=1500ms", "FindAll"},
+			want: map[string]*CacheOptions{
+				"FindById": {TTL: 5 * time.Minute},
+				"FindList": {TTL: 1500 * time.Millisecond},
+				"FindAll":  {},
+			},
+		},
+		{name: "no method", values: []string{": ttl=1s"}, err: "should be \"MethodName[: ttl=duration]\""},
+		{name: "repeated method", values: []string{"FindById", "FindById: ttl=1s"}, err: "are repeated"},
+		{name: "no value", values: []string{"FindById: ttl"}, err: "should be key=value"},
+		{name: "milliseconds", values: []string{"FindById: ttl=1500"}, err: "positive duration"},
+		{name: "submillisecond", values: []string{"FindById: ttl=1ms500us"}, err: "positive duration"},
+		{name: "negative", values: []string{"FindById: ttl=-1s"}, err: "positive duration"},
+		{name: "unknown option", values: []string{"FindById: size=10"}, err: "unknown option size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newIdlExtractStruct("Video")
+			err := extractCacheOptions("Video", tt.values, st)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(st.CacheOptions, tt.want) {
+				t.Fatalf("got %+v, want %+v", st.CacheOptions, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCacheOptions(t *testing.T) {
+	if _, err := extractVideo(`mongo.cache = "FindById: ttl=1m"`); err != nil {
+		t.Fatal(err)
+	}
+	_, err := extractVideo(`mongo.cache = "Tx"`)
+	if err == nil || !strings.Contains(err.Error(), "Tx is not a Find method") {
+		t.Fatalf("got error %v", err)
+	}
+}
@@ -19,6 +19,7 @@ package extract
 import (
 	"go/ast"
 	"go/token"
+	"path"
 	"path/filepath"
 	"reflect"
 	"strings"
@@ -35,6 +36,11 @@ type IdlExtractStruct struct {
 	StructFields  []*StructField
 	InterfaceInfo *InterfaceInfo
 	UpdateInfo
+	AnnotationInfo
+
+	// ModelDir and ModelPackage locate the generated go package of the struct
+	ModelDir     string
+	ModelPackage string
 }
 
 type InterfaceInfo struct {
@@ -48,14 +54,26 @@ type InterfaceMethod struct {
 	Params           code.Params
 	Returns          code.Returns
 	BelongedToStruct *IdlExtractStruct
+
+	// CacheIDParamName is the param compared with the id by the query of the method, it is resolved by
+	// the parser for the cached repository and empty if the query does not select one document by the id
+	CacheIDParamName string
 }
 
 type StructField struct {
 	Name               string
 	Type               code.Type
 	Tag                reflect.StructTag
+	Optional           bool // the thrift field is optional, its go type is a pointer
+	OmitEmpty          bool // the bson tag has omitempty option
+	Validation         *FieldValidation
 	IsBelongedToStruct bool
 	BelongedToStruct   *IdlExtractStruct
+
+	// EnumValues are the values of the enum field or the enum elements of the container field,
+	// ElementStruct is the struct of the elements of the container field
+	EnumValues    []int64
+	ElementStruct *IdlExtractStruct
 }
 
 type UpdateInfo struct {
@@ -77,6 +95,9 @@ func newIdlExtractStruct(name string) *IdlExtractStruct {
 			PreMethodNamesMap: map[string]struct{}{},
 			PreIfMethods:      []*InterfaceMethod{},
 		},
+		AnnotationInfo: AnnotationInfo{
+			CollectionName: GetPkgName(name),
+		},
 	}
 }
 
@@ -257,6 +278,24 @@ func getType(expr ast.Expr, pkgName string, isPbCall bool) code.Type {
 
 	case *ast.InterfaceType:
 		return code.InterfaceType{}
+
+	case *ast.FuncType:
+		funcType := code.FuncType{}
+		for _, param := range expr.Params.List {
+			paramType := getType(param.Type, pkgName, isPbCall)
+			for i := 0; i < len(param.Names) || i == 0; i++ {
+				funcType.Params = append(funcType.Params, paramType)
+			}
+		}
+		if expr.Results != nil {
+			for _, result := range expr.Results.List {
+				resultType := getType(result.Type, pkgName, isPbCall)
+				for i := 0; i < len(result.Names) || i == 0; i++ {
+					funcType.Returns = append(funcType.Returns, resultType)
+				}
+			}
+		}
+		return funcType
 	}
 
 	return nil
@@ -269,6 +308,41 @@ func GetFileName(structName, prefix string) (fileMongoName, fileIfName string) {
 	return
 }
 
+// GetMockFileName returns the name of the mock file which is generated next to the interface file.
+func GetMockFileName(structName, prefix string) string {
+	dir := GetPkgName(structName)
+	return filepath.Join(prefix, dir, dir+"_repo_mock.go")
+}
+
+// GetExtraFileName returns the name of the file which is generated in the same package as the repository.
+func GetExtraFileName(structName, prefix, fileName string) string {
+	dir := GetPkgName(structName)
+	return filepath.Join(prefix, dir, fileName)
+}
+
+// MongoxPkgName is the package which is shared by all the repositories.
+const MongoxPkgName = "mongox"
+
+// GetMongoxFileName returns the name of the file which is generated in the shared package.
+func GetMongoxFileName(daoDir, fileName string) string {
+	return filepath.Join(daoDir, MongoxPkgName, fileName)
+}
+
+// OtelPkgName is the package of the OpenTelemetry adapter of the tracer, it is located in the shared package.
+const OtelPkgName = "mongootel"
+
+// GetMongoxImportPath returns the import path of the shared package, the dao dir is located
+// by its path relative to the out dir, which is the root of the go module.
+func GetMongoxImportPath(goMod, outDir, daoDir string) string {
+	rel := daoDir
+	if filepath.IsAbs(daoDir) {
+		if r, err := filepath.Rel(outDir, daoDir); err == nil {
+			rel = r
+		}
+	}
+	return path.Join(goMod, filepath.ToSlash(rel), MongoxPkgName)
+}
+
 func GetPkgName(structName string) string {
 	tokens := camelcase.Split(structName)
 	dir := ""
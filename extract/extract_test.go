@@ -0,0 +1,269 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/cloudwego/thriftgo/plugin"
+	"github.com/hertz-contrib/thrift-gen-mongo/args"
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+)
+
+// videoFields are the fields of the Video struct of the test IDLs.
+const videoFields = `
+    1: i64 Id (go.tag="bson:\"_id,omitempty\"")
+    2: string Title (go.tag="bson:\"title,omitempty\"")
+    3: i32 Status (go.tag="bson:\"status\"")
+    4: optional string Lang (go.tag="bson:\"lang,omitempty\"")
+    5: i64 DeletedAt (go.tag="bson:\"deleted_at,omitempty\"")
+    6: bool Removed (go.tag="bson:\"removed\"")
+    7: i64 CreatedAt (go.tag="bson:\"created_at,omitempty\"")
+    8: i64 UpdatedAt (go.tag="bson:\"updated_at\"")
+    9: string TenantId (go.tag="bson:\"tenant_id\"")
+`
+
+// extractVideo extracts the Video struct declared with the annotations, a FindById and a Tx method.
+func extractVideo(annotations ...string) (*IdlExtractStruct, error) {
+	idl := "namespace go video\nstruct Video {" + videoFields + "}\n(\n" +
+		"    mongo.FindByIdEqual = \"FindById(ctx context.Context, id int64) (*video.Video, error)\"\n" +
+		"    mongo.TransactionInsertOne = \"Tx(ctx context.Context, client *mongo.Client, v *video.Video) error\"\n"
+	for _, annotation := range annotations {
+		idl += "    " + annotation + "\n"
+	}
+	idl += ")\n"
+
+	structs, err := extractIDL(idl)
+	if err != nil {
+		return nil, err
+	}
+	return structs[0], nil
+}
+
+func extractIDL(idl string) ([]*IdlExtractStruct, error) {
+	ast, err := parser.ParseString("video.thrift", idl)
+	if err != nil {
+		return nil, err
+	}
+	meta := &ThriftMeta{
+		Req: &plugin.Request{AST: ast},
+		Args: &args.Arguments{
+			PackagePrefix: "test/biz/model",
+			ModelDir:      "biz/model",
+			DaoDir:        "biz/dao",
+		},
+		ImportPaths: make([]string, 0, 10),
+	}
+	return meta.ParseThriftIdl()
+}
+
+func TestExtractStructAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations []string
+		check       func(st *IdlExtractStruct) bool
+		err         string
+	}{
+		{
+			name:        "soft delete timestamp",
+			annotations: []string{`mongo.soft_delete = "deleted_at"`},
+			check:       func(st *IdlExtractStruct) bool { return st.SoftDeleteField.Name == "DeletedAt" },
+		},
+		{
+			name:        "soft delete flag",
+			annotations: []string{`mongo.soft_delete = "Removed"`},
+			check:       func(st *IdlExtractStruct) bool { return st.SoftDeleteField.Name == "Removed" },
+		},
+		{
+			name:        "soft delete string",
+			annotations: []string{`mongo.soft_delete = "Title"`},
+			err:         "should be bool or i64",
+		},
+		{
+			name:        "timestamps",
+			annotations: []string{`mongo.created_at = "created_at"`, `mongo.updated_at = "UpdatedAt"`},
+			check: func(st *IdlExtractStruct) bool {
+				return st.CreatedAtField.Name == "CreatedAt" && st.UpdatedAtField.Name == "UpdatedAt"
+			},
+		},
+		{
+			name:        "created at without omitempty",
+			annotations: []string{`mongo.created_at = "updated_at"`},
+			err:         "should have omitempty option",
+		},
+		{
+			name:  "default collection",
+			check: func(st *IdlExtractStruct) bool { return st.CollectionName == "video" && st.DatabaseName == "" },
+		},
+		{
+			name:        "collection and database",
+			annotations: []string{`mongo.collection = "videos"`, `mongo.database = "media"`},
+			check:       func(st *IdlExtractStruct) bool { return st.CollectionName == "videos" && st.DatabaseName == "media" },
+		},
+		{
+			name:        "system collection",
+			annotations: []string{`mongo.collection = "system.views"`},
+			err:         "invalid collection name",
+		},
+		{
+			name:        "dotted database",
+			annotations: []string{`mongo.database = "a.b"`},
+			err:         "invalid database name",
+		},
+		{
+			name:        "id strategy",
+			annotations: []string{`mongo.id = "Id"`, `mongo.id_strategy = "Snowflake"`},
+			check:       func(st *IdlExtractStruct) bool { return st.IDField.Name == "Id" && st.IDStrategy == "snowflake" },
+		},
+		{
+			name:        "id strategy without id",
+			annotations: []string{`mongo.id_strategy = "snowflake"`},
+			err:         "requires annotation mongo.id",
+		},
+		{
+			name:        "uuid of an i64 id",
+			annotations: []string{`mongo.id = "Id"`, `mongo.id_strategy = "uuidv7"`},
+			err:         "should be string for the uuidv7 strategy",
+		},
+		{
+			name:        "unknown id strategy",
+			annotations: []string{`mongo.id = "Id"`, `mongo.id_strategy = "random"`},
+			err:         "unknown strategy random",
+		},
+		{
+			name:        "sequence defaults",
+			annotations: []string{`mongo.id = "Id"`, `mongo.id_strategy = "sequence"`},
+			check: func(st *IdlExtractStruct) bool {
+				return st.SequenceName == "video" && st.SequenceCollection == "counters" && !st.SequenceBatch
+			},
+		},
+		{
+			name: "sequence",
+			annotations: []string{`mongo.id = "Id"`, `mongo.id_strategy = "sequence"`, `mongo.sequence = "videos"`,
+				`mongo.sequence_collection = "ids"`, `mongo.sequence_batch = "true"`},
+			check: func(st *IdlExtractStruct) bool {
+				return st.SequenceName == "videos" && st.SequenceCollection == "ids" && st.SequenceBatch
+			},
+		},
+		{
+			name:        "sequence without the strategy",
+			annotations: []string{`mongo.id = "Id"`, `mongo.id_strategy = "snowflake"`, `mongo.sequence = "videos"`},
+			err:         "the sequence annotations require the sequence strategy",
+		},
+		{
+			name:        "optional id",
+			annotations: []string{`mongo.id = "Lang"`},
+			err:         "should be required or default string, i64 or i32",
+		},
+		{
+			name:        "tenant",
+			annotations: []string{`mongo.tenant = "tenant_id"`},
+			check:       func(st *IdlExtractStruct) bool { return st.TenantField.Name == "TenantId" },
+		},
+		{
+			name:        "optional tenant",
+			annotations: []string{`mongo.tenant = "Lang"`},
+			err:         "should be required or default string",
+		},
+		{
+			name:        "unknown field",
+			annotations: []string{`mongo.soft_delete = "Owner"`},
+			err:         "Owner",
+		},
+		{
+			name:        "chunk size",
+			annotations: []string{`mongo.chunk_size = "0"`},
+			err:         "should be a positive integer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := extractVideo(tt.annotations...)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(st) {
+				t.Fatalf("unexpected annotation info %+v", st.AnnotationInfo)
+			}
+		})
+	}
+}
+
+func TestExtractTimestampTypes(t *testing.T) {
+	date := code.SelectorExprType{X: "time", Sel: "Time"}
+	tests := []struct {
+		name    string
+		created *StructField
+		updated *StructField
+		err     string
+	}{
+		{
+			name:    "dates",
+			created: &StructField{Name: "CreatedAt", Type: date, OmitEmpty: true},
+			updated: &StructField{Name: "UpdatedAt", Type: date},
+		},
+		{
+			name:    "date and milliseconds",
+			created: &StructField{Name: "CreatedAt", Type: date, OmitEmpty: true},
+			updated: &StructField{Name: "UpdatedAt", Type: code.IdentType("int64")},
+			err:     "the timestamp fields CreatedAt and UpdatedAt should have the same type",
+		},
+		{
+			name:    "optional date",
+			created: &StructField{Name: "CreatedAt", Type: date, OmitEmpty: true, Optional: true},
+			updated: &StructField{Name: "UpdatedAt", Type: date},
+			err:     "should be required or default i64 or time.Time",
+		},
+		{
+			name:    "string",
+			created: &StructField{Name: "CreatedAt", Type: code.IdentType("string"), OmitEmpty: true},
+			updated: &StructField{Name: "UpdatedAt", Type: date},
+			err:     "should be required or default i64 or time.Time",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawStruct := newIdlExtractStruct("Video")
+			rawStruct.StructFields = []*StructField{tt.created, tt.updated}
+			st := &parser.StructLike{Name: "Video", Annotations: parser.Annotations{
+				{Key: CreatedAtAnnotation, Values: []string{"CreatedAt"}},
+				{Key: UpdatedAtAnnotation, Values: []string{"UpdatedAt"}},
+			}}
+			err := extractStructAnnotations(st, rawStruct)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rawStruct.CreatedAtField != tt.created || rawStruct.UpdatedAtField != tt.updated {
+				t.Fatalf("unexpected timestamp fields %+v %+v", rawStruct.CreatedAtField, rawStruct.UpdatedAtField)
+			}
+		})
+	}
+}
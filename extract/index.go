@@ -0,0 +1,187 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IndexAnnotation declares an index of the collection, the annotation may have a value for each index:
+//
+//	"Field [asc|desc|text|2dsphere], ...[; unique, sparse, ttl=seconds, name=name, partial=Field Op Value And ...]"
+//
+// the fields are referenced by their names in the struct, the nested fields join the names like the methods.
+const IndexAnnotation = "mongo.index"
+
+// index key kinds, the value of the key in the index document
+const (
+	IndexAsc      = "1"
+	IndexDesc     = "-1"
+	IndexText     = "text"
+	Index2dsphere = "2dsphere"
+)
+
+// operators of the partial filter expression
+var partialOperators = map[string]string{
+	"Equal":  "$eq",
+	"Gt":     "$gt",
+	"Gte":    "$gte",
+	"Lt":     "$lt",
+	"Lte":    "$lte",
+	"Exists": "$exists",
+}
+
+type Index struct {
+	Keys    []*IndexKey
+	Name    string
+	Unique  bool
+	Sparse  bool
+	TTL     string // the seconds after which the documents expire by the time.Time key, it is empty if not set
+	Partial []*IndexCondition
+}
+
+type IndexKey struct {
+	// Field is the field name referenced by the annotation, BsonPath is resolved by the parser
+	Field    string
+	BsonPath string
+	Kind     string
+}
+
+// IndexCondition is a condition of the partial filter expression, Value is the go literal resolved by the parser.
+type IndexCondition struct {
+	Field    string
+	BsonPath string
+	Operator string
+	RawValue string
+	Value    string
+}
+
+// MongoOperator returns the query operator of the condition.
+func (ic *IndexCondition) MongoOperator() string {
+	return partialOperators[ic.Operator]
+}
+
+func extractIndexes(structName string, values []string, rawStruct *IdlExtractStruct) error {
+	for _, value := range values {
+		index, err := parseIndex(value)
+		if err != nil {
+			return fmt.Errorf("struct %s: annotation %s: %q: %s", structName, IndexAnnotation, value, err.Error())
+		}
+		rawStruct.Indexes = append(rawStruct.Indexes, index)
+	}
+	return nil
+}
+
+func parseIndex(value string) (*Index, error) {
+	keys, opts := value, ""
+	if i := strings.Index(value, ";"); i != -1 {
+		keys, opts = value[:i], value[i+1:]
+	}
+
+	index := &Index{}
+	for _, item := range strings.Split(keys, ",") {
+		fields := strings.Fields(item)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("the key %q should be \"Field [asc|desc|text|2dsphere]\"", strings.TrimSpace(item))
+		}
+		key := &IndexKey{Field: strings.ReplaceAll(fields[0], ".", ""), Kind: IndexAsc}
+		if len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "asc":
+			case "desc":
+				key.Kind = IndexDesc
+			case IndexText:
+				key.Kind = IndexText
+			case Index2dsphere:
+				key.Kind = Index2dsphere
+			default:
+				return nil, fmt.Errorf("unknown key kind %s", fields[1])
+			}
+		}
+		index.Keys = append(index.Keys, key)
+	}
+
+	for _, item := range strings.Split(opts, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, arg := item, ""
+		if i := strings.Index(item, "="); i != -1 {
+			name, arg = strings.TrimSpace(item[:i]), strings.TrimSpace(item[i+1:])
+		}
+		switch name {
+		case "unique":
+			index.Unique = true
+		case "sparse":
+			index.Sparse = true
+		case "name":
+			if arg == "" {
+				return nil, fmt.Errorf("the name should not be empty")
+			}
+			index.Name = arg
+		case "ttl":
+			if seconds, err := strconv.ParseInt(arg, 10, 32); err != nil || seconds < 0 {
+				return nil, fmt.Errorf("the ttl %s should be non-negative seconds", arg)
+			}
+			if len(index.Keys) != 1 {
+				return nil, fmt.Errorf("the ttl index should have only one key")
+			}
+			index.TTL = arg
+		case "partial":
+			conditions, err := parsePartial(arg)
+			if err != nil {
+				return nil, err
+			}
+			index.Partial = conditions
+		default:
+			return nil, fmt.Errorf("unknown option %s", item)
+		}
+	}
+	if index.Sparse && len(index.Partial) != 0 {
+		return nil, fmt.Errorf("the index should not be both sparse and partial")
+	}
+	return index, nil
+}
+
+func parsePartial(arg string) ([]*IndexCondition, error) {
+	conditions := make([]*IndexCondition, 0, 2)
+	for _, item := range strings.Split(arg, " And ") {
+		fields := strings.Fields(item)
+		if len(fields) < 2 || partialOperators[fields[1]] == "" {
+			return nil, fmt.Errorf("the partial condition %q should be \"Field Op Value\", Op is one of "+
+				"Equal, Gt, Gte, Lt, Lte and Exists", strings.TrimSpace(item))
+		}
+		condition := &IndexCondition{
+			Field:    strings.ReplaceAll(fields[0], ".", ""),
+			Operator: fields[1],
+			RawValue: strings.TrimSpace(strings.Join(fields[2:], " ")),
+		}
+		if condition.Operator == "Exists" {
+			if condition.RawValue != "" {
+				return nil, fmt.Errorf("the partial condition %q should not have a value", strings.TrimSpace(item))
+			}
+			condition.Value = "true"
+		} else if condition.RawValue == "" {
+			return nil, fmt.Errorf("the partial condition %q should have a value", strings.TrimSpace(item))
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseIndex(t *testing.T) {
+	tests := []struct {
+		value string
+		want  *Index
+		err   string
+	}{
+		{
+			value: "Title",
+			want:  &Index{Keys: []*IndexKey{{Field: "Title", Kind: IndexAsc}}},
+		},
+		{
+			value: "TenantId asc, Author.Name desc, Title text; unique, sparse, name=by_author",
+			want: &Index{
+				Keys: []*IndexKey{
+					{Field: "TenantId", Kind: IndexAsc},
+					{Field: "AuthorName", Kind: IndexDesc},
+					{Field: "Title", Kind: IndexText},
+				},
+				Name:   "by_author",
+				Unique: true,
+				Sparse: true,
+			},
+		},
+		{
+			value: "Location 2dsphere",
+			want:  &Index{Keys: []*IndexKey{{Field: "Location", Kind: Index2dsphere}}},
+		},
+		{
+			value: "ExpireAt; ttl=3600",
+			want:  &Index{Keys: []*IndexKey{{Field: "ExpireAt", Kind: IndexAsc}}, TTL: "3600"},
+		},
+		{
+			value: "Title; partial=Status Gte 1 And Public Equal true And Lang Exists",
+			want: &Index{
+				Keys: []*IndexKey{{Field: "Title", Kind: IndexAsc}},
+				Partial: []*IndexCondition{
+					{Field: "Status", Operator: "Gte", RawValue: "1"},
+					{Field: "Public", Operator: "Equal", RawValue: "true"},
+					{Field: "Lang", Operator: "Exists", Value: "true"},
+				},
+			},
+		},
+		{value: "Title up", err: "unknown key kind up"},
+		{value: "Title asc desc", err: "should be"},
+		{value: "Title, ", err: "should be"},
+		{value: "Title; name=", err: "the name should not be empty"},
+		{value: "ExpireAt; ttl=-1", err: "non-negative seconds"},
+		{value: "ExpireAt; ttl=1h", err: "non-negative seconds"},
+		{value: "ExpireAt, Title; ttl=60", err: "only one key"},
+		{value: "Title; partial=Status Like 1", err: "Op is one of"},
+		{value: "Title; partial=Status Gte", err: "should have a value"},
+		{value: "Title; partial=Lang Exists false", err: "should not have a value"},
+		{value: "Title; sparse, partial=Status Gt 1", err: "both sparse and partial"},
+		{value: "Title; hidden", err: "unknown option hidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseIndex(tt.value)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// elementStructs caches the structs of the container elements, the struct is cached before its fields
+// are extracted, so that the recursive structs refer to themselves instead of being extracted endlessly.
+var elementStructs = map[*parser.StructLike]*IdlExtractStruct{}
+
+// extractFieldSchema records the enum values and the element struct of the field which are used by the
+// $jsonSchema validator, the elements of the nested containers are resolved to the innermost one.
+func extractFieldSchema(node *parser.Type, file *parser.Thrift, sf *StructField) error {
+	for node.ValueType != nil {
+		node = node.ValueType
+	}
+	st, enum, stFile := lookupThriftType(node.Name, file)
+	if enum != nil {
+		for _, value := range enum.Values {
+			sf.EnumValues = append(sf.EnumValues, value.Value)
+		}
+		return nil
+	}
+	if st == nil || sf.IsBelongedToStruct {
+		return nil
+	}
+
+	if rs, ok := elementStructs[st]; ok {
+		sf.ElementStruct = rs
+		return nil
+	}
+	rs := &IdlExtractStruct{
+		Name:         st.Name,
+		StructFields: make([]*StructField, 0, 10),
+	}
+	elementStructs[st] = rs
+	sf.ElementStruct = rs
+	return extractIdlStruct(st, stFile, rs)
+}
+
+// lookupThriftType returns the struct or the enum named by name and the file which declares it.
+func lookupThriftType(name string, file *parser.Thrift) (*parser.StructLike, *parser.Enum, *parser.Thrift) {
+	if index := strings.Index(name, "."); index != -1 {
+		for _, include := range file.Includes {
+			if strings.Contains(filepath.Base(include.Reference.Filename), name[:index]) {
+				return lookupThriftType(name[index+1:], include.Reference)
+			}
+		}
+		return nil, nil, nil
+	}
+	for _, st := range file.Structs {
+		if st.Name == name {
+			return st, nil, file
+		}
+	}
+	for _, enum := range file.Enums {
+		if enum.Name == name {
+			return nil, enum, file
+		}
+	}
+	return nil, nil, nil
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ShardKeyAnnotation declares the shard key of the collection:
+//
+//	"Field [asc|hashed], ...[; unique, strict]"
+//
+// the One operations without equality on the shard key are reported as warnings, they are errors with strict.
+const ShardKeyAnnotation = "mongo.shard_key"
+
+// IndexHashed is the key kind of the hashed shard key.
+const IndexHashed = "hashed"
+
+type ShardKey struct {
+	Keys   []*IndexKey
+	Unique bool
+	Strict bool
+}
+
+func extractShardKey(structName, value string, rawStruct *IdlExtractStruct) error {
+	shardKey, err := parseShardKey(value)
+	if err != nil {
+		return fmt.Errorf("struct %s: annotation %s: %q: %s", structName, ShardKeyAnnotation, value, err.Error())
+	}
+	rawStruct.ShardKey = shardKey
+	return nil
+}
+
+func parseShardKey(value string) (*ShardKey, error) {
+	keys, opts := value, ""
+	if i := strings.Index(value, ";"); i != -1 {
+		keys, opts = value[:i], value[i+1:]
+	}
+
+	shardKey := &ShardKey{}
+	hashed := false
+	for _, item := range strings.Split(keys, ",") {
+		fields := strings.Fields(item)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("the key %q should be \"Field [asc|hashed]\"", strings.TrimSpace(item))
+		}
+		key := &IndexKey{Field: strings.ReplaceAll(fields[0], ".", ""), Kind: IndexAsc}
+		if len(fields) == 2 {
+			switch strings.ToLower(fields[1]) {
+			case "asc":
+			case IndexHashed:
+				if hashed {
+					return nil, fmt.Errorf("the shard key should have only one hashed field")
+				}
+				hashed = true
+				key.Kind = IndexHashed
+			default:
+				return nil, fmt.Errorf("unknown key kind %s", fields[1])
+			}
+		}
+		shardKey.Keys = append(shardKey.Keys, key)
+	}
+
+	for _, item := range strings.Split(opts, ",") {
+		switch item = strings.TrimSpace(item); item {
+		case "":
+		case "unique":
+			shardKey.Unique = true
+		case "strict":
+			shardKey.Strict = true
+		default:
+			return nil, fmt.Errorf("unknown option %s", item)
+		}
+	}
+	if shardKey.Unique && hashed {
+		return nil, fmt.Errorf("the hashed shard key should not be unique")
+	}
+	return shardKey, nil
+}
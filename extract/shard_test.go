@@ -0,0 +1,70 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseShardKey(t *testing.T) {
+	tests := []struct {
+		value string
+		want  *ShardKey
+		err   string
+	}{
+		{
+			value: "TenantId",
+			want:  &ShardKey{Keys: []*IndexKey{{Field: "TenantId", Kind: IndexAsc}}},
+		},
+		{
+			value: "TenantId asc, Author.Name; unique, strict",
+			want: &ShardKey{
+				Keys:   []*IndexKey{{Field: "TenantId", Kind: IndexAsc}, {Field: "AuthorName", Kind: IndexAsc}},
+				Unique: true,
+				Strict: true,
+			},
+		},
+		{
+			value: "TenantId, Id HASHED",
+			want:  &ShardKey{Keys: []*IndexKey{{Field: "TenantId", Kind: IndexAsc}, {Field: "Id", Kind: IndexHashed}}},
+		},
+		{value: "Id desc", err: "unknown key kind desc"},
+		{value: "Id hashed, Title hashed", err: "only one hashed field"},
+		{value: "Id hashed; unique", err: "should not be unique"},
+		{value: "Id; sparse", err: "unknown option sparse"},
+		{value: ", Id", err: "should be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseShardKey(tt.value)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
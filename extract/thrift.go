@@ -57,13 +57,16 @@ func (info *ThriftMeta) ParseThriftIdl() (rawStructs []*IdlExtractStruct, err er
 		for _, st := range file.Structs {
 			hasInterface := false
 			for _, anno := range st.Annotations {
-				if strings.Index(anno.Key, "mongo.") == 0 && len(anno.Key) > 6 {
+				if isMethodAnnotation(anno.Key) {
 					hasInterface = true
 					break
 				}
 			}
 			if hasInterface {
 				rawStruct := newIdlExtractStruct(utils.CamelString(st.Name))
+				rawStruct.ModelDir = filepath.Join(info.Args.ModelDir,
+					strings.ReplaceAll(file.Namespaces[0].Name, ".", consts.Slash))
+				rawStruct.ModelPackage = filepath.Base(rawStruct.ModelDir)
 				if err = extractIdlStruct(st, file, rawStruct); err != nil {
 					return err
 				}
@@ -71,10 +74,14 @@ func (info *ThriftMeta) ParseThriftIdl() (rawStructs []*IdlExtractStruct, err er
 				if len(rawStruct.StructFields) != 0 {
 					rawStructs = append(rawStructs, rawStruct)
 
+					if err = extractStructAnnotations(st, rawStruct); err != nil {
+						return err
+					}
+
 					tokens := make([]string, 0, 10)
 					methods := ""
 					for _, anno := range st.Annotations {
-						if strings.Index(anno.Key, "mongo.") == 0 {
+						if isMethodAnnotation(anno.Key) {
 							methods += anno.GetValues()[0] + "\n"
 							tokens = append(tokens, anno.Key[6:])
 						}
@@ -88,6 +95,12 @@ func (info *ThriftMeta) ParseThriftIdl() (rawStructs []*IdlExtractStruct, err er
 					if err = extractIdlInterface(rawInterface, rawStruct, tokens); err != nil {
 						return err
 					}
+					if err = checkTransactionOptions(st.Name, rawStruct); err != nil {
+						return err
+					}
+					if err = checkCacheOptions(st.Name, rawStruct); err != nil {
+						return err
+					}
 				}
 			}
 		}
@@ -108,7 +121,10 @@ func extractIdlStruct(st *parser.StructLike, file *parser.Thrift, rawStruct *Idl
 	for _, field := range st.Fields {
 		fag := field.Annotations.Get("go.tag")
 		if len(field.Annotations) > 0 && fag != nil && strings.Contains(fag[0], bson) {
+			fieldCount := len(rawStruct.StructFields)
 			tag := handleTagOmitempty(fag[0])
+			omitEmpty := strings.Contains(fag[0], ",omitempty")
+			optional := field.Requiredness == parser.FieldType_Optional
 
 			t := convertThriftType(field.Type, file)
 			if t == nil {
@@ -116,9 +132,11 @@ func extractIdlStruct(st *parser.StructLike, file *parser.Thrift, rawStruct *Idl
 			}
 			if isThriftBaseType(field.Type.Name) || isThriftContainerType(field.Type.Name) {
 				sf := &StructField{
-					Name: utils.CamelString(field.Name),
-					Type: t,
-					Tag:  tag,
+					Name:      utils.CamelString(field.Name),
+					Type:      t,
+					Tag:       tag,
+					Optional:  optional,
+					OmitEmpty: omitEmpty,
 				}
 				rawStruct.StructFields = append(rawStruct.StructFields, sf)
 			} else if strings.Contains(field.Type.Name, ".") {
@@ -144,9 +162,11 @@ func extractIdlStruct(st *parser.StructLike, file *parser.Thrift, rawStruct *Idl
 				// enum
 				if subStruct == nil {
 					sf := &StructField{
-						Name: utils.CamelString(field.Name),
-						Type: t,
-						Tag:  tag,
+						Name:      utils.CamelString(field.Name),
+						Type:      t,
+						Tag:       tag,
+						Optional:  optional,
+						OmitEmpty: omitEmpty,
 					}
 					rawStruct.StructFields = append(rawStruct.StructFields, sf)
 				} else {
@@ -161,6 +181,8 @@ func extractIdlStruct(st *parser.StructLike, file *parser.Thrift, rawStruct *Idl
 						Name:               utils.CamelString(field.Name),
 						Type:               t,
 						Tag:                tag,
+						Optional:           optional,
+						OmitEmpty:          omitEmpty,
 						IsBelongedToStruct: true,
 						BelongedToStruct:   rs,
 					}
@@ -178,9 +200,11 @@ func extractIdlStruct(st *parser.StructLike, file *parser.Thrift, rawStruct *Idl
 				// enum
 				if subStruct == nil {
 					sf := &StructField{
-						Name: utils.CamelString(field.Name),
-						Type: t,
-						Tag:  tag,
+						Name:      utils.CamelString(field.Name),
+						Type:      t,
+						Tag:       tag,
+						Optional:  optional,
+						OmitEmpty: omitEmpty,
 					}
 					rawStruct.StructFields = append(rawStruct.StructFields, sf)
 				} else {
@@ -195,12 +219,20 @@ func extractIdlStruct(st *parser.StructLike, file *parser.Thrift, rawStruct *Idl
 						Name:               utils.CamelString(field.Name),
 						Type:               t,
 						Tag:                tag,
+						Optional:           optional,
+						OmitEmpty:          omitEmpty,
 						IsBelongedToStruct: true,
 						BelongedToStruct:   rs,
 					}
 					rawStruct.StructFields = append(rawStruct.StructFields, sf)
 				}
 			}
+
+			if len(rawStruct.StructFields) > fieldCount {
+				if err := extractFieldSchema(field.Type, file, rawStruct.StructFields[fieldCount]); err != nil {
+					return err
+				}
+			}
 		}
 	}
 	return nil
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TransactionOptionsAnnotation configures the session and the transaction of a Transaction method,
+// every value is "MethodName: key=value, ..." and the annotation may have a value for each method.
+const TransactionOptionsAnnotation = "mongo.transaction_options"
+
+// TransactionOptions are the options of the Transaction method, the empty values use the driver defaults.
+type TransactionOptions struct {
+	// ReadConcern is one of local, available, majority, linearizable and snapshot
+	ReadConcern string
+	// WriteConcern is majority or the number of the acknowledging members
+	WriteConcern string
+	// ReadPreference is one of primary, primaryPreferred, secondary, secondaryPreferred and nearest
+	ReadPreference string
+	// MaxCommitTime is the maximum time of the commitTransaction command, it is zero if not set
+	MaxCommitTime time.Duration
+	// CausalConsistency is set on the session, it is nil if not set
+	CausalConsistency *bool
+}
+
+var readConcerns = map[string]string{
+	"local":        "Local",
+	"available":    "Available",
+	"majority":     "Majority",
+	"linearizable": "Linearizable",
+	"snapshot":     "Snapshot",
+}
+
+var readPreferences = map[string]string{
+	"primary":            "Primary",
+	"primarypreferred":   "PrimaryPreferred",
+	"secondary":          "Secondary",
+	"secondarypreferred": "SecondaryPreferred",
+	"nearest":            "Nearest",
+}
+
+// ReadConcernFunc returns the function of the readconcern package which creates the read concern.
+func (to *TransactionOptions) ReadConcernFunc() string {
+	return readConcerns[to.ReadConcern]
+}
+
+// ReadPreferenceFunc returns the function of the readpref package which creates the read preference.
+func (to *TransactionOptions) ReadPreferenceFunc() string {
+	return readPreferences[strings.ToLower(to.ReadPreference)]
+}
+
+// extractTransactionOptions parses the values of the transaction options annotation of the struct.
+func extractTransactionOptions(structName string, values []string, rawStruct *IdlExtractStruct) error {
+	for _, value := range values {
+		index := strings.Index(value, ":")
+		if index == -1 {
+			return fmt.Errorf("struct %s: annotation %s: %q should be \"MethodName: key=value, ...\"",
+				structName, TransactionOptionsAnnotation, value)
+		}
+		methodName := strings.TrimSpace(value[:index])
+		if _, ok := rawStruct.TransactionOptions[methodName]; ok {
+			return fmt.Errorf("struct %s: annotation %s: the options of method %s are repeated",
+				structName, TransactionOptionsAnnotation, methodName)
+		}
+
+		options := &TransactionOptions{}
+		for _, item := range strings.Split(value[index+1:], ",") {
+			if strings.TrimSpace(item) == "" {
+				continue
+			}
+			if err := options.set(item); err != nil {
+				return fmt.Errorf("struct %s: annotation %s: method %s: %s",
+					structName, TransactionOptionsAnnotation, methodName, err.Error())
+			}
+		}
+		if rawStruct.TransactionOptions == nil {
+			rawStruct.TransactionOptions = map[string]*TransactionOptions{}
+		}
+		rawStruct.TransactionOptions[methodName] = options
+	}
+	return nil
+}
+
+func (to *TransactionOptions) set(item string) error {
+	pair := strings.SplitN(item, "=", 2)
+	if len(pair) != 2 {
+		return fmt.Errorf("%q should be key=value", strings.TrimSpace(item))
+	}
+	key, value := strings.TrimSpace(pair[0]), strings.TrimSpace(pair[1])
+
+	switch key {
+	case "read_concern":
+		if _, ok := readConcerns[value]; !ok {
+			return fmt.Errorf("unknown read concern %s", value)
+		}
+		to.ReadConcern = value
+	case "write_concern":
+		if w, err := strconv.Atoi(value); value != "majority" && (err != nil || w < 0) {
+			return fmt.Errorf("the write concern %s should be majority or a non-negative integer", value)
+		}
+		to.WriteConcern = value
+	case "read_preference":
+		if _, ok := readPreferences[strings.ToLower(value)]; !ok {
+			return fmt.Errorf("unknown read preference %s", value)
+		}
+		to.ReadPreference = value
+	case "max_commit_time":
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 || d%time.Millisecond != 0 {
+			return fmt.Errorf("the max commit time %s should be a positive duration in milliseconds", value)
+		}
+		to.MaxCommitTime = d
+	case "causal_consistency":
+		causal, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("the causal consistency should be true or false")
+		}
+		to.CausalConsistency = &causal
+	default:
+		return fmt.Errorf("unknown option %s", key)
+	}
+	return nil
+}
+
+// checkTransactionOptions checks that the transaction options are set on the Transaction methods of the struct.
+func checkTransactionOptions(structName string, rawStruct *IdlExtractStruct) error {
+	for methodName := range rawStruct.TransactionOptions {
+		found := false
+		for _, method := range append(rawStruct.PreIfMethods[:len(rawStruct.PreIfMethods):len(rawStruct.PreIfMethods)],
+			rawStruct.InterfaceInfo.Methods...) {
+			if method.Name == methodName && strings.HasPrefix(method.ParsedTokens, "Transaction") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("struct %s: annotation %s: %s is not a Transaction method",
+				structName, TransactionOptionsAnnotation, methodName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractTransactionOptions(t *testing.T) {
+	causal := false
+	tests := []struct {
+		name   string
+		values []string
+		want   map[string]*TransactionOptions
+		err    string
+	}{
+		{
+			name: "all options",
+			values: []string{"Tx: read_concern=snapshot, write_concern=majority, read_preference=primaryPreferred, " +
+				"max_commit_time=1500ms, causal_consistency=false"},
+			want: map[string]*TransactionOptions{"Tx": {
+				ReadConcern:       "snapshot",
+				WriteConcern:      "majority",
+				ReadPreference:    "primaryPreferred",
+				MaxCommitTime:     1500 * time.Millisecond,
+				CausalConsistency: &causal,
+			}},
+		},
+		{
+			name:   "several methods",
+			values: []string{"Tx1: write_concern=2", "Tx2:"},
+			want:   map[string]*TransactionOptions{"Tx1": {WriteConcern: "2"}, "Tx2": {}},
+		},
+		{name: "no method", values: []string{"read_concern=local"}, err: "should be \"MethodName: key=value, ...\""},
+		{name: "repeated method", values: []string{"Tx: write_concern=1", "Tx: write_concern=2"}, err: "are repeated"},
+		{name: "no value", values: []string{"Tx: read_concern"}, err: "should be key=value"},
+		{name: "read concern", values: []string{"Tx: read_concern=strong"}, err: "unknown read concern strong"},
+		{name: "write concern", values: []string{"Tx: write_concern=-1"}, err: "majority or a non-negative integer"},
+		{name: "read preference", values: []string{"Tx: read_preference=any"}, err: "unknown read preference any"},
+		{name: "max commit time", values: []string{"Tx: max_commit_time=10us"}, err: "in milliseconds"},
+		{name: "causal consistency", values: []string{"Tx: causal_consistency=yes"}, err: "true or false"},
+		{name: "unknown option", values: []string{"Tx: retry=3"}, err: "unknown option retry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newIdlExtractStruct("Video")
+			err := extractTransactionOptions("Video", tt.values, st)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(st.TransactionOptions, tt.want) {
+				t.Fatalf("got %+v, want %+v", st.TransactionOptions, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckTransactionOptions(t *testing.T) {
+	if _, err := extractVideo(`mongo.transaction_options = "Tx: read_concern=majority"`); err != nil {
+		t.Fatal(err)
+	}
+	_, err := extractVideo(`mongo.transaction_options = "FindById: read_concern=majority"`)
+	if err == nil || !strings.Contains(err.Error(), "FindById is not a Transaction method") {
+		t.Fatalf("got error %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package extract
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// field annotations which generate the Validate method of the struct
+const (
+	RequiredAnnotation = "mongo.required"
+	MinAnnotation      = "mongo.min"
+	MaxAnnotation      = "mongo.max"
+	MinLenAnnotation   = "mongo.min_len"
+	MaxLenAnnotation   = "mongo.max_len"
+	PatternAnnotation  = "mongo.pattern"
+	EnumAnnotation     = "mongo.enum"
+)
+
+// FieldValidation stores the validation annotations of a field,
+// the empty string means the rule is not set.
+type FieldValidation struct {
+	Required bool
+	Min      string
+	Max      string
+	MinLen   string
+	MaxLen   string
+	Pattern  string
+	// Enum stores the allowed values as go literals
+	Enum []string
+}
+
+// HasValidation reports whether the struct has fields with validation annotations.
+func (st *IdlExtractStruct) HasValidation() bool {
+	for _, field := range st.StructFields {
+		if field.Validation != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPointer reports whether the go type of the field is a pointer.
+func (sf *StructField) IsPointer() bool {
+	t := sf.Type.RealName()
+	return strings.HasPrefix(t, "*") || (sf.Optional && !strings.HasPrefix(t, "[]") && !strings.HasPrefix(t, "map["))
+}
+
+// IsTime reports whether the go type of the field is time.Time, it is stored as the bson date.
+func (sf *StructField) IsTime() bool {
+	return sf.Type.RealName() == "time.Time"
+}
+
+// HasLen reports whether the go type of the field supports len.
+func (sf *StructField) HasLen() bool {
+	t := sf.Type.RealName()
+	return t == "string" || strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[")
+}
+
+func (sf *StructField) isNumber() bool {
+	switch sf.Type.RealName() {
+	case "int8", "int16", "int32", "int64", "float64":
+		return true
+	default:
+		return false
+	}
+}
+
+func (sf *StructField) isEnum() bool {
+	_, ok := sf.Type.(code.SelectorExprType)
+	return ok
+}
+
+func extractFieldValidations(st *parser.StructLike, rawStruct *IdlExtractStruct) error {
+	for _, field := range st.Fields {
+		validation := &FieldValidation{}
+		hasValidation := false
+		var sf *StructField
+		for _, anno := range field.Annotations {
+			if _, ok := fieldValidators[anno.Key]; !ok {
+				continue
+			}
+			if sf == nil {
+				var err error
+				if sf, err = rawStruct.lookupField(field.Name); err != nil {
+					return fmt.Errorf("struct %s: annotation %s: %s", st.Name, anno.Key, err.Error())
+				}
+			}
+			if len(anno.GetValues()) != 1 {
+				return fmt.Errorf("struct %s: field %s: annotation %s should have only one value",
+					st.Name, field.Name, anno.Key)
+			}
+			if err := fieldValidators[anno.Key](sf, validation, anno.GetValues()[0]); err != nil {
+				return fmt.Errorf("struct %s: field %s: annotation %s: %s", st.Name, field.Name, anno.Key, err.Error())
+			}
+			hasValidation = true
+		}
+		if hasValidation {
+			sf.Validation = validation
+		}
+	}
+
+	return nil
+}
+
+var fieldValidators = map[string]func(sf *StructField, validation *FieldValidation, value string) error{
+	RequiredAnnotation: func(sf *StructField, validation *FieldValidation, value string) error {
+		required, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("the value should be true or false")
+		}
+		if required && sf.Type.RealName() == "bool" && !sf.IsPointer() {
+			return fmt.Errorf("the required bool field should be optional")
+		}
+		validation.Required = required
+		return nil
+	},
+	MinAnnotation: func(sf *StructField, validation *FieldValidation, value string) error {
+		number, err := parseNumber(sf, value)
+		validation.Min = number
+		return err
+	},
+	MaxAnnotation: func(sf *StructField, validation *FieldValidation, value string) error {
+		number, err := parseNumber(sf, value)
+		validation.Max = number
+		return err
+	},
+	MinLenAnnotation: func(sf *StructField, validation *FieldValidation, value string) error {
+		length, err := parseLen(sf, value)
+		validation.MinLen = length
+		return err
+	},
+	MaxLenAnnotation: func(sf *StructField, validation *FieldValidation, value string) error {
+		length, err := parseLen(sf, value)
+		validation.MaxLen = length
+		return err
+	},
+	PatternAnnotation: func(sf *StructField, validation *FieldValidation, value string) error {
+		if sf.Type.RealName() != "string" {
+			return fmt.Errorf("the field should be string")
+		}
+		if _, err := regexp.Compile(value); err != nil {
+			return err
+		}
+		validation.Pattern = value
+		return nil
+	},
+	EnumAnnotation: func(sf *StructField, validation *FieldValidation, value string) error {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if sf.Type.RealName() == "string" {
+				validation.Enum = append(validation.Enum, strconv.Quote(item))
+				continue
+			}
+			if !sf.isNumber() && !sf.isEnum() {
+				return fmt.Errorf("the field should be string, number or enum")
+			}
+			if _, err := strconv.ParseFloat(item, 64); err != nil {
+				return fmt.Errorf("%s is not a number", item)
+			}
+			validation.Enum = append(validation.Enum, item)
+		}
+		return nil
+	},
+}
+
+func parseNumber(sf *StructField, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if !sf.isNumber() {
+		return "", fmt.Errorf("the field should be number")
+	}
+	if sf.Type.RealName() == "float64" {
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", fmt.Errorf("%s is not a number", value)
+		}
+		return value, nil
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+		return "", fmt.Errorf("%s is not an integer", value)
+	}
+	return value, nil
+}
+
+func parseLen(sf *StructField, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if !sf.HasLen() {
+		return "", fmt.Errorf("the field should be string, list, set or map")
+	}
+	if length, err := strconv.Atoi(value); err != nil || length < 0 {
+		return "", fmt.Errorf("%s is not a length", value)
+	}
+	return value, nil
+}
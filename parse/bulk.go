@@ -24,7 +24,7 @@ import (
 
 type BulkParse struct {
 	// Operations defines all the operations contained in the Bulk,
-	// supports Insert One(Many not support), Update One Many, Delete One Many
+	// supports Insert One(Many not support), Replace One, Update One Many, Delete One Many
 	Operations []Operation
 
 	// CtxParamName defines the method's context.Context param name when Bulk is called independently
@@ -32,6 +32,9 @@ type BulkParse struct {
 
 	// BelongedToMethod defines the method to which Bulk belongs
 	BelongedToMethod *extract.InterfaceMethod
+
+	// Unordered is true when the BulkUnordered form continues writing after an operation failed
+	Unordered bool
 }
 
 func newBulkParse() *BulkParse {
@@ -58,10 +61,15 @@ func (bp *BulkParse) parseBulk(tokens []string, method *extract.InterfaceMethod,
 
 	bp.BelongedToMethod = method
 
+	if len(tokens) > 0 && tokens[0] == unordered {
+		bp.Unordered = true
+		tokens = tokens[1:]
+	}
+
 	for index := 0; index < len(tokens); index++ {
 		if tokens[index] == Find || tokens[index] == Count || tokens[index] == Bulk || tokens[index] == Transaction {
 			return newMethodSyntaxError(method.Name, "the Bulk operation does not supports Find, Count, "+
-				"Bulk, Transaction, only supports Insert, Update, Delete")
+				"Bulk, Transaction, only supports Insert, Replace, Update, Delete")
 		}
 
 		if tokens[index] == Insert {
@@ -115,6 +123,23 @@ func (bp *BulkParse) parseBulk(tokens []string, method *extract.InterfaceMethod,
 			}
 		}
 
+		if tokens[index] == Replace {
+			if index == len(tokens)-1 || tokens[index+1] != One {
+				return newMethodSyntaxError(method.Name, "Replace should be followed by One")
+			}
+			if index+1 == len(tokens)-1 {
+				return newMethodSyntaxError(method.Name, "there is no content after Replace One")
+			}
+
+			noIndex := getNextOperationIndex(tokens, index+2, false)
+			rp := newReplaceParse()
+			if err := rp.parseReplace(tokens[index+2:noIndex], method, curParamIndex); err != nil {
+				return err
+			}
+			index = noIndex - 1
+			bp.Operations = append(bp.Operations, rp)
+		}
+
 		if tokens[index] == Delete {
 			if index == len(tokens)-1 {
 				return newMethodSyntaxError(method.Name, "Delete should be followed by One or Many")
@@ -190,7 +215,7 @@ func getNextOperationIndex(tokens []string, startIndex int, hasCollection bool)
 	count := 0
 	for i := startIndex; i < len(tokens); i++ {
 		if tokens[i] == Insert || tokens[i] == Find || tokens[i] == Update || tokens[i] == Delete ||
-			tokens[i] == Count || tokens[i] == Transaction || tokens[i] == Bulk || tokens[i] == collection {
+			tokens[i] == Replace || tokens[i] == Count || tokens[i] == Transaction || tokens[i] == Bulk || tokens[i] == collection {
 			if !hasCollection && count == 0 {
 				noIndex = i
 				break
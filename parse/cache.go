@@ -0,0 +1,71 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import "github.com/hertz-contrib/thrift-gen-mongo/extract"
+
+// resolveCacheIDs records the id params of the methods of the cached repository, the methods which existed
+// before the update are included since the cached repository is generated with all the methods.
+func resolveCacheIDs(st *extract.IdlExtractStruct, ifo *InterfaceOperation) error {
+	if len(st.CacheOptions) == 0 {
+		return nil
+	}
+
+	operations := append(ifo.PreOperations[:len(ifo.PreOperations):len(ifo.PreOperations)], ifo.Operations...)
+
+	idNames := map[string]struct{}{"_id": {}}
+	if st.IDField != nil {
+		idNames[st.IDField.Tag.Get("bson")] = struct{}{}
+	}
+	for _, operation := range operations {
+		var query *Query
+		var method *extract.InterfaceMethod
+		switch operation := operation.(type) {
+		case *FindParse:
+			query, method = operation.Query, operation.BelongedToMethod
+		case *UpdateParse:
+			query, method = operation.Query, operation.BelongedToMethod
+		case *DeleteParse:
+			query, method = operation.Query, operation.BelongedToMethod
+		default:
+			continue
+		}
+		method.CacheIDParamName = idParamName(query.ConnectionOpTree, idNames)
+	}
+	return nil
+}
+
+// idParamName returns the param compared with the id if the query selects one document by the id,
+// the conditions joined by Or may select other documents.
+func idParamName(node *ConnectionOpTree, idNames map[string]struct{}) string {
+	if node == nil {
+		return ""
+	}
+	if node.LeftChildren == nil {
+		if _, ok := idNames[node.MongoFieldName]; ok && node.Name == string(Equal) && len(node.ParamNames) == 1 {
+			return node.ParamNames[0]
+		}
+		return ""
+	}
+	if node.Name != string(And) {
+		return ""
+	}
+	if name := idParamName(node.LeftChildren, idNames); name != "" {
+		return name
+	}
+	return idParamName(node.RightChildren, idNames)
+}
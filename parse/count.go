@@ -31,8 +31,20 @@ type CountParse struct {
 
 	// BelongedToMethod defines the method to which Count belongs
 	BelongedToMethod *extract.InterfaceMethod
+
+	// Estimated is true when the EstimatedCount form counts all documents with the collection metadata
+	Estimated bool
+
+	SkipParamName    string
+	LimitParamName   string
+	MaxTimeParamName string
 }
 
+const (
+	estimated = "Estimated"
+	maxTime   = "MaxTime"
+)
+
 func newCountParse() *CountParse {
 	return &CountParse{Query: newQuery()}
 }
@@ -57,6 +69,17 @@ func (cp *CountParse) parseCount(tokens []string, method *extract.InterfaceMetho
 	if err := cp.parseQuery(tokens, method, curParamIndex); err != nil {
 		return err
 	}
+	if cp.Estimated {
+		if err := cp.checkEstimated(method); err != nil {
+			return err
+		}
+		// the documents of the tenant are counted by the filter unless the resolver routes them
+		// to the collection of the tenant
+		cp.Query.scopeTenant(method, "")
+	} else {
+		cp.Query.excludeSoftDeleted(method.BelongedToStruct, false)
+		cp.Query.scopeTenant(method, "")
+	}
 
 	if *curParamIndex < len(method.Params) {
 		return newMethodSyntaxError(method.Name, fmt.Sprintf("too many method parameters written, "+
@@ -100,8 +123,65 @@ func (cp *CountParse) parseQuery(tokens []string, method *extract.InterfaceMetho
 	if err != nil {
 		return newMethodSyntaxError(method.Name, err.Error())
 	}
+	if err = cp.parseCountOptions(tokens[:fqIndex], method, curParamIndex); err != nil {
+		return err
+	}
 	if err = cp.Query.parseQuery(tokens[fqIndex:], method, curParamIndex); err != nil {
 		return err
 	}
 	return nil
 }
+
+// parseCountOptions parses the Skip, Limit and MaxTime tokens before By or All,
+// the params are taken in the order of the tokens.
+func (cp *CountParse) parseCountOptions(tokens []string, method *extract.InterfaceMethod, curParamIndex *int) error {
+	for index := 0; index < len(tokens); index++ {
+		var paramName *string
+		token, paramType := tokens[index], "int64"
+		switch {
+		case token == skip:
+			paramName = &cp.SkipParamName
+		case token == limit:
+			paramName = &cp.LimitParamName
+		case index+1 < len(tokens) && token+tokens[index+1] == maxTime:
+			paramName, paramType = &cp.MaxTimeParamName, "time.Duration"
+			token = maxTime
+			index++
+		default:
+			return newMethodSyntaxError(method.Name, fmt.Sprintf("unknown token %s before By or All, "+
+				"Count only supports Skip, Limit, MaxTime", token))
+		}
+
+		if *paramName != "" {
+			return newMethodSyntaxError(method.Name, token+" can only be used once")
+		}
+		if *curParamIndex >= len(method.Params) {
+			return newMethodSyntaxError(method.Name, "insufficient number of input parameters")
+		}
+		if method.Params[*curParamIndex].Type.RealName() != paramType {
+			return newMethodSyntaxError(method.Name, fmt.Sprintf("%s requires passing in a value of type %s",
+				token, paramType))
+		}
+		*paramName = method.Params[*curParamIndex].Name
+		*curParamIndex += 1
+	}
+	return nil
+}
+
+func (cp *CountParse) checkEstimated(method *extract.InterfaceMethod) error {
+	if cp.Query.QueryMode != All || cp.Query.ConnectionOpTree != nil {
+		return newMethodSyntaxError(method.Name, "EstimatedCount only supports All")
+	}
+	if cp.SkipParamName != "" || cp.LimitParamName != "" {
+		return newMethodSyntaxError(method.Name, "EstimatedCount does not support Skip and Limit")
+	}
+	if method.BelongedToStruct.SoftDeleteField != nil {
+		return newMethodSyntaxError(method.Name, "EstimatedCount can not exclude the soft deleted documents, "+
+			"use Count instead")
+	}
+	if method.BelongedToStruct.TenantField != nil && !method.BelongedToStruct.TenantResolver {
+		return newMethodSyntaxError(method.Name, "EstimatedCount can not be scoped by the tenant without "+
+			"the tenant resolver, use Count instead")
+	}
+	return nil
+}
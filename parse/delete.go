@@ -35,6 +35,9 @@ type DeleteParse struct {
 
 	// BelongedToMethod defines the method to which Delete belongs
 	BelongedToMethod *extract.InterfaceMethod
+
+	// Hard is true when the HardDelete form removes the documents of a struct with soft delete field
+	Hard bool
 }
 
 func newDeleteParse() *DeleteParse {
@@ -64,6 +67,10 @@ func (dp *DeleteParse) parseDelete(tokens []string, method *extract.InterfaceMet
 	if err := dp.parseQuery(tokens, method, curParamIndex); err != nil {
 		return err
 	}
+	if !dp.Hard {
+		dp.Query.excludeSoftDeleted(method.BelongedToStruct, false)
+	}
+	dp.Query.scopeTenant(method, "")
 
 	if !isCalled {
 		if *curParamIndex < len(method.Params) {
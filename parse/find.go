@@ -36,6 +36,9 @@ type FindParse struct {
 	SkipParamName  string
 	LimitParamName string
 
+	// WithDeleted is true when the FindWithDeleted form also returns soft deleted documents
+	WithDeleted bool
+
 	// CtxParamName defines the method's context.Context param name
 	CtxParamName string
 
@@ -86,6 +89,11 @@ func (fp *FindParse) parseFind(tokens []string, method *extract.InterfaceMethod,
 
 	fp.BelongedToMethod = method
 
+	if hasWithDeleted(tokens) {
+		fp.WithDeleted = true
+		tokens = tokens[2:]
+	}
+
 	tokenIndex, err := fp.parseProject(tokens, method.BelongedToStruct)
 	if err != nil {
 		return newMethodSyntaxError(method.Name, err.Error())
@@ -104,6 +112,11 @@ func (fp *FindParse) parseFind(tokens []string, method *extract.InterfaceMethod,
 		return err
 	}
 
+	if !fp.WithDeleted {
+		fp.Query.excludeSoftDeleted(method.BelongedToStruct, false)
+	}
+	fp.Query.scopeTenant(method, "")
+
 	if *curParamIndex < len(method.Params) {
 		return newMethodSyntaxError(method.Name, fmt.Sprintf("too many method parameters written, "+
 			"%v and subsequent parameters are useless", method.Params[*curParamIndex].Name))
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/fatih/camelcase"
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+)
+
+// resolveIndexes resolves the fields referenced by the index annotations of the struct to bson paths
+// the same way as the fields of the methods.
+func resolveIndexes(st *extract.IdlExtractStruct) error {
+	for _, index := range st.Indexes {
+		for _, key := range index.Keys {
+			path, t, err := resolveIndexField(key.Field, extract.IndexAnnotation, st)
+			if err != nil {
+				return err
+			}
+			// the server expires the documents only by the date values of the key
+			if index.TTL != "" && t.RealName() != "time.Time" && t.RealName() != "*time.Time" {
+				return fmt.Errorf("struct %s: annotation %s: the ttl index key %s should be time.Time, "+
+					"its type is %s", st.Name, extract.IndexAnnotation, key.Field, t.RealName())
+			}
+			key.BsonPath = path
+		}
+		for _, condition := range index.Partial {
+			path, t, err := resolveIndexField(condition.Field, extract.IndexAnnotation, st)
+			if err != nil {
+				return err
+			}
+			condition.BsonPath = path
+			if condition.Operator == "Exists" {
+				continue
+			}
+			if condition.Value, err = indexLiteral(condition.RawValue, t); err != nil {
+				return fmt.Errorf("struct %s: annotation %s: field %s: %s",
+					st.Name, extract.IndexAnnotation, condition.Field, err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func resolveIndexField(field, annotation string, st *extract.IdlExtractStruct) (string, code.Type, error) {
+	curIndex := new(int)
+	names, types, err := getFieldNameType(camelcase.Split(field), st, curIndex, true)
+	if err != nil || len(names) != 1 {
+		return "", nil, fmt.Errorf("struct %s: annotation %s: field %s not found", st.Name, annotation, field)
+	}
+	return names[0], types[0], nil
+}
+
+func indexLiteral(value string, t code.Type) (string, error) {
+	switch t.RealName() {
+	case "string":
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		return strconv.Quote(value), nil
+	case "bool":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%s is not a bool", value)
+		}
+		return strconv.FormatBool(b), nil
+	case "int8", "int16", "int32", "int64":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "", fmt.Errorf("%s is not an integer", value)
+		}
+		return value, nil
+	case "float64":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "", fmt.Errorf("%s is not a number", value)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("the partial condition of %s is not supported", t.RealName())
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/cloudwego/thriftgo/plugin"
+	"github.com/hertz-contrib/thrift-gen-mongo/args"
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+)
+
+func TestResolveTTLIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		fieldType code.Type
+		err       string
+	}{
+		{name: "date", fieldType: code.SelectorExprType{X: "time", Sel: "Time"}},
+		{name: "optional date", fieldType: code.StarExprType{RealType: code.SelectorExprType{X: "time", Sel: "Time"}}},
+		{name: "milliseconds", fieldType: code.IdentType("int64"), err: "should be time.Time, its type is int64"},
+		{name: "string", fieldType: code.IdentType("string"), err: "should be time.Time, its type is string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ast, err := parser.ParseString("video.thrift", videoIDL+`mongo.index = "CreatedAt; ttl=3600"`+"\n"+
+				`mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"`+"\n)\n")
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta := &extract.ThriftMeta{
+				Req:         &plugin.Request{AST: ast},
+				Args:        &args.Arguments{PackagePrefix: "test/biz/model", ModelDir: "biz/model", DaoDir: "biz/dao"},
+				ImportPaths: make([]string, 0, 10),
+			}
+			structs, err := meta.ParseThriftIdl()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// thriftgo does not generate time.Time, the type of the field is set as the go.tag would
+			for _, field := range structs[0].StructFields {
+				if field.Name == "CreatedAt" {
+					field.Type = tt.fieldType
+				}
+			}
+
+			err = resolveIndexes(structs[0])
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if path := structs[0].Indexes[0].Keys[0].BsonPath; path != "created_at" {
+					t.Fatalf("got key %s", path)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -30,8 +30,22 @@ type InsertParse struct {
 
 	// BelongedToMethod defines the method to which Insert belongs
 	BelongedToMethod *extract.InterfaceMethod
+
+	// Unordered is true when the Insert Many continues writing after a document failed
+	Unordered bool
+
+	// TypedID is true when the Insert returns the id field of the struct instead of the inserted _id
+	TypedID bool
+
+	// ProgressParamName defines the method's func(done, total int) param name which is called after
+	// every chunk of the Insert Many is written, it is empty if the method has no progress param
+	ProgressParamName string
 }
 
+const progressType = "func(int, int)"
+
+const unordered = "Unordered"
+
 func newInsertParse() *InsertParse {
 	return &InsertParse{MethodParamNames: [2]string{}}
 }
@@ -58,6 +72,9 @@ func (ip *InsertParse) parseInsert(method *extract.InterfaceMethod, curParamInde
 			method.Params[*curParamIndex].Name,
 			method.Params[*curParamIndex+1].Name,
 		}
+		if len(method.Params) == 3 {
+			ip.ProgressParamName = method.Params[2].Name
+		}
 	} else {
 		ip.MethodParamNames = [2]string{
 			method.Params[*curParamIndex].Name,
@@ -70,9 +87,27 @@ func (ip *InsertParse) parseInsert(method *extract.InterfaceMethod, curParamInde
 	return nil
 }
 
+// parseUnordered is used to parse the Unordered token of Insert Many.
+//
+//	input params description:
+//	tokens: it contains all tokens belonging to Insert except for Insert token
+//	method: the method to which Insert belongs
+func (ip *InsertParse) parseUnordered(tokens []string, method *extract.InterfaceMethod) error {
+	for _, token := range tokens {
+		if token != unordered {
+			continue
+		}
+		if ip.OperateMode != OperateMany {
+			return newMethodSyntaxError(method.Name, "Unordered is only supported in Insert Many mode")
+		}
+		ip.Unordered = true
+	}
+	return nil
+}
+
 func (ip *InsertParse) check(method *extract.InterfaceMethod) error {
-	if len(method.Params) != 2 {
-		return newMethodSyntaxError(method.Name, "input parameter not equal to 2")
+	if len(method.Params) != 2 && len(method.Params) != 3 {
+		return newMethodSyntaxError(method.Name, "input parameter not equal to 2 or 3")
 	}
 
 	if len(method.Returns) != 2 {
@@ -89,16 +124,27 @@ func (ip *InsertParse) check(method *extract.InterfaceMethod) error {
 			"should be error")
 	}
 
+	idType := ""
+	if idField := method.BelongedToStruct.IDField; idField != nil {
+		idType = idField.Type.RealName()
+	}
+
 	if _, ok := method.Params[1].Type.(code.StarExprType); ok {
-		if method.Returns[0].RealName() != "interface{}" {
-			return newMethodSyntaxError(method.Name, "inconsistent types, the first parameter in the "+
-				"return parameters should be interface{}")
+		if t := method.Returns[0].RealName(); t != "interface{}" {
+			if t != idType {
+				return newMethodSyntaxError(method.Name, "inconsistent types, the first parameter in the "+
+					"return parameters should be interface{} or the type of the id field")
+			}
+			ip.TypedID = true
 		}
 		ip.OperateMode = OperateOne
 	} else if _, ok = method.Params[1].Type.(code.SliceType); ok {
-		if method.Returns[0].RealName() != "[]interface{}" {
-			return newMethodSyntaxError(method.Name, "inconsistent types, the first parameter in the "+
-				"return parameters should be []interface{}")
+		if t := method.Returns[0].RealName(); t != "[]interface{}" {
+			if idType == "" || t != "[]"+idType {
+				return newMethodSyntaxError(method.Name, "inconsistent types, the first parameter in the "+
+					"return parameters should be []interface{} or the slice of the id field type")
+			}
+			ip.TypedID = true
 		}
 		ip.OperateMode = OperateMany
 	} else {
@@ -106,5 +152,16 @@ func (ip *InsertParse) check(method *extract.InterfaceMethod) error {
 			"should be interface{} or []interface{}")
 	}
 
+	if len(method.Params) == 3 {
+		if ip.OperateMode != OperateMany || method.Params[2].Type.RealName() != progressType {
+			return newMethodSyntaxError(method.Name, "the third parameter in the input parameters "+
+				"should be the progress "+progressType+" of Insert Many")
+		}
+		if method.BelongedToStruct.ChunkSize == 0 {
+			return newMethodSyntaxError(method.Name, "the progress parameter requires the "+
+				extract.ChunkSizeAnnotation+" annotation")
+		}
+	}
+
 	return nil
 }
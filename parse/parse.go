@@ -31,6 +31,13 @@ import (
 type InterfaceOperation struct {
 	BelongedToStruct *extract.IdlExtractStruct
 	Operations       []Operation
+
+	// PreOperations are the operations of the methods which existed before the update, they are parsed
+	// again for the files which are generated with all the methods
+	PreOperations []Operation
+
+	// Warnings are the problems of the operations which do not fail the generation
+	Warnings []string
 }
 
 const (
@@ -41,6 +48,8 @@ const (
 	Count       = "Count"
 	Transaction = "Transaction"
 	Bulk        = "Bulk"
+	Replace     = "Replace"
+	Watch       = "Watch"
 )
 
 type OperateMode int
@@ -57,10 +66,29 @@ const (
 
 func HandleOperations(structs []*extract.IdlExtractStruct) (result []*InterfaceOperation, err error) {
 	for _, st := range structs {
+		if err = resolveIndexes(st); err != nil {
+			return nil, err
+		}
+		if err = resolveShardKey(st); err != nil {
+			return nil, err
+		}
 		ifo := newInterfaceOperation()
 		if err = ifo.parseInterfaceMethod(st); err != nil {
 			return nil, err
 		}
+		if err = ifo.checkShardKey(); err != nil {
+			return nil, err
+		}
+		if st.Update {
+			pre := newInterfaceOperation()
+			if err = pre.parseMethods(st, st.PreIfMethods); err != nil {
+				return nil, err
+			}
+			ifo.PreOperations = pre.Operations
+		}
+		if err = resolveCacheIDs(st, ifo); err != nil {
+			return nil, err
+		}
 		result = append(result, ifo)
 	}
 	return
@@ -71,7 +99,12 @@ func newInterfaceOperation() *InterfaceOperation {
 }
 
 func (ifo *InterfaceOperation) parseInterfaceMethod(extractStruct *extract.IdlExtractStruct) error {
-	for _, method := range extractStruct.InterfaceInfo.Methods {
+	return ifo.parseMethods(extractStruct, extractStruct.InterfaceInfo.Methods)
+}
+
+func (ifo *InterfaceOperation) parseMethods(extractStruct *extract.IdlExtractStruct, methods []*extract.InterfaceMethod) error {
+	ifo.BelongedToStruct = extractStruct
+	for _, method := range methods {
 		tokens := camelcase.Split(method.ParsedTokens)
 		switch tokens[0] {
 		case Insert:
@@ -81,6 +114,9 @@ func (ifo *InterfaceOperation) parseInterfaceMethod(extractStruct *extract.IdlEx
 			if err := ip.parseInsert(method, curParamIndex, false); err != nil {
 				return err
 			}
+			if err := ip.parseUnordered(tokens[1:], method); err != nil {
+				return err
+			}
 			ifo.BelongedToStruct = extractStruct
 			ifo.Operations = append(ifo.Operations, ip)
 
@@ -114,6 +150,20 @@ func (ifo *InterfaceOperation) parseInterfaceMethod(extractStruct *extract.IdlEx
 			ifo.BelongedToStruct = extractStruct
 			ifo.Operations = append(ifo.Operations, dp)
 
+		case hard:
+			if len(tokens) == 1 || tokens[1] != Delete {
+				return newMethodSyntaxError(method.Name, "Hard should be followed by Delete")
+			}
+			curParamIndex := new(int)
+			*curParamIndex = 1
+			dp := newDeleteParse()
+			dp.Hard = true
+			if err := dp.parseDelete(tokens[2:], method, curParamIndex, false); err != nil {
+				return err
+			}
+			ifo.BelongedToStruct = extractStruct
+			ifo.Operations = append(ifo.Operations, dp)
+
 		case Count:
 			curParamIndex := new(int)
 			*curParamIndex = 1
@@ -124,6 +174,20 @@ func (ifo *InterfaceOperation) parseInterfaceMethod(extractStruct *extract.IdlEx
 			ifo.BelongedToStruct = extractStruct
 			ifo.Operations = append(ifo.Operations, cp)
 
+		case estimated:
+			if len(tokens) == 1 || tokens[1] != Count {
+				return newMethodSyntaxError(method.Name, "Estimated should be followed by Count")
+			}
+			curParamIndex := new(int)
+			*curParamIndex = 1
+			cp := newCountParse()
+			cp.Estimated = true
+			if err := cp.parseCount(tokens[2:], method, curParamIndex); err != nil {
+				return err
+			}
+			ifo.BelongedToStruct = extractStruct
+			ifo.Operations = append(ifo.Operations, cp)
+
 		case Transaction:
 			curParamIndex := new(int)
 			*curParamIndex = 2
@@ -144,9 +208,19 @@ func (ifo *InterfaceOperation) parseInterfaceMethod(extractStruct *extract.IdlEx
 			ifo.BelongedToStruct = extractStruct
 			ifo.Operations = append(ifo.Operations, bp)
 
+		case Watch:
+			curParamIndex := new(int)
+			*curParamIndex = 1
+			wp := newWatchParse()
+			if err := wp.parseWatch(tokens[1:], method, curParamIndex); err != nil {
+				return err
+			}
+			ifo.BelongedToStruct = extractStruct
+			ifo.Operations = append(ifo.Operations, wp)
+
 		default:
 			return newMethodSyntaxError(method.Name, "wrong operation name, should be Insert, Find, "+
-				"Update, Delete, Count, Transaction, Bulk")
+				"Update, Delete, HardDelete, Count, EstimatedCount, Transaction, Bulk, Watch")
 		}
 	}
 
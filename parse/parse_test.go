@@ -0,0 +1,116 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/cloudwego/thriftgo/plugin"
+	"github.com/hertz-contrib/thrift-gen-mongo/args"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+)
+
+// videoIDL is the IDL of the Video struct, the annotations are appended to it.
+const videoIDL = `namespace go video
+
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id,omitempty\"")
+    2: string Title (go.tag="bson:\"title,omitempty\"")
+    3: i32 Status (go.tag="bson:\"status\"")
+    4: i64 DeletedAt (go.tag="bson:\"deleted_at,omitempty\"")
+    5: bool Removed (go.tag="bson:\"removed\"")
+    6: string TenantId (go.tag="bson:\"tenant_id\"")
+    7: i64 CreatedAt (go.tag="bson:\"created_at,omitempty\"")
+}
+(
+`
+
+// parseVideo parses the Video struct declared with the annotations.
+func parseVideo(annotations ...string) (*InterfaceOperation, error) {
+	ast, err := parser.ParseString("video.thrift", videoIDL+strings.Join(annotations, "\n")+"\n)\n")
+	if err != nil {
+		return nil, err
+	}
+	meta := &extract.ThriftMeta{
+		Req: &plugin.Request{AST: ast},
+		Args: &args.Arguments{
+			PackagePrefix: "test/biz/model",
+			ModelDir:      "biz/model",
+			DaoDir:        "biz/dao",
+		},
+		ImportPaths: make([]string, 0, 10),
+	}
+	structs, err := meta.ParseThriftIdl()
+	if err != nil {
+		return nil, err
+	}
+	operations, err := HandleOperations(structs)
+	if err != nil {
+		return nil, err
+	}
+	return operations[0], nil
+}
+
+// operationQuery returns the query of the operation, it is nil if the operation has no query.
+func operationQuery(operation Operation) *Query {
+	switch op := operation.(type) {
+	case *FindParse:
+		return op.Query
+	case *UpdateParse:
+		return op.Query
+	case *DeleteParse:
+		return op.Query
+	case *CountParse:
+		return op.Query
+	case *ReplaceParse:
+		return op.Query
+	case *WatchParse:
+		return op.Query
+	}
+	return nil
+}
+
+// treeString formats the query tree as Name(field, params...) for the leaves and Name(left, right) for the others.
+func treeString(node *ConnectionOpTree) string {
+	if node == nil {
+		return ""
+	}
+	if node.Name == string(And) || node.Name == string(Or) {
+		return node.Name + "(" + treeString(node.LeftChildren) + ", " + treeString(node.RightChildren) + ")"
+	}
+	return node.Name + "(" + strings.Join(append([]string{node.MongoFieldName}, node.ParamNames...), ", ") + ")"
+}
+
+// checkTrees checks the query trees of the operations of the methods, every want is the tree of the method
+// of the same index.
+func checkTrees(t *testing.T, ifo *InterfaceOperation, want []string) {
+	t.Helper()
+	if len(ifo.Operations) != len(want) {
+		t.Fatalf("got %d operations, want %d", len(ifo.Operations), len(want))
+	}
+	for i, operation := range ifo.Operations {
+		q := operationQuery(operation)
+		if q == nil {
+			t.Fatalf("operation %d has no query", i)
+		}
+		if got := treeString(q.ConnectionOpTree); got != want[i] {
+			t.Errorf("operation %d: got %s, want %s", i, got, want[i])
+		}
+	}
+}
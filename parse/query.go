@@ -62,6 +62,11 @@ type Query struct {
 
 	// ConnectionOpTree stores query information
 	ConnectionOpTree *ConnectionOpTree
+
+	// foundName is the document found by the previous step of the Transaction,
+	// the conditions ending with Found take the values of the fields of it instead of the params
+	foundName string
+	fromFound bool
 }
 
 type ConnectionOpTree struct {
@@ -75,6 +80,7 @@ type ConnectionOpTree struct {
 const (
 	leftBracket  = "Lb"
 	rightBracket = "Rb"
+	found        = "Found"
 )
 
 func newQuery() *Query {
@@ -173,6 +179,15 @@ func (q *Query) createTree(tokens []string, method *extract.InterfaceMethod, cur
 		}
 	}
 
+	if len(tokens) > 2 && tokens[len(tokens)-1] == found {
+		if q.foundName == "" {
+			return nil, newMethodSyntaxError(method.Name, "Found should follow a Find One step of the Transaction")
+		}
+		q.fromFound = true
+		defer func() { q.fromFound = false }()
+		tokens = tokens[:len(tokens)-1]
+	}
+
 	cpName, fieldName, paramNames, err := q.splitConditionPairs(tokens, method, curParamIndex)
 	if err != nil {
 		return nil, err
@@ -199,7 +214,6 @@ func (q *Query) splitConditionPairs(methodTokens []string, method *extract.Inter
 		}
 
 		if i-1 >= 0 && methodTokens[i] == "Equal" && methodTokens[i-1] == "Not" {
-			fmt.Printf("%v\n", methodTokens[:i-1])
 			return q.parseQueryConditionPair(methodTokens[:i-1], method, curParamIndex, NotEqual, 1)
 		}
 
@@ -282,6 +296,10 @@ func (q *Query) parseQueryConditionPair(methodTokens []string, method *extract.I
 		return "", "", nil, newMethodSyntaxError(method.Name, "only one field name can be included between And or Or")
 	}
 
+	if q.fromFound {
+		return q.parseFoundConditionPair(method, result[0], queryComparator, paramCount)
+	}
+
 	var values []string
 	if paramCount > 0 {
 		if *curParamIndex+paramCount > len(method.Params) {
@@ -322,3 +340,19 @@ func getFirstQueryIndex(tokens []string) (int, error) {
 	}
 	return firstIndex, nil
 }
+
+// parseFoundConditionPair takes the value of the condition from the top level field of the found document.
+func (q *Query) parseFoundConditionPair(method *extract.InterfaceMethod, mongoFieldName string,
+	queryComparator QueryComparator, paramCount int,
+) (string, string, []string, error) {
+	if paramCount != 1 || queryComparator == In || queryComparator == NotIn {
+		return "", "", nil, newMethodSyntaxError(method.Name, fmt.Sprintf("Found does not support %s", queryComparator))
+	}
+	for _, field := range method.BelongedToStruct.StructFields {
+		if field.Tag.Get("bson") == mongoFieldName {
+			return string(queryComparator), mongoFieldName, []string{q.foundName + "." + field.Name}, nil
+		}
+	}
+	return "", "", nil, newMethodSyntaxError(method.Name, fmt.Sprintf("Found only supports the top level fields, "+
+		"%s is not", mongoFieldName))
+}
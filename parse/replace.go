@@ -0,0 +1,92 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+)
+
+const upsert = "Upsert"
+
+// ReplaceParse replaces one document with the whole structure, it is only supported by Bulk.
+type ReplaceParse struct {
+	// ReplaceStructObjName defines the method's structure point param name of the replacement
+	ReplaceStructObjName string
+
+	// Query defines the Query information contained in the Replace operation
+	Query *Query
+
+	// BelongedToMethod defines the method to which Replace belongs
+	BelongedToMethod *extract.InterfaceMethod
+
+	// Upsert is true when the replacement is inserted if no document matches the query
+	Upsert bool
+}
+
+func newReplaceParse() *ReplaceParse {
+	return &ReplaceParse{Query: newQuery()}
+}
+
+func (rp *ReplaceParse) GetOperationName() string {
+	return Replace
+}
+
+// parseReplace is called by Bulk
+//
+//	input params description:
+//	tokens: it contains all tokens belonging to Replace except for Replace One tokens
+//	method: the method to which Replace belongs
+//	curParamIndex: current method's param index
+func (rp *ReplaceParse) parseReplace(tokens []string, method *extract.InterfaceMethod, curParamIndex *int) error {
+	rp.BelongedToMethod = method
+
+	if len(tokens) > 0 && tokens[0] == upsert {
+		rp.Upsert = true
+		tokens = tokens[1:]
+	}
+
+	fqIndex, err := getFirstQueryIndex(tokens)
+	if err != nil {
+		return newMethodSyntaxError(method.Name, err.Error())
+	}
+	if fqIndex != 0 {
+		return newMethodSyntaxError(method.Name, "Replace One replaces the whole structure, "+
+			"it should be followed by Upsert, By or All")
+	}
+
+	if *curParamIndex >= len(method.Params) {
+		return newMethodSyntaxError(method.Name, "insufficient number of input parameters")
+	}
+	t, ok := method.Params[*curParamIndex].Type.(code.StarExprType)
+	if !ok {
+		return newMethodSyntaxError(method.Name, "the input when replacing the whole structure is not a structure pointer")
+	}
+	if _, ok = t.RealType.(code.SelectorExprType); !ok {
+		return newMethodSyntaxError(method.Name, "the input when replacing the whole structure is not in the form of *Package.StructName")
+	}
+	rp.ReplaceStructObjName = method.Params[*curParamIndex].Name
+	*curParamIndex += 1
+
+	if err = rp.Query.parseQuery(tokens, method, curParamIndex); err != nil {
+		return err
+	}
+	rp.Query.excludeSoftDeleted(method.BelongedToStruct, rp.Upsert)
+	rp.Query.scopeTenant(method, "")
+
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+)
+
+// resolveShardKey resolves the fields of the shard key annotation of the struct to bson paths.
+func resolveShardKey(st *extract.IdlExtractStruct) error {
+	if st.ShardKey == nil {
+		return nil
+	}
+	for _, key := range st.ShardKey.Keys {
+		path, _, err := resolveIndexField(key.Field, extract.ShardKeyAnnotation, st)
+		if err != nil {
+			return err
+		}
+		key.BsonPath = path
+	}
+	return nil
+}
+
+// checkShardKey reports the One operations whose queries have no equality on every field of the shard key,
+// they are sent to all the shards. The reports are warnings unless the shard key is strict,
+// the upserts must always have the equality since the server rejects them. The fields matched by the tenant
+// of the context only are always warnings.
+func (ifo *InterfaceOperation) checkShardKey() error {
+	st := ifo.BelongedToStruct
+	if st == nil || st.ShardKey == nil {
+		return nil
+	}
+	for _, operation := range ifo.Operations {
+		if err := ifo.checkOperationShardKey(operation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ifo *InterfaceOperation) checkOperationShardKey(operation Operation) error {
+	switch op := operation.(type) {
+	case *FindParse:
+		if op.OperateMode == OperateOne {
+			return ifo.checkQueryShardKey(op.BelongedToMethod, op.Query, false)
+		}
+	case *UpdateParse:
+		if op.Upsert || op.OperateMode == OperateOne {
+			return ifo.checkQueryShardKey(op.BelongedToMethod, op.Query, op.Upsert)
+		}
+	case *DeleteParse:
+		if op.OperateMode == OperateOne {
+			return ifo.checkQueryShardKey(op.BelongedToMethod, op.Query, false)
+		}
+	case *ReplaceParse:
+		return ifo.checkQueryShardKey(op.BelongedToMethod, op.Query, op.Upsert)
+	case *BulkParse:
+		for _, bulkOperation := range op.Operations {
+			if err := ifo.checkOperationShardKey(bulkOperation); err != nil {
+				return err
+			}
+		}
+	case *TransactionParse:
+		for _, tsOperation := range op.TransactionOperations {
+			// the operations of the other collections are not sharded by the shard key of the struct
+			if tsOperation.CollectionParamName != defaultCollection {
+				continue
+			}
+			if err := ifo.checkOperationShardKey(tsOperation.Operation); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ifo *InterfaceOperation) checkQueryShardKey(method *extract.InterfaceMethod, q *Query, upsert bool) error {
+	equalities, tenants := map[string]bool{}, map[string]bool{}
+	shardKeyEqualities(q.ConnectionOpTree, equalities, tenants)
+
+	missing := make([]string, 0, len(ifo.BelongedToStruct.ShardKey.Keys))
+	tenantOnly := make([]string, 0, 1)
+	for _, key := range ifo.BelongedToStruct.ShardKey.Keys {
+		if equalities[key.BsonPath] {
+			continue
+		}
+		if tenants[key.BsonPath] {
+			tenantOnly = append(tenantOnly, key.Field)
+			continue
+		}
+		missing = append(missing, key.Field)
+	}
+	if len(tenantOnly) != 0 {
+		ifo.Warnings = append(ifo.Warnings, fmt.Sprintf("struct %s: method %s: the shard key field %s is matched "+
+			"by the tenant of the context only, the query is sent to all the shards if the context bypasses the tenant scope",
+			ifo.BelongedToStruct.Name, method.Name, strings.Join(tenantOnly, ", ")))
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if upsert {
+		return newMethodSyntaxError(method.Name, fmt.Sprintf("the upsert should have equality on the shard key "+
+			"field %s", strings.Join(missing, ", ")))
+	}
+	reason := fmt.Sprintf("the query has no equality on the shard key field %s, it is sent to all the shards",
+		strings.Join(missing, ", "))
+	if ifo.BelongedToStruct.ShardKey.Strict {
+		return newMethodSyntaxError(method.Name, reason)
+	}
+	ifo.Warnings = append(ifo.Warnings, fmt.Sprintf("struct %s: method %s: %s",
+		ifo.BelongedToStruct.Name, method.Name, reason))
+	return nil
+}
+
+// shardKeyEqualities collects the fields which are matched by equality in the query tree, and the fields which
+// are matched by the tenant of the context into tenants since the condition is dropped if the context bypasses
+// the tenant scope. Only the conditions ANDed with the whole query are collected.
+func shardKeyEqualities(node *ConnectionOpTree, equalities, tenants map[string]bool) {
+	if node == nil {
+		return
+	}
+	switch node.Name {
+	case string(And):
+		shardKeyEqualities(node.LeftChildren, equalities, tenants)
+		shardKeyEqualities(node.RightChildren, equalities, tenants)
+	case string(Equal), string(True), string(False):
+		equalities[node.MongoFieldName] = true
+	case string(Tenant):
+		tenants[node.MongoFieldName] = true
+	}
+}
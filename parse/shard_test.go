@@ -0,0 +1,106 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckShardKey(t *testing.T) {
+	tenant := "matched by the tenant of the context only"
+	tests := []struct {
+		name        string
+		annotations []string
+		warning     string
+		err         string
+	}{
+		{
+			name: "equality",
+			annotations: []string{
+				`mongo.shard_key = "Status"`,
+				`mongo.FindByStatusEqual = "FindByStatus(ctx context.Context, status int32) (*video.Video, error)"`,
+			},
+		},
+		{
+			name: "missing",
+			annotations: []string{
+				`mongo.shard_key = "Status"`,
+				`mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"`,
+			},
+			warning: "the query has no equality on the shard key field Status",
+		},
+		{
+			name: "missing upsert",
+			annotations: []string{
+				`mongo.shard_key = "Status"`,
+				`mongo.UpdateUpsertTitleByIdEqual = "UpsertTitle(ctx context.Context, title string, id int64) (bool, error)"`,
+			},
+			err: "the upsert should have equality on the shard key field Status",
+		},
+		{
+			name: "tenant",
+			annotations: []string{
+				`mongo.tenant = "tenant_id"`,
+				`mongo.shard_key = "TenantId"`,
+				`mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"`,
+			},
+			warning: tenant,
+		},
+		{
+			name: "tenant strict upsert",
+			annotations: []string{
+				`mongo.tenant = "tenant_id"`,
+				`mongo.shard_key = "TenantId; strict"`,
+				`mongo.UpdateUpsertTitleByIdEqual = "UpsertTitle(ctx context.Context, title string, id int64) (bool, error)"`,
+			},
+			warning: tenant,
+		},
+		{
+			name: "tenant and missing",
+			annotations: []string{
+				`mongo.tenant = "tenant_id"`,
+				`mongo.shard_key = "TenantId, Status; strict"`,
+				`mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"`,
+			},
+			err: "the query has no equality on the shard key field Status,",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ifo, err := parseVideo(tt.annotations...)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.warning == "" {
+				if len(ifo.Warnings) != 0 {
+					t.Fatalf("got warnings %q", ifo.Warnings)
+				}
+				return
+			}
+			if len(ifo.Warnings) != 1 || !strings.Contains(ifo.Warnings[0], tt.warning) {
+				t.Fatalf("got warnings %q, want %q", ifo.Warnings, tt.warning)
+			}
+		})
+	}
+}
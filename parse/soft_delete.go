@@ -0,0 +1,77 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+)
+
+const (
+	hard    = "Hard"
+	with    = "With"
+	deleted = "Deleted"
+)
+
+// excludeSoftDeleted ANDs the not deleted condition of the soft delete field into the query tree,
+// it does nothing if the struct has no soft delete field or the query is an upsert filter.
+// The upserts match the soft deleted documents too, otherwise an upsert of a soft deleted document
+// would insert another document with its _id.
+func (q *Query) excludeSoftDeleted(extractStruct *extract.IdlExtractStruct, upsert bool) {
+	if extractStruct == nil || extractStruct.SoftDeleteField == nil || upsert {
+		return
+	}
+
+	q.andNode(notDeletedNode(extractStruct.SoftDeleteField))
+}
+
+// andNode ANDs the node into the query tree, the query mode All becomes the node itself.
+func (q *Query) andNode(node *ConnectionOpTree) {
+	if q.ConnectionOpTree == nil {
+		q.ConnectionOpTree = node
+		return
+	}
+
+	q.ConnectionOpTree = &ConnectionOpTree{
+		Name:          string(And),
+		LeftChildren:  q.ConnectionOpTree,
+		RightChildren: node,
+	}
+}
+
+// notDeletedNode returns the leaf node matching the documents which are not soft deleted.
+// The bool flag must not be true, the deleted-at timestamp must be missing, null or zero.
+func notDeletedNode(field *extract.StructField) *ConnectionOpTree {
+	mongoFieldName := field.Tag.Get("bson")
+	if field.Type.RealName() == "bool" {
+		return &ConnectionOpTree{
+			Name:           string(NotEqual),
+			MongoFieldName: mongoFieldName,
+			ParamNames:     []string{"true"},
+		}
+	}
+
+	return &ConnectionOpTree{
+		Name:           string(In),
+		MongoFieldName: mongoFieldName,
+		ParamNames:     []string{"bson.A{nil, 0}"},
+	}
+}
+
+// hasWithDeleted reports whether the tokens start with With Deleted.
+func hasWithDeleted(tokens []string) bool {
+	return len(tokens) >= 2 && tokens[0] == with && tokens[1] == deleted
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"testing"
+)
+
+func TestExcludeSoftDeleted(t *testing.T) {
+	const notDeleted = "In(deleted_at, bson.A{nil, 0})"
+	tests := []struct {
+		name       string
+		softDelete string
+		method     string
+		tree       string
+	}{
+		{
+			name:   "no soft delete",
+			method: `mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"`,
+			tree:   "Equal(_id, id)",
+		},
+		{
+			name:       "find",
+			softDelete: "deleted_at",
+			method:     `mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"`,
+			tree:       "And(Equal(_id, id), " + notDeleted + ")",
+		},
+		{
+			name:       "find all",
+			softDelete: "deleted_at",
+			method:     `mongo.FindAll = "FindAll(ctx context.Context) ([]*video.Video, error)"`,
+			tree:       notDeleted,
+		},
+		{
+			name:       "or",
+			softDelete: "deleted_at",
+			method: `mongo.FindByIdEqualOrStatusEqual = ` +
+				`"FindByIdOrStatus(ctx context.Context, id int64, status int32) ([]*video.Video, error)"`,
+			tree: "And(Or(Equal(_id, id), Equal(status, status)), " + notDeleted + ")",
+		},
+		{
+			name:       "with deleted",
+			softDelete: "deleted_at",
+			method: `mongo.FindWithDeletedByIdEqual = ` +
+				`"FindWithDeletedById(ctx context.Context, id int64) (*video.Video, error)"`,
+			tree: "Equal(_id, id)",
+		},
+		{
+			name:       "flag",
+			softDelete: "Removed",
+			method:     `mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"`,
+			tree:       "And(Equal(_id, id), NotEqual(removed, true))",
+		},
+		{
+			name:       "update",
+			softDelete: "deleted_at",
+			method:     `mongo.UpdateTitleByIdEqual = "UpdateTitle(ctx context.Context, title string, id int64) (bool, error)"`,
+			tree:       "And(Equal(_id, id), " + notDeleted + ")",
+		},
+		{
+			name:       "upsert",
+			softDelete: "deleted_at",
+			method:     `mongo.UpdateUpsertTitleByIdEqual = "UpsertTitle(ctx context.Context, title string, id int64) (bool, error)"`,
+			tree:       "Equal(_id, id)",
+		},
+		{
+			name:       "delete",
+			softDelete: "deleted_at",
+			method:     `mongo.DeleteByStatusEqual = "DeleteByStatus(ctx context.Context, status int32) (int, error)"`,
+			tree:       "And(Equal(status, status), " + notDeleted + ")",
+		},
+		{
+			name:       "hard delete",
+			softDelete: "deleted_at",
+			method:     `mongo.HardDeleteByIdEqual = "HardDeleteById(ctx context.Context, id int64) (bool, error)"`,
+			tree:       "Equal(_id, id)",
+		},
+		{
+			name:       "count",
+			softDelete: "deleted_at",
+			method:     `mongo.CountByStatusEqual = "CountByStatus(ctx context.Context, status int32) (int, error)"`,
+			tree:       "And(Equal(status, status), " + notDeleted + ")",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotations := []string{tt.method}
+			if tt.softDelete != "" {
+				annotations = append(annotations, `mongo.soft_delete = "`+tt.softDelete+`"`)
+			}
+			ifo, err := parseVideo(annotations...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTrees(t, ifo, []string{tt.tree})
+		})
+	}
+}
+
+func TestExcludeSoftDeletedBulk(t *testing.T) {
+	ifo, err := parseVideo(`mongo.soft_delete = "deleted_at"`,
+		`mongo.BulkUpdateOneUpsertTitleByIdEqualReplaceOneUpsertByIdEqualDeleteOneByIdEqual = `+
+			`"Bulk(ctx context.Context, title string, id int64, v *video.Video, id2 int64, id3 int64) (*mongo.BulkWriteResult, error)"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bp := ifo.Operations[0].(*BulkParse)
+	want := []string{"Equal(_id, id)", "Equal(_id, id2)", "And(Equal(_id, id3), In(deleted_at, bson.A{nil, 0}))"}
+	if len(bp.Operations) != len(want) {
+		t.Fatalf("got %d operations, want %d", len(bp.Operations), len(want))
+	}
+	for i, operation := range bp.Operations {
+		if got := treeString(operationQuery(operation).ConnectionOpTree); got != want[i] {
+			t.Errorf("operation %d: got %s, want %s", i, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,40 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+)
+
+// Tenant is the comparator of the leaf which scopes the query to the tenant of the context,
+// it is not a token of the methods, the ParamNames of the leaf is the name of the context.
+const Tenant = QueryComparator("Tenant")
+
+// scopeTenant ANDs the tenant condition of the tenant field into the query tree, the field name is prefixed
+// by prefix, it does nothing if the struct has no tenant field.
+func (q *Query) scopeTenant(method *extract.InterfaceMethod, prefix string) {
+	st := method.BelongedToStruct
+	if st == nil || st.TenantField == nil || len(method.Params) == 0 {
+		return
+	}
+
+	q.andNode(&ConnectionOpTree{
+		Name:           string(Tenant),
+		MongoFieldName: prefix + st.TenantField.Tag.Get("bson"),
+		ParamNames:     []string{method.Params[0].Name},
+	})
+}
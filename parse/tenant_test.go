@@ -0,0 +1,105 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScopeTenant(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations []string
+		tree        string
+		err         string
+	}{
+		{
+			name:        "find",
+			annotations: []string{`mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"`},
+			tree:        "And(Equal(_id, id), Tenant(tenant_id, ctx))",
+		},
+		{
+			name:        "find all",
+			annotations: []string{`mongo.FindAll = "FindAll(ctx context.Context) ([]*video.Video, error)"`},
+			tree:        "Tenant(tenant_id, ctx)",
+		},
+		{
+			name: "soft delete",
+			annotations: []string{
+				`mongo.soft_delete = "deleted_at"`,
+				`mongo.DeleteByIdEqual = "DeleteById(c context.Context, id int64) (bool, error)"`,
+			},
+			tree: "And(And(Equal(_id, id), In(deleted_at, bson.A{nil, 0})), Tenant(tenant_id, c))",
+		},
+		{
+			name:        "hard delete",
+			annotations: []string{`mongo.HardDeleteByIdEqual = "HardDeleteById(ctx context.Context, id int64) (bool, error)"`},
+			tree:        "And(Equal(_id, id), Tenant(tenant_id, ctx))",
+		},
+		{
+			name: "count",
+			annotations: []string{
+				`mongo.CountByStatusEqual = "CountByStatus(ctx context.Context, status int32) (int, error)"`,
+			},
+			tree: "And(Equal(status, status), Tenant(tenant_id, ctx))",
+		},
+		{
+			name:        "estimated count",
+			annotations: []string{`mongo.EstimatedCountAll = "EstimatedCount(ctx context.Context) (int, error)"`},
+			err:         "without the tenant resolver",
+		},
+		{
+			name: "estimated count resolver",
+			annotations: []string{
+				`mongo.tenant_resolver = "true"`,
+				`mongo.EstimatedCountAll = "EstimatedCount(ctx context.Context) (int, error)"`,
+			},
+			tree: "Tenant(tenant_id, ctx)",
+		},
+		{
+			name: "watch",
+			annotations: []string{
+				`mongo.WatchInsertUpdateByStatusEqual = ` +
+					`"WatchStatus(ctx context.Context, status int32) (*VideoChangeStream, error)"`,
+			},
+			tree: "And(Equal(fullDocument.status, status), Tenant(fullDocument.tenant_id, ctx))",
+		},
+		{
+			name: "watch delete",
+			annotations: []string{
+				`mongo.WatchDeleteAll = "WatchDelete(ctx context.Context) (*VideoChangeStream, error)"`,
+			},
+			err: "can not be scoped by the tenant",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ifo, err := parseVideo(append(tt.annotations, `mongo.tenant = "tenant_id"`)...)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTrees(t, ifo, []string{tt.tree})
+		})
+	}
+}
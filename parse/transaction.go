@@ -18,6 +18,7 @@ package parse
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/hertz-contrib/thrift-gen-mongo/extract"
@@ -25,7 +26,8 @@ import (
 
 type TransactionParse struct {
 	// TransactionOperations defines all the operations contained in the Transaction,
-	// supports Insert One Many, Update One Many, Delete One Many, Bulk(Mark boundaries with parentheses).
+	// supports Find One Many, Count, Insert One Many, Update One Many, Delete One Many,
+	// Bulk(Mark boundaries with parentheses).
 	TransactionOperations []TransactionOperation
 
 	// CtxParamName defines the method's context.Context param name
@@ -39,6 +41,9 @@ type TransactionParse struct {
 
 	// BelongedToMethod defines the method to which Transaction belongs
 	BelongedToMethod *extract.InterfaceMethod
+
+	// foundName is the result of the last Find One step, it is used by the Found conditions of the later steps
+	foundName string
 }
 
 type TransactionOperation struct {
@@ -46,6 +51,13 @@ type TransactionOperation struct {
 	// if not specified, default is r.collection
 	CollectionParamName string
 	Operation           Operation
+
+	// ResultName defines the local variable which stores the result of the Find or Count step
+	ResultName string
+
+	// GuardParamName defines the method's param name which is called with the result of the Find or Count step,
+	// the transaction is aborted if it returns an error
+	GuardParamName string
 }
 
 func newTransactionParse() *TransactionParse {
@@ -94,9 +106,18 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 	}
 
 	for index := 0; index < len(tokens); index++ {
-		if tokens[index] == Find || tokens[index] == Count || tokens[index] == Transaction {
-			return newMethodSyntaxError(method.Name, "the Transaction operation does not supports Find, Count, "+
-				"Transaction, only supports Insert, Update, Delete, Bulk")
+		if tokens[index] == Transaction {
+			return newMethodSyntaxError(method.Name, "the Transaction operation does not supports Transaction, "+
+				"only supports Find, Count, Insert, Update, Delete, Bulk")
+		}
+
+		if tokens[index] == Find || tokens[index] == Count {
+			noIndex, err := tp.parseTransactionRead(method, tokens, index, curParamIndex, defaultCollection)
+			if err != nil {
+				return err
+			}
+			index = noIndex - 1
+			continue
 		}
 
 		if tokens[index] == Insert {
@@ -138,7 +159,8 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 			belongedToOpIndex := -1
 			belongedToOpIndexName := ""
 			for i := index + 1; i < len(tokens); i++ {
-				if tokens[i] == Insert || tokens[i] == Update || tokens[i] == Delete || tokens[i] == Bulk {
+				if tokens[i] == Insert || tokens[i] == Update || tokens[i] == Delete || tokens[i] == Bulk ||
+					tokens[i] == Find || tokens[i] == Count {
 					belongedToOpIndex = i
 					belongedToOpIndexName = tokens[i]
 					break
@@ -146,7 +168,7 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 			}
 
 			if belongedToOpIndex == -1 {
-				return newMethodSyntaxError(method.Name, "there is no Insert, Update, Delete, Bulk "+
+				return newMethodSyntaxError(method.Name, "there is no Find, Count, Insert, Update, Delete, Bulk "+
 					"tokens after the Collection")
 			}
 			if belongedToOpIndex == index+1 {
@@ -161,6 +183,13 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 			}
 
 			switch belongedToOpIndexName {
+			case Find, Count:
+				noIndex, err := tp.parseTransactionRead(method, tokens, belongedToOpIndex, curParamIndex, v)
+				if err != nil {
+					return err
+				}
+				index = noIndex - 1
+
 			case Insert:
 				if err := tp.parseTransactionInsert(method, tokens, belongedToOpIndex, curParamIndex, v); err != nil {
 					return err
@@ -168,14 +197,14 @@ func (tp *TransactionParse) parseTransaction(tokens []string, method *extract.In
 				index = belongedToOpIndex + 1
 
 			case Update:
-				noIndex, err := tp.parseTransactionUpdate(method, tokens, belongedToOpIndex, curParamIndex, v, true)
+				noIndex, err := tp.parseTransactionUpdate(method, tokens, belongedToOpIndex, curParamIndex, v, false)
 				if err != nil {
 					return err
 				}
 				index = noIndex - 1
 
 			case Delete:
-				noIndex, err := tp.parseTransactionDelete(method, tokens, belongedToOpIndex, curParamIndex, v, true)
+				noIndex, err := tp.parseTransactionDelete(method, tokens, belongedToOpIndex, curParamIndex, v, false)
 				if err != nil {
 					return err
 				}
@@ -249,6 +278,11 @@ func (tp *TransactionParse) parseTransactionInsert(method *extract.InterfaceMeth
 		} else {
 			ip.OperateMode = OperateMany
 		}
+		if index+2 < len(tokens) && tokens[index+2] == unordered {
+			if err := ip.parseUnordered(tokens[index+2:index+3], method); err != nil {
+				return err
+			}
+		}
 
 		tp.TransactionOperations = append(tp.TransactionOperations, TransactionOperation{
 			CollectionParamName: collectionParamName,
@@ -274,6 +308,7 @@ func (tp *TransactionParse) parseTransactionUpdate(method *extract.InterfaceMeth
 
 		noIndex := getNextOperationIndex(tokens, index+2, hasCollection)
 		up := newUpdateParse()
+		up.Query.foundName = tp.foundName
 		if err := up.parseUpdate(tokens[index+2:noIndex], method, curParamIndex, true); err != nil {
 			return 0, err
 		}
@@ -308,6 +343,7 @@ func (tp *TransactionParse) parseTransactionDelete(method *extract.InterfaceMeth
 
 		noIndex := getNextOperationIndex(tokens, index+2, hasCollection)
 		dp := newDeleteParse()
+		dp.Query.foundName = tp.foundName
 		if err := dp.parseDelete(tokens[index+2:noIndex], method, curParamIndex, true); err != nil {
 			return 0, err
 		}
@@ -327,6 +363,89 @@ func (tp *TransactionParse) parseTransactionDelete(method *extract.InterfaceMeth
 	}
 }
 
+// parseTransactionRead parses the Find One, Find Many and Count steps, the result of the step is stored in
+// the local variable and can be checked by the guard param following the query params, such as
+// func(*model.User) error for Find One, func([]*model.User) error for Find Many and func(int) error for Count.
+// The guard is required by Find Many and Count, Find One without guard aborts the transaction if no document is found.
+func (tp *TransactionParse) parseTransactionRead(method *extract.InterfaceMethod, tokens []string,
+	index int, curParamIndex *int, collectionParamName string,
+) (int, error) {
+	step := strconv.Itoa(len(tp.TransactionOperations) + 1)
+	modelType := "*" + method.BelongedToStruct.ModelPackage + "." + method.BelongedToStruct.Name
+
+	var (
+		operation  Operation
+		resultName string
+		guardType  string
+		noIndex    int
+	)
+	if tokens[index] == Find {
+		if index == len(tokens)-1 || (tokens[index+1] != One && tokens[index+1] != Many) {
+			return 0, newMethodSyntaxError(method.Name, "no One or Many specified after Find")
+		}
+		if index+1 == len(tokens)-1 {
+			return 0, newMethodSyntaxError(method.Name, fmt.Sprintf("no tokens specified after Find %s", tokens[index+1]))
+		}
+
+		noIndex = getNextOperationIndex(tokens, index+2, false)
+		fp := newFindParse()
+		fp.BelongedToMethod = method
+		fp.Query.foundName = tp.foundName
+		if err := fp.Query.parseQuery(tokens[index+2:noIndex], method, curParamIndex); err != nil {
+			return 0, err
+		}
+		fp.Query.excludeSoftDeleted(method.BelongedToStruct, false)
+		fp.Query.scopeTenant(method, "")
+
+		resultName = "found" + step
+		if tokens[index+1] == One {
+			fp.OperateMode = OperateOne
+			guardType = "func(" + modelType + ") error"
+		} else {
+			fp.OperateMode = OperateMany
+			guardType = "func([]" + modelType + ") error"
+		}
+		operation = fp
+	} else {
+		if index == len(tokens)-1 {
+			return 0, newMethodSyntaxError(method.Name, "no tokens specified after Count")
+		}
+
+		noIndex = getNextOperationIndex(tokens, index+1, false)
+		cp := newCountParse()
+		cp.BelongedToMethod = method
+		cp.Query.foundName = tp.foundName
+		if err := cp.parseQuery(tokens[index+1:noIndex], method, curParamIndex); err != nil {
+			return 0, err
+		}
+		cp.Query.excludeSoftDeleted(method.BelongedToStruct, false)
+		cp.Query.scopeTenant(method, "")
+
+		resultName = "count" + step
+		guardType = "func(int) error"
+		operation = cp
+	}
+
+	taOperation := TransactionOperation{
+		CollectionParamName: collectionParamName,
+		Operation:           operation,
+		ResultName:          resultName,
+	}
+	if *curParamIndex < len(method.Params) && method.Params[*curParamIndex].Type.RealName() == guardType {
+		taOperation.GuardParamName = method.Params[*curParamIndex].Name
+		*curParamIndex += 1
+	}
+	if fp, ok := operation.(*FindParse); ok && fp.OperateMode == OperateOne {
+		tp.foundName = resultName
+	} else if taOperation.GuardParamName == "" {
+		return 0, newMethodSyntaxError(method.Name, fmt.Sprintf("the guard parameter %s should follow the "+
+			"parameters of the Transaction step %s", guardType, step))
+	}
+
+	tp.TransactionOperations = append(tp.TransactionOperations, taOperation)
+	return noIndex, nil
+}
+
 func (tp *TransactionParse) parseTransactionBulk(method *extract.InterfaceMethod, tokens []string,
 	index int, curParamIndex *int, collectionParamName string,
 ) (int, error) {
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransactionRead(t *testing.T) {
+	ifo, err := parseVideo(`mongo.TransactionFindOneByIdEqualUpdateOneStatusByIdEqualFoundCountByStatusEqual = "Publish(ctx context.Context, client *mongo.Client, id int64, guard func(*video.Video) error, status int32, status2 int32, check func(int) error) error"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := ifo.Operations[0].(*TransactionParse)
+	if len(tp.TransactionOperations) != 3 {
+		t.Fatalf("got %d steps, want 3", len(tp.TransactionOperations))
+	}
+
+	want := []struct {
+		result, guard, tree string
+	}{
+		{result: "found1", guard: "guard", tree: "Equal(_id, id)"},
+		{tree: "Equal(_id, found1.Id)"},
+		{result: "count3", guard: "check", tree: "Equal(status, status2)"},
+	}
+	for i, step := range tp.TransactionOperations {
+		if step.ResultName != want[i].result || step.GuardParamName != want[i].guard {
+			t.Errorf("step %d: got result %q guard %q, want %q %q", i+1, step.ResultName, step.GuardParamName,
+				want[i].result, want[i].guard)
+		}
+		q := operationQuery(step.Operation)
+		if got := treeString(q.ConnectionOpTree); got != want[i].tree {
+			t.Errorf("step %d: got %s, want %s", i+1, got, want[i].tree)
+		}
+	}
+}
+
+func TestTransactionReadErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		err        string
+	}{
+		{
+			name:       "count without guard",
+			annotation: `mongo.TransactionCountByStatusEqualDeleteOneByIdEqual = "Tx(ctx context.Context, client *mongo.Client, status int32, id int64) error"`,
+			err:        "the guard parameter func(int) error should follow the parameters of the Transaction step 1",
+		},
+		{
+			name:       "found without find one",
+			annotation: `mongo.TransactionDeleteOneByIdEqualFound = "Tx(ctx context.Context, client *mongo.Client) error"`,
+			err:        "Found should follow a Find One step of the Transaction",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseVideo(tt.annotation)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
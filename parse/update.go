@@ -95,6 +95,8 @@ func (up *UpdateParse) parseUpdate(tokens []string, method *extract.InterfaceMet
 	if err = up.Query.parseQuery(tokens[fqIndex:], method, curParamIndex); err != nil {
 		return err
 	}
+	up.Query.excludeSoftDeleted(method.BelongedToStruct, up.Upsert)
+	up.Query.scopeTenant(method, "")
 
 	if !isCalled {
 		if *curParamIndex < len(method.Params) {
@@ -168,17 +170,17 @@ func (up *UpdateParse) parseUpdateField(tokens []string, method *extract.Interfa
 	}
 
 	for i := 0; i < len(result); i++ {
-		if i+1 >= len(method.Params) {
+		if i+*curParamIndex >= len(method.Params) {
 			return newMethodSyntaxError(method.Name, "insufficient number of input parameters")
 		}
 		if method.Params[i+*curParamIndex].Type.RealName() != t[i].RealName() {
 			return newMethodSyntaxError(method.Name,
 				fmt.Sprintf("the field type in the parameter transfer: %s, the actual required field type: %s",
-					method.Params[i].Type.RealName(), t[0].RealName()))
+					method.Params[i+*curParamIndex].Type.RealName(), t[i].RealName()))
 		}
 		up.UpdateFields = append(up.UpdateFields, UpdateField{
 			MongoFieldName: result[i],
-			ParamName:      method.Params[i+1].Name,
+			ParamName:      method.Params[i+*curParamIndex].Name,
 		})
 	}
 	*curParamIndex += len(result)
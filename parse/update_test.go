@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpdateFieldParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		fields     string
+	}{
+		{
+			name:       "update",
+			annotation: `mongo.UpdateTitleStatusByIdEqual = "UpdateTitleStatus(ctx context.Context, title string, status int32, id int64) (bool, error)"`,
+			fields:     "title=title, status=status",
+		},
+		{
+			name: "bulk",
+			annotation: `mongo.BulkInsertOneUpdateOneTitleStatusByIdEqual = ` +
+				`"Bulk(ctx context.Context, v *video.Video, title string, status int32, id int64) (*mongo.BulkWriteResult, error)"`,
+			fields: "title=title, status=status",
+		},
+		{
+			name: "transaction",
+			annotation: `mongo.TransactionInsertOneUpdateOneStatusByIdEqual = ` +
+				`"Save(ctx context.Context, client *mongo.Client, v *video.Video, status int32, id int64) error"`,
+			fields: "status=status",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ifo, err := parseVideo(tt.annotation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var update *UpdateParse
+			switch operation := ifo.Operations[0].(type) {
+			case *UpdateParse:
+				update = operation
+			case *BulkParse:
+				update = operation.Operations[1].(*UpdateParse)
+			case *TransactionParse:
+				update = operation.TransactionOperations[1].Operation.(*UpdateParse)
+			}
+			fields := make([]string, 0, len(update.UpdateFields))
+			for _, field := range update.UpdateFields {
+				fields = append(fields, field.MongoFieldName+"="+field.ParamName)
+			}
+			if got := strings.Join(fields, ", "); got != tt.fields {
+				t.Errorf("got fields %s, want %s", got, tt.fields)
+			}
+		})
+	}
+}
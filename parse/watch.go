@@ -0,0 +1,169 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+)
+
+type WatchParse struct {
+	// OperationTypes are the operation types of the change events, empty means all types
+	OperationTypes []string
+
+	// Query defines the Query information contained in the Watch operation,
+	// the fields are the paths of the fullDocument of the change events
+	Query *Query
+
+	// CtxParamName defines the method's context.Context param name
+	CtxParamName string
+
+	// OptionsParamName defines the method's *options.ChangeStreamOptions param name, it is optional
+	// and sets the resume token or the operation time to start at
+	OptionsParamName string
+
+	// BelongedToMethod defines the method to which Watch belongs
+	BelongedToMethod *extract.InterfaceMethod
+}
+
+const (
+	fullDocument      = "fullDocument."
+	changeStreamType  = "ChangeStream"
+	changeOptionsType = "*options.ChangeStreamOptions"
+)
+
+// watchOperationTypes maps the tokens to the operation types of the change events.
+var watchOperationTypes = map[string]string{
+	Insert:  "insert",
+	Update:  "update",
+	Replace: "replace",
+	Delete:  "delete",
+}
+
+func newWatchParse() *WatchParse {
+	return &WatchParse{Query: newQuery()}
+}
+
+func (wp *WatchParse) GetOperationName() string {
+	return Watch
+}
+
+// parseWatch can be called independently.
+//
+//	input params description:
+//	tokens: it contains all tokens belonging to Watch except for Watch token
+//	method: the method to which Watch belongs
+//	curParamIndex: current method's param index
+func (wp *WatchParse) parseWatch(tokens []string, method *extract.InterfaceMethod, curParamIndex *int) error {
+	if err := wp.check(method); err != nil {
+		return err
+	}
+
+	wp.BelongedToMethod = method
+
+	fqIndex, err := getFirstQueryIndex(tokens)
+	if err != nil {
+		return newMethodSyntaxError(method.Name, err.Error())
+	}
+	for _, token := range tokens[:fqIndex] {
+		operationType, ok := watchOperationTypes[token]
+		if !ok {
+			return newMethodSyntaxError(method.Name, fmt.Sprintf("unknown operation type %s, "+
+				"should be Insert, Update, Replace or Delete", token))
+		}
+		wp.OperationTypes = append(wp.OperationTypes, operationType)
+	}
+
+	if err = wp.Query.parseQuery(tokens[fqIndex:], method, curParamIndex); err != nil {
+		return err
+	}
+	if wp.Query.ConnectionOpTree != nil {
+		for _, operationType := range wp.OperationTypes {
+			if operationType == watchOperationTypes[Delete] {
+				return newMethodSyntaxError(method.Name, "the Delete events carry no full document, "+
+					"they can not be filtered by the query")
+			}
+		}
+		prefixFieldNames(wp.Query.ConnectionOpTree, fullDocument)
+	}
+	if method.BelongedToStruct.TenantField != nil {
+		for _, operationType := range wp.OperationTypes {
+			if operationType == watchOperationTypes[Delete] {
+				return newMethodSyntaxError(method.Name, "the Delete events carry no full document, "+
+					"they can not be scoped by the tenant")
+			}
+		}
+		// the Delete events are not delivered since they carry no full document
+		wp.Query.scopeTenant(method, fullDocument)
+	}
+
+	if *curParamIndex < len(method.Params) && method.Params[*curParamIndex].Type.RealName() == changeOptionsType {
+		wp.OptionsParamName = method.Params[*curParamIndex].Name
+		*curParamIndex++
+	}
+
+	if *curParamIndex < len(method.Params) {
+		return newMethodSyntaxError(method.Name, fmt.Sprintf("too many method parameters written, "+
+			"%v and subsequent parameters are useless", method.Params[*curParamIndex].Name))
+	}
+
+	return nil
+}
+
+func (wp *WatchParse) check(method *extract.InterfaceMethod) error {
+	if len(method.Params) < 1 {
+		return newMethodSyntaxError(method.Name, "less than one input parameters")
+	}
+
+	if len(method.Returns) != 2 {
+		return newMethodSyntaxError(method.Name, "return parameter not equal to 2")
+	}
+
+	if method.Params[0].Type.RealName() != "context.Context" {
+		return newMethodSyntaxError(method.Name, "the first parameter in the input parameters "+
+			"should be context.Context")
+	}
+
+	streamType := "*" + method.BelongedToStruct.Name + changeStreamType
+	if method.Returns[0].RealName() != streamType {
+		return newMethodSyntaxError(method.Name, "the first parameter in the return parameters "+
+			"should be "+streamType)
+	}
+
+	if method.Returns[1].RealName() != "error" {
+		return newMethodSyntaxError(method.Name, "the second parameter in the return parameters "+
+			"should be error")
+	}
+
+	wp.CtxParamName = method.Params[0].Name
+
+	return nil
+}
+
+// prefixFieldNames prefixes the field names of the leaves of the query tree.
+func prefixFieldNames(node *ConnectionOpTree, prefix string) {
+	if node.LeftChildren == nil {
+		if !strings.HasPrefix(node.MongoFieldName, prefix) {
+			node.MongoFieldName = prefix + node.MongoFieldName
+		}
+		return
+	}
+	prefixFieldNames(node.LeftChildren, prefix)
+	prefixFieldNames(node.RightChildren, prefix)
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWatch(t *testing.T) {
+	ifo, err := parseVideo(
+		`mongo.WatchInsertUpdateByStatusEqualAndTitleEqual = "WatchStatus(ctx context.Context, status int32, title string, opts *options.ChangeStreamOptions) (*VideoChangeStream, error)"`,
+		`mongo.WatchAll = "Watch(ctx context.Context) (*VideoChangeStream, error)"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wp := ifo.Operations[0].(*WatchParse)
+	if want := []string{"insert", "update"}; !reflect.DeepEqual(wp.OperationTypes, want) {
+		t.Errorf("got operation types %v, want %v", wp.OperationTypes, want)
+	}
+	if wp.OptionsParamName != "opts" {
+		t.Errorf("got options param %q, want opts", wp.OptionsParamName)
+	}
+	if got, want := treeString(wp.Query.ConnectionOpTree),
+		"And(Equal(fullDocument.status, status), Equal(fullDocument.title, title))"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	wp = ifo.Operations[1].(*WatchParse)
+	if len(wp.OperationTypes) != 0 || wp.Query.ConnectionOpTree != nil || wp.OptionsParamName != "" {
+		t.Errorf("got %+v, want all the events", wp)
+	}
+}
+
+func TestWatchErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		err        string
+	}{
+		{
+			name:       "delete with query",
+			annotation: `mongo.WatchDeleteByStatusEqual = "Watch(ctx context.Context, status int32) (*VideoChangeStream, error)"`,
+			err:        "the Delete events carry no full document",
+		},
+		{
+			name:       "unknown operation type",
+			annotation: `mongo.WatchFindAll = "Watch(ctx context.Context) (*VideoChangeStream, error)"`,
+			err:        "unknown operation type Find",
+		},
+		{
+			name:       "stream type",
+			annotation: `mongo.WatchAll = "Watch(ctx context.Context) (*mongo.ChangeStream, error)"`,
+			err:        "should be *VideoChangeStream",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseVideo(tt.annotation)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
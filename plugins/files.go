@@ -0,0 +1,339 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugins
+
+import (
+	"go/format"
+	"path/filepath"
+
+	"github.com/cloudwego/thriftgo/plugin"
+	"github.com/hertz-contrib/thrift-gen-mongo/args"
+	"github.com/hertz-contrib/thrift-gen-mongo/codegen"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/template"
+)
+
+// renderFile renders the file of package pkg, the renders follow the package clause and the imports in order.
+func renderFile(version, pkg string, imports map[string]string, renders ...template.Render) (string, error) {
+	tpl := &template.Template{
+		Renders: []template.Render{&template.BaseRender{
+			Version:     version,
+			PackageName: pkg,
+			Imports:     imports,
+		}},
+	}
+	tpl.Renders = append(tpl.Renders, renders...)
+
+	buff, err := tpl.Build()
+	if err != nil {
+		return "", err
+	}
+	formattedCode, err := format.Source(buff.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	return string(formattedCode), nil
+}
+
+// appendFile appends the file to the result, the import paths are added when the content references their packages.
+func appendFile(result []*plugin.Generated, name, content string, importPaths []string) ([]*plugin.Generated, error) {
+	if len(importPaths) != 0 {
+		var err error
+		if content, err = extract.AddMongoModelImports(content, importPaths); err != nil {
+			return nil, err
+		}
+	}
+	return append(result, &plugin.Generated{
+		Content: content,
+		Name:    &name,
+	}), nil
+}
+
+// withImport returns a copy of imports with the import path added.
+func withImport(imports map[string]string, path string) map[string]string {
+	result := make(map[string]string, len(imports)+1)
+	for p, name := range imports {
+		result[p] = name
+	}
+	result[path] = ""
+	return result
+}
+
+func methodRenders(renders []*template.MethodRender) []template.Render {
+	result := make([]template.Render, 0, len(renders))
+	for _, render := range renders {
+		result = append(result, render)
+	}
+	return result
+}
+
+// buildStructFiles appends the files which are generated with the repository of the struct besides
+// the repository and the interface files.
+func buildStructFiles(result []*plugin.Generated, args *args.Arguments, st *extract.IdlExtractStruct,
+	memoryRenders []*template.MethodRender, modelImportPaths func(paths ...string) []string, mongoxImportPath string,
+) ([]*plugin.Generated, error) {
+	pkgName := extract.GetPkgName(st.Name)
+	fileName := func(name string) string {
+		return extract.GetExtraFileName(st.Name, args.DaoDir, name)
+	}
+
+	// build errors file
+	content, err := renderFile(args.Version, pkgName, codegen.ErrorsImports, &template.ErrorsRender{})
+	if err != nil {
+		return nil, err
+	}
+	if result, err = appendFile(result, fileName("errors.go"), content, nil); err != nil {
+		return nil, err
+	}
+
+	// build bulk builder file
+	content, err = renderFile(args.Version, pkgName, codegen.BulkBuilderImports(st), codegen.GetBulkBuilderRender(st, args.Tracing))
+	if err != nil {
+		return nil, err
+	}
+	if result, err = appendFile(result, fileName("bulk.go"), content, modelImportPaths(mongoxImportPath)); err != nil {
+		return nil, err
+	}
+
+	// build chunk file
+	if st.ChunkSize > 0 {
+		content, err = renderFile(args.Version, pkgName, map[string]string{}, &template.ChunkRender{ChunkSize: st.ChunkSize})
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, fileName("chunk.go"), content, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// build transaction file
+	if hasTransaction(st) {
+		content, err = renderFile(args.Version, pkgName, withImport(codegen.TransactionImports, mongoxImportPath),
+			&template.TransactionRender{})
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, fileName("transaction.go"), content, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// build watch file
+	if hasWatch(st) {
+		content, err = renderFile(args.Version, pkgName, codegen.WatchImports, codegen.GetWatchRender(st))
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, fileName("watch.go"), content, modelImportPaths()); err != nil {
+			return nil, err
+		}
+	}
+
+	// build cached repository file
+	if len(st.CacheOptions) != 0 {
+		renders := append([]template.Render{&template.CachedRepositoryRender{StructName: st.Name}},
+			methodRenders(codegen.GetCachedMethodRenders(st))...)
+		content, err = renderFile(args.Version, pkgName,
+			withImport(codegen.CachedRepositoryImports, mongoxImportPath), renders...)
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, fileName("cache.go"), content,
+			modelImportPaths(codegen.CachedRepositoryParamImports...)); err != nil {
+			return nil, err
+		}
+	}
+
+	// build in-memory repository file
+	renders := append([]template.Render{&template.MemoryRepositoryRender{StructName: st.Name}},
+		methodRenders(memoryRenders)...)
+	content, err = renderFile(args.Version, pkgName, withImport(codegen.MemoryRepositoryImports, mongoxImportPath),
+		renders...)
+	if err != nil {
+		return nil, err
+	}
+	if result, err = appendFile(result, fileName("memory.go"), content,
+		modelImportPaths(codegen.MemoryRepositoryParamImports...)); err != nil {
+		return nil, err
+	}
+
+	// build mock file, it is generated with all the methods of the interface so that it stays in sync
+	// with the interface in the update mode
+	if args.Mock {
+		methods := getRepositoryMethods(st)
+		renders := append([]template.Render{codegen.GetMockRender(st, methods)},
+			methodRenders(codegen.GetMockMethodRenders(st, methods))...)
+		content, err = renderFile(args.Version, pkgName, map[string]string{}, renders...)
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, extract.GetMockFileName(st.Name, args.DaoDir), content,
+			append(modelImportPaths(mongoxImportPath), codegen.MockParamImports...)); err != nil {
+			return nil, err
+		}
+	}
+
+	// build schema file
+	content, err = renderFile(args.Version, pkgName, codegen.SchemaImports, codegen.GetSchemaRender(st))
+	if err != nil {
+		return nil, err
+	}
+	if result, err = appendFile(result, fileName("schema.go"), content, nil); err != nil {
+		return nil, err
+	}
+
+	// build index file
+	if len(st.Indexes) != 0 {
+		content, err = renderFile(args.Version, pkgName, codegen.IndexImports(st), codegen.GetEnsureIndexesRender(st))
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, fileName("index.go"), content, []string{mongoxImportPath}); err != nil {
+			return nil, err
+		}
+	}
+
+	// build shard file
+	if st.ShardKey != nil {
+		content, err = renderFile(args.Version, pkgName, codegen.ShardImports, codegen.GetShardCollectionRender(st))
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, fileName("shard.go"), content, []string{mongoxImportPath}); err != nil {
+			return nil, err
+		}
+	}
+
+	// build id file
+	if st.IDStrategy != "" {
+		content, err = renderFile(args.Version, pkgName, codegen.IDImports(st.IDStrategy), &template.IDRender{
+			PackageName: pkgName,
+			StructName:  st.Name,
+			FieldName:   st.IDField.Name,
+			FieldType:   st.IDField.Type.RealName(),
+			Strategy:    st.IDStrategy,
+
+			SequenceName:       st.SequenceName,
+			SequenceCollection: st.SequenceCollection,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, fileName("id.go"), content, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// buildMongoxFiles appends the files of the package which is shared by all the repositories.
+func buildMongoxFiles(result []*plugin.Generated, args *args.Arguments, structs []*extract.IdlExtractStruct,
+	mongoxImportPath string,
+) ([]*plugin.Generated, error) {
+	files := []struct {
+		name    string
+		imports map[string]string
+		render  template.Render
+		enabled bool
+	}{
+		{"unit_of_work.go", codegen.UnitOfWorkImports, &template.UnitOfWorkRender{
+			MaxAttempts: codegen.DefaultTransactionMaxAttempts,
+		}, true},
+		{"interceptor.go", codegen.InterceptorImports, &template.InterceptorRender{}, true},
+		{"memory.go", codegen.MemoryImports, &template.MemoryRender{}, true},
+		{"mock.go", codegen.MockRecorderImports, &template.MockRecorderRender{}, args.Mock},
+		{"tenant.go", codegen.TenantImports, &template.TenantRender{}, anyStruct(structs, codegen.TenantScoped)},
+		{"cache.go", codegen.CacheImports, &template.CacheRender{}, anyStruct(structs, func(st *extract.IdlExtractStruct) bool {
+			return len(st.CacheOptions) != 0
+		})},
+		{"trace.go", codegen.TraceImports, &template.TraceRender{}, args.Tracing},
+	}
+	for _, file := range files {
+		if !file.enabled {
+			continue
+		}
+		content, err := renderFile(args.Version, extract.MongoxPkgName, file.imports, file.render)
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, extract.GetMongoxFileName(args.DaoDir, file.name), content, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// build the OpenTelemetry adapter of the tracer
+	if args.Tracing {
+		content, err := renderFile(args.Version, extract.OtelPkgName, withImport(codegen.OtelImports, mongoxImportPath),
+			&template.OtelRender{MongoxImportPath: mongoxImportPath})
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, extract.GetMongoxFileName(args.DaoDir,
+			filepath.Join(extract.OtelPkgName, "tracer.go")), content, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// buildValidateFiles appends the validation files of the model packages.
+func buildValidateFiles(result []*plugin.Generated, args *args.Arguments, structs []*extract.IdlExtractStruct,
+) ([]*plugin.Generated, error) {
+	modelDirs := make([]string, 0, 5)
+	modelStructs := make(map[string][]*extract.IdlExtractStruct)
+	for _, st := range structs {
+		if !st.HasValidation() {
+			continue
+		}
+		if _, ok := modelStructs[st.ModelDir]; !ok {
+			modelDirs = append(modelDirs, st.ModelDir)
+		}
+		modelStructs[st.ModelDir] = append(modelStructs[st.ModelDir], st)
+	}
+
+	for _, modelDir := range modelDirs {
+		methods, patterns := codegen.GetValidateRenders(modelStructs[modelDir])
+		imports := make(map[string]string, len(codegen.ValidateImports))
+		for path, name := range codegen.ValidateImports {
+			if path != "regexp" || len(patterns) != 0 {
+				imports[path] = name
+			}
+		}
+		renders := append([]template.Render{&template.ValidationRender{Patterns: patterns}}, methodRenders(methods)...)
+		content, err := renderFile(args.Version, modelStructs[modelDir][0].ModelPackage, imports, renders...)
+		if err != nil {
+			return nil, err
+		}
+		if result, err = appendFile(result, filepath.Join(modelDir, "mongo_validate.go"), content, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func anyStruct(structs []*extract.IdlExtractStruct, fn func(st *extract.IdlExtractStruct) bool) bool {
+	for _, st := range structs {
+		if fn(st) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package plugins
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/cloudwego/thriftgo/plugin"
+	"github.com/hertz-contrib/thrift-gen-mongo/args"
+	"github.com/hertz-contrib/thrift-gen-mongo/codegen"
+	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
+)
+
+// generate runs the plugin on the IDL for the module test in dir, the model is in biz/model and the
+// repositories are in biz/dao.
+func generate(t *testing.T, idl, dir string) []*plugin.Generated {
+	t.Helper()
+	ast, err := parser.ParseFile(idl, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &args.Arguments{
+		GoMod:         "test",
+		PackagePrefix: "test/biz/model",
+		IdlPath:       idl,
+		IdlType:       "thrift",
+		OutDir:        dir,
+		ModelDir:      filepath.Join(dir, "biz", "model"),
+		DaoDir:        filepath.Join(dir, "biz", "dao"),
+		Version:       Version,
+		Tracing:       true,
+		Mock:          true,
+	}
+	thriftMeta := &extract.ThriftMeta{
+		Req:         &plugin.Request{AST: ast},
+		Args:        a,
+		ImportPaths: make([]string, 0, 10),
+	}
+
+	rawStructs, err := thriftMeta.ParseThriftIdl()
+	if err != nil {
+		t.Fatal(err)
+	}
+	operations, err := parse.HandleOperations(rawStructs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := buildResponse(a, rawStructs, codegen.HandleCodegen(operations, a.Tracing),
+		codegen.HandleMemoryCodegen(operations), thriftMeta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return generated
+}
+
+// TestGeneratedCode generates the repositories of testdata/generated/video.thrift into the module of
+// testdata/generated, and runs the tests of the module against the generated code.
+func TestGeneratedCode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("the generated code is built in the long tests")
+	}
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go command is not found")
+	}
+
+	dir := t.TempDir()
+	err = filepath.WalkDir("testdata/generated", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel("testdata/generated", path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return writeFile(filepath.Join(dir, rel), data)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range generate(t, filepath.Join(dir, "video.thrift"), dir) {
+		if err = writeFile(*g.Name, []byte(g.Content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(args ...string) ([]byte, error) {
+		cmd := exec.Command(goCmd, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+		return cmd.CombinedOutput()
+	}
+	if output, err := run("mod", "download", "go.mongodb.org/mongo-driver"); err != nil {
+		t.Skipf("the dependencies of the generated code are unavailable: %s", output)
+	}
+	if output, err := run("vet", "./..."); err != nil {
+		t.Fatalf("%s", output)
+	}
+	if output, err := run("test", "./..."); err != nil {
+		t.Fatalf("%s", output)
+	}
+}
+
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
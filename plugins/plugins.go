@@ -75,8 +75,9 @@ func HandleRequest(req *plugin.Request) error {
 		return err
 	}
 
-	methodRenders := codegen.HandleCodegen(operations)
-	generated, err := buildResponse(a, rawStructs, methodRenders, thriftMeta)
+	methodRenders := codegen.HandleCodegen(operations, a.Tracing)
+	memoryRenders := codegen.HandleMemoryCodegen(operations)
+	generated, err := buildResponse(a, rawStructs, methodRenders, memoryRenders, thriftMeta)
 	if err != nil {
 		return err
 	}
@@ -84,6 +85,9 @@ func HandleRequest(req *plugin.Request) error {
 	res := &plugin.Response{
 		Contents: generated,
 	}
+	for _, operation := range operations {
+		res.Warnings = append(res.Warnings, operation.Warnings...)
+	}
 
 	if err := handleRequest(res); err != nil {
 		return err
@@ -18,6 +18,7 @@ package plugins
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -61,15 +62,26 @@ func Test_handleRequest(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	methodRenders := codegen.HandleCodegen(operations)
-	generated, err := buildResponse(a, rawStructs, methodRenders, thriftMeta)
+	methodRenders := codegen.HandleCodegen(operations, a.Tracing)
+	memoryRenders := codegen.HandleMemoryCodegen(operations)
+	generated, err := buildResponse(a, rawStructs, methodRenders, memoryRenders, thriftMeta)
 	if err != nil && len(generated) != 2 {
 		t.Fatal(err)
 	}
 
+	// the repository interface and its mongo implementation are in the video_repo files,
+	// the other files of the repository and the shared mongox package are generated beside them
+	repos := 0
 	for _, g := range generated {
-		if !strings.Contains(*g.Name, "video_repo") {
-			t.Fatal(err)
+		if strings.Contains(*g.Name, "video_repo") {
+			repos++
+			continue
 		}
+		if dir := filepath.Base(filepath.Dir(*g.Name)); dir != "video" && dir != extract.MongoxPkgName {
+			t.Fatalf("unexpected generated file %s", *g.Name)
+		}
+	}
+	if repos != 2 {
+		t.Fatalf("got %d video_repo files, want 2", repos)
 	}
 }
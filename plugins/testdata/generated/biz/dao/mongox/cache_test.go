@@ -0,0 +1,57 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongox
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type cachedDoc struct {
+	Title string
+	Tags  []string
+	At    time.Time
+}
+
+func TestLRUCacheCopies(t *testing.T) {
+	cache := NewLRUCache(2)
+	doc := &cachedDoc{Title: "one", Tags: []string{"a"}, At: time.Unix(1, 0)}
+	cache.Set("key", doc, 0)
+	doc.Tags[0] = "changed after set"
+
+	value, ok := cache.Get("key")
+	if !ok {
+		t.Fatal("the value is not cached")
+	}
+	got := value.(*cachedDoc)
+	if got == doc || got.Tags[0] != "a" || !got.At.Equal(doc.At) {
+		t.Fatalf("got %+v", got)
+	}
+	got.Tags[0] = "changed after get"
+	if value, _ = cache.Get("key"); value.(*cachedDoc).Tags[0] != "a" {
+		t.Fatalf("the cached value is changed to %+v", value)
+	}
+}
+
+func TestAfterCommitOutOfUnitOfWork(t *testing.T) {
+	ran := false
+	AfterCommit(context.Background(), func() { ran = true })
+	if !ran {
+		t.Fatal("the hook is not run at once out of a UnitOfWork")
+	}
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongox
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func newTestCollection(t *testing.T) *MemoryCollection {
+	t.Helper()
+	c := NewMemoryCollection("test")
+	documents := []interface{}{
+		bson.D{
+			{Key: "_id", Value: 1}, {Key: "title", Value: "a"}, {Key: "n", Value: int32(1)},
+			{Key: "tags", Value: bson.A{"x", "y"}}, {Key: "author", Value: bson.D{{Key: "name", Value: "ann"}}},
+			{Key: "items", Value: bson.A{bson.D{{Key: "k", Value: 1}}, bson.D{{Key: "k", Value: 2}}}},
+		},
+		bson.D{
+			{Key: "_id", Value: 2}, {Key: "title", Value: "b"}, {Key: "n", Value: int64(5)},
+			{Key: "tags", Value: bson.A{}}, {Key: "author", Value: bson.D{{Key: "name", Value: "bob"}}},
+		},
+		bson.D{
+			{Key: "_id", Value: 3}, {Key: "title", Value: "c"}, {Key: "n", Value: 10.0}, {Key: "author", Value: nil},
+		},
+		bson.D{
+			{Key: "_id", Value: 4}, {Key: "title", Value: "d"}, {Key: "n", Value: "10"},
+		},
+	}
+	if _, err := c.InsertMany(context.Background(), documents); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func findIDs(c *MemoryCollection, filter interface{}) ([]int32, error) {
+	cursor, err := c.Find(context.Background(), filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var documents []struct {
+		ID int32 `bson:"_id"`
+	}
+	if err = cursor.All(context.Background(), &documents); err != nil {
+		return nil, err
+	}
+	ids := make([]int32, 0, len(documents))
+	for _, document := range documents {
+		ids = append(ids, document.ID)
+	}
+	return ids, nil
+}
+
+func TestMatchDocument(t *testing.T) {
+	c := newTestCollection(t)
+	tests := []struct {
+		name   string
+		filter interface{}
+		want   []int32
+	}{
+		{"empty", bson.M{}, []int32{1, 2, 3, 4}},
+		{"equal", bson.M{"title": "a"}, []int32{1}},
+		{"equal across number types", bson.M{"n": 5}, []int32{2}},
+		{"equal does not convert strings", bson.M{"n": 10}, []int32{3}},
+		{"equal array element", bson.M{"tags": "x"}, []int32{1}},
+		{"equal whole array", bson.M{"tags": bson.A{"x", "y"}}, []int32{1}},
+		{"equal empty array", bson.M{"tags": bson.A{}}, []int32{2}},
+		{"equal nested field", bson.M{"author.name": "bob"}, []int32{2}},
+		{"equal field of array documents", bson.M{"items.k": 2}, []int32{1}},
+		{"equal null matches missing", bson.M{"author": nil}, []int32{3, 4}},
+		{"$eq", bson.M{"n": bson.M{"$eq": 1}}, []int32{1}},
+		{"$ne", bson.M{"n": bson.M{"$ne": 5}}, []int32{1, 3, 4}},
+		{"$ne missing", bson.M{"tags": bson.M{"$ne": "x"}}, []int32{2, 3, 4}},
+		{"$in", bson.M{"n": bson.M{"$in": bson.A{1, 10}}}, []int32{1, 3}},
+		{"$in null", bson.M{"author": bson.M{"$in": bson.A{nil}}}, []int32{3, 4}},
+		{"$nin", bson.M{"n": bson.M{"$nin": bson.A{1, 10}}}, []int32{2, 4}},
+		{"$lt", bson.M{"n": bson.M{"$lt": 5}}, []int32{1}},
+		{"$lte", bson.M{"n": bson.M{"$lte": 5}}, []int32{1, 2}},
+		{"$gt", bson.M{"n": bson.M{"$gt": 5}}, []int32{3}},
+		{"$gte", bson.M{"n": bson.M{"$gte": 5}}, []int32{2, 3}},
+		{"$gt string", bson.M{"n": bson.M{"$gt": "1"}}, []int32{4}},
+		{"$gt array element", bson.M{"items.k": bson.M{"$gt": 1}}, []int32{1}},
+		{"range", bson.D{{Key: "n", Value: bson.D{{Key: "$gt", Value: 1}, {Key: "$lt", Value: 10}}}}, []int32{2}},
+		{"$exists", bson.M{"tags": bson.M{"$exists": true}}, []int32{1, 2}},
+		{"$exists null", bson.M{"author": bson.M{"$exists": true}}, []int32{1, 2, 3}},
+		{"not $exists", bson.M{"tags": bson.M{"$exists": false}}, []int32{3, 4}},
+		{"$and", bson.M{"$and": bson.A{bson.M{"n": bson.M{"$gte": 1}}, bson.M{"n": bson.M{"$lt": 10}}}}, []int32{1, 2}},
+		{"$or", bson.M{"$or": bson.A{bson.M{"title": "a"}, bson.M{"n": "10"}}}, []int32{1, 4}},
+		{"$nor", bson.M{"$nor": bson.A{bson.M{"title": "a"}, bson.M{"n": "10"}}}, []int32{2, 3}},
+		{"nested logical", bson.M{"$and": bson.A{
+			bson.M{"$or": bson.A{bson.M{"title": "a"}, bson.M{"title": "b"}}},
+			bson.M{"$and": bson.A{bson.M{"tags": bson.M{"$exists": true}}}},
+		}}, []int32{1, 2}},
+		{"implicit and", bson.M{"title": "b", "n": 5}, []int32{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findIDs(c, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchDocumentUnsupported(t *testing.T) {
+	c := newTestCollection(t)
+	tests := []struct {
+		name   string
+		filter interface{}
+		err    string
+	}{
+		{"operator", bson.M{"title": bson.M{"$regex": "a"}}, "$regex is not supported"},
+		{"top level operator", bson.M{"$where": "true"}, "$where is not supported"},
+		{"$in without array", bson.M{"n": bson.M{"$in": 1}}, "$in needs an array"},
+		{"$and without array", bson.M{"$and": bson.M{"n": 1}}, "$and must be a nonempty array"},
+		{"empty $or", bson.M{"$or": bson.A{}}, "$or must be a nonempty array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := findIDs(c, tt.filter)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestApplyUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		update interface{}
+		upsert bool
+		want   bson.M
+		err    string
+	}{
+		{
+			name:   "$set",
+			update: bson.M{"$set": bson.M{"title": "z", "author.name": "zed"}},
+			want:   bson.M{"_id": int32(2), "title": "z", "author": bson.M{"name": "zed"}},
+		},
+		{
+			name:   "$unset",
+			update: bson.M{"$unset": bson.M{"author": ""}},
+			want:   bson.M{"_id": int32(2), "title": "b"},
+		},
+		{
+			name:   "$setOnInsert is skipped on update",
+			update: bson.M{"$setOnInsert": bson.M{"title": "z"}},
+			want:   bson.M{"_id": int32(2), "title": "b", "author": bson.M{"name": "bob"}},
+		},
+		{
+			name:   "$inc",
+			update: bson.M{"$inc": bson.M{"n": 1}},
+			err:    "$inc is not supported",
+		},
+		{
+			name:   "_id",
+			update: bson.M{"$set": bson.M{"_id": 7}},
+			err:    "immutable field '_id'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewMemoryCollection("test")
+			ctx := context.Background()
+			_, err := c.InsertOne(ctx, bson.D{
+				{Key: "_id", Value: int32(2)}, {Key: "title", Value: "b"},
+				{Key: "author", Value: bson.D{{Key: "name", Value: "bob"}}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.UpdateOne(ctx, bson.M{"_id": 2}, tt.update)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got bson.M
+			if err = c.FindOne(ctx, bson.M{"_id": 2}).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(normalize(got), normalize(tt.want)) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	c := NewMemoryCollection("test")
+	ctx := context.Background()
+	result, err := c.UpdateOne(ctx, bson.M{"title": "a"}, bson.M{
+		"$set":         bson.M{"n": 1},
+		"$setOnInsert": bson.M{"_id": 9},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.UpsertedCount != 1 {
+		t.Fatalf("got %+v", result)
+	}
+	ids, err := findIDs(c, bson.M{"title": "a", "n": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int32{9}) {
+		t.Fatalf("got %v", ids)
+	}
+}
+
+// normalize converts the documents into the same types by marshaling them.
+func normalize(m bson.M) bson.M {
+	data, err := bson.Marshal(m)
+	if err != nil {
+		panic(err)
+	}
+	var result bson.M
+	if err = bson.Unmarshal(data, &result); err != nil {
+		panic(err)
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongox
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRoutedTenantConditions(t *testing.T) {
+	ctx := WithTenant(context.Background(), "a")
+	if got := RoutedTenantConditions(ctx, "tenant_id", false); !reflect.DeepEqual(got, TenantConditions(ctx, "tenant_id")) {
+		t.Fatalf("got conditions %v of the shared collection", got)
+	}
+	if got := RoutedTenantConditions(ctx, "tenant_id", true); !reflect.DeepEqual(got, []bson.M{{}}) {
+		t.Fatalf("got conditions %v of the collection of the tenant", got)
+	}
+}
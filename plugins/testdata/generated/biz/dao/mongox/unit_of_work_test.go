@@ -0,0 +1,79 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package mongox
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestWithTransactionAttempts(t *testing.T) {
+	// the transactions which do not run any operation do not connect to the server
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	transient := fmt.Errorf("update: %w", mongo.CommandError{Code: 112, Labels: []string{"TransientTransactionError"}})
+	tests := []struct {
+		name        string
+		maxAttempts int
+		errs        []error
+		attempts    int
+	}{
+		{name: "committed", maxAttempts: 3, errs: []error{nil}, attempts: 1},
+		{name: "retried", maxAttempts: 3, errs: []error{transient, nil}, attempts: 2},
+		{name: "exhausted", maxAttempts: 2, errs: []error{transient, transient}, attempts: 2},
+		{name: "one attempt at least", maxAttempts: 0, errs: []error{transient}, attempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := client.StartSession()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer session.EndSession(context.Background())
+
+			attempts := 0
+			err = WithTransaction(context.Background(), session, nil, tt.maxAttempts, func(mongo.SessionContext) error {
+				attempts++
+				return tt.errs[attempts-1]
+			})
+			if attempts != tt.attempts {
+				t.Fatalf("got %d attempts, want %d", attempts, tt.attempts)
+			}
+			// the error of the last attempt is returned as it is
+			if want := tt.errs[attempts-1]; err != want {
+				t.Fatalf("got error %v, want %v", err, want)
+			}
+		})
+	}
+}
+
+func TestTransactionMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	if n := TransactionMaxAttempts(ctx); n != DefaultMaxAttempts {
+		t.Fatalf("got %d attempts", n)
+	}
+	if n := TransactionMaxAttempts(WithTransactionMaxAttempts(ctx, 1)); n != 1 {
+		t.Fatalf("got %d attempts", n)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package video
+
+import (
+	"context"
+	"testing"
+
+	"test/biz/dao/mongox"
+	"test/biz/model/video"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// stubRepository stores one video and counts the FindById calls, the other methods are not implemented.
+type stubRepository struct {
+	VideoRepository
+	video *video.Video
+	finds int
+}
+
+func (r *stubRepository) FindById(ctx context.Context, id int64) (*video.Video, error) {
+	r.finds++
+	v := *r.video
+	return &v, nil
+}
+
+func (r *stubRepository) UpdateTitle(ctx context.Context, title string, id int64) (bool, error) {
+	r.video.Title = title
+	return true, nil
+}
+
+func TestCachedRepository(t *testing.T) {
+	stub := &stubRepository{video: &video.Video{Id: 1, Title: "old"}}
+	repo := NewCachedVideoRepository(stub, mongox.NewLRUCache(10))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if v, err := repo.FindById(ctx, 1); err != nil || v.Title != "old" {
+			t.Fatalf("got %v, %v", v, err)
+		}
+	}
+	if stub.finds != 1 {
+		t.Fatalf("got %d finds, want the second one cached", stub.finds)
+	}
+
+	if _, err := repo.UpdateTitle(ctx, "new", 1); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := repo.FindById(ctx, 1); err != nil || v.Title != "new" {
+		t.Fatalf("got %v, %v, want the update to invalidate the cached video", v, err)
+	}
+	if stub.finds != 2 {
+		t.Fatalf("got %d finds, want 2", stub.finds)
+	}
+}
+
+func TestCachedBulk(t *testing.T) {
+	repo := NewCachedVideoRepository(NewVideoRepositoryMemory(), mongox.NewLRUCache(10))
+	ctx := mongox.WithTenant(context.Background(), "a")
+	if _, err := repo.InsertOne(ctx, newVideo(1, "old")); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := repo.FindById(ctx, 1); err != nil || v.Title != "old" {
+		t.Fatalf("got %v, %v", v, err)
+	}
+
+	if _, err := repo.Bulk().UpdateByIdEqual(1, bson.M{"$set": bson.M{"title": "new"}}).Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := repo.FindById(ctx, 1); err != nil || v.Title != "new" {
+		t.Fatalf("got %v, %v, want the bulk update to invalidate the cached video", v, err)
+	}
+}
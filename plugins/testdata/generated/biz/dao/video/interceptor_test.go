@@ -0,0 +1,79 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package video
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"test/biz/dao/mongox"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestInterceptors(t *testing.T) {
+	errStop := errors.New("stop")
+	var calls []string
+	var got *mongox.Invocation
+	outer := func(ctx context.Context, invocation *mongox.Invocation, next mongox.Handler) error {
+		calls = append(calls, "outer")
+		return next(ctx)
+	}
+	inner := func(ctx context.Context, invocation *mongox.Invocation, next mongox.Handler) error {
+		calls = append(calls, "inner")
+		got = invocation
+		return errStop
+	}
+	collection := newClient(t).Database("test").Collection("video")
+	repo := NewVideoRepository(collection, WithVideoInterceptors(outer, inner))
+
+	if _, err := repo.UpdateTitle(context.Background(), "title", 1); !errors.Is(err, errStop) {
+		t.Fatalf("got error %v, want %v", err, errStop)
+	}
+	if !reflect.DeepEqual(calls, []string{"outer", "inner"}) {
+		t.Fatalf("got calls %v", calls)
+	}
+	if got.Repository != "Video" || got.Method != "UpdateTitle" || got.Operation != "Update" || got.Filter == nil ||
+		!reflect.DeepEqual(got.Args, []interface{}{"title", int64(1)}) {
+		t.Fatalf("got invocation %+v", got)
+	}
+}
+
+func TestBulkInterceptors(t *testing.T) {
+	errStop := errors.New("stop")
+	var got *mongox.Invocation
+	stop := func(ctx context.Context, invocation *mongox.Invocation, next mongox.Handler) error {
+		got = invocation
+		return errStop
+	}
+	collection := newClient(t).Database("test").Collection("video")
+	repo := NewVideoRepository(collection, WithVideoInterceptors(stop))
+
+	bulk := repo.Bulk().InsertOne(newVideo(1, "hello")).DeleteByIdEqual(2)
+	if _, err := bulk.Exec(mongox.WithTenant(context.Background(), "a")); !errors.Is(err, errStop) {
+		t.Fatalf("got error %v, want %v", err, errStop)
+	}
+	if got.Collection != "video" || got.Repository != "Video" || got.Method != "Bulk" || got.Operation != "Bulk" ||
+		len(got.Args) != bulk.Len() {
+		t.Fatalf("got invocation %+v", got)
+	}
+	if _, ok := got.Args[0].(*mongo.InsertOneModel); !ok {
+		t.Fatalf("got args %v", got.Args)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package video
+
+import (
+	"context"
+	"testing"
+
+	"test/biz/model/video"
+)
+
+// fakeT records the errors of the assertions.
+type fakeT struct {
+	errors int
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors++
+}
+
+func TestRepositoryMock(t *testing.T) {
+	mock := NewVideoRepositoryMock().ReturnFindById(&video.Video{Id: 1, Title: "stub"}, nil)
+	mock.CountAllFunc = func(ctx context.Context) (int, error) {
+		return 3, nil
+	}
+	var repo VideoRepository = mock
+	ctx := context.Background()
+
+	if v, err := repo.FindById(ctx, 1); err != nil || v.Title != "stub" {
+		t.Fatalf("got %+v, %v", v, err)
+	}
+	if n, err := repo.CountAll(ctx); err != nil || n != 3 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	if updated, err := repo.UpdateTitle(ctx, "title", 2); err != nil || updated {
+		t.Fatalf("got the zero values %v, %v", updated, err)
+	}
+
+	mock.AssertCalled(t, "FindById", int64(1))
+	mock.AssertCallCount(t, "UpdateTitle", 1)
+	mock.AssertNotCalled(t, "DeleteById")
+
+	ft := &fakeT{}
+	if mock.AssertCalled(ft, "FindById", int64(2)) || mock.AssertNotCalled(ft, "CountAll") || ft.errors != 2 {
+		t.Fatalf("the failed assertions report %d errors", ft.errors)
+	}
+}
@@ -0,0 +1,29 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package video
+
+import "testing"
+
+func TestRepositoryFromClient(t *testing.T) {
+	repo, ok := NewVideoRepositoryFromClient(newClient(t)).(*VideoRepositoryMongo)
+	if !ok {
+		t.Fatal("the repository is not a *VideoRepositoryMongo")
+	}
+	if repo.collection.Name() != "video" || repo.collection.Database().Name() != "media" {
+		t.Fatalf("got collection %s.%s", repo.collection.Database().Name(), repo.collection.Name())
+	}
+}
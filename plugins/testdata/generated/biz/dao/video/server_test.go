@@ -0,0 +1,186 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package video
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	opReply = 1
+	opQuery = 2004
+	opMsg   = 2013
+)
+
+// server is a standalone MongoDB server speaking just enough of the wire protocol for the writes,
+// it acknowledges every write command and records the names of the commands it received.
+type server struct {
+	listener net.Listener
+	mu       sync.Mutex
+	commands []string
+	// commitErrors are the replies of the next commitTransaction commands
+	commitErrors []bson.M
+}
+
+// newServer starts the server and returns the client connected to it.
+func newServer(t *testing.T) (*server, *mongo.Client) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &server{listener: listener}
+	go s.serve()
+	client, err := mongo.Connect(context.Background(),
+		options.Client().ApplyURI("mongodb://"+listener.Addr().String()).SetDirect(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = client.Disconnect(context.Background())
+		_ = listener.Close()
+	})
+	return s, client
+}
+
+// FailCommits fails the next commitTransaction commands with the replies.
+func (s *server) FailCommits(replies ...bson.M) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commitErrors = append(s.commitErrors, replies...)
+}
+
+// Commands returns the names of the write and commit commands received by the server.
+func (s *server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *server) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		header := make([]byte, 16)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		body := make([]byte, binary.LittleEndian.Uint32(header)-16)
+		if _, err := io.ReadFull(conn, body); err != nil {
+			return
+		}
+		requestID, opCode := binary.LittleEndian.Uint32(header[4:]), binary.LittleEndian.Uint32(header[12:])
+
+		var reply []byte
+		switch opCode {
+		case opQuery:
+			// the handshake: flags, the collection name, the skip and the return counts precede the query
+			query := body[4:]
+			for query[0] != 0 {
+				query = query[1:]
+			}
+			reply = make([]byte, 20)
+			binary.LittleEndian.PutUint32(reply[16:], 1)
+			reply = append(reply, s.reply(bson.Raw(query[9:]), 0)...)
+		case opMsg:
+			command, documents := readMsg(body)
+			reply = append([]byte{0, 0, 0, 0, 0}, s.reply(command, documents)...)
+		default:
+			return
+		}
+		code := uint32(opMsg)
+		if opCode == opQuery {
+			code = opReply
+		}
+		message := make([]byte, 16, 16+len(reply))
+		binary.LittleEndian.PutUint32(message, uint32(16+len(reply)))
+		binary.LittleEndian.PutUint32(message[8:], requestID)
+		binary.LittleEndian.PutUint32(message[12:], code)
+		if _, err := conn.Write(append(message, reply...)); err != nil {
+			return
+		}
+	}
+}
+
+// readMsg returns the command of the OP_MSG body and the number of the documents of its document sequences.
+func readMsg(body []byte) (command bson.Raw, documents int) {
+	sections := body[4:]
+	for len(sections) > 0 {
+		kind := sections[0]
+		size := int(binary.LittleEndian.Uint32(sections[1:]))
+		if kind == 0 {
+			command = bson.Raw(sections[1 : 1+size])
+		} else {
+			sequence := sections[5 : 1+size]
+			for sequence[0] != 0 {
+				sequence = sequence[1:]
+			}
+			for sequence = sequence[1:]; len(sequence) > 0; documents++ {
+				sequence = sequence[binary.LittleEndian.Uint32(sequence):]
+			}
+		}
+		sections = sections[1+size:]
+	}
+	return command, documents
+}
+
+// reply returns the reply document of the command, the writes match and modify all their documents.
+func (s *server) reply(command bson.Raw, documents int) []byte {
+	elements, _ := command.Elements()
+	name := elements[0].Key()
+	reply := bson.M{"ok": 1}
+	switch name {
+	case "hello", "isMaster", "ismaster":
+		reply = bson.M{
+			"ok": 1, "helloOk": true, "isWritablePrimary": true, "ismaster": true,
+			"minWireVersion": 0, "maxWireVersion": 17, "maxBsonObjectSize": 16 * 1024 * 1024,
+			"maxMessageSizeBytes": 48000000, "maxWriteBatchSize": 100000, "logicalSessionTimeoutMinutes": 30,
+		}
+	case "insert", "delete":
+		reply["n"] = documents
+	case "update":
+		reply["n"], reply["nModified"] = documents, documents
+	}
+	if name == "insert" || name == "update" || name == "delete" || name == "commitTransaction" {
+		s.mu.Lock()
+		s.commands = append(s.commands, name)
+		if name == "commitTransaction" && len(s.commitErrors) != 0 {
+			reply, s.commitErrors = s.commitErrors[0], s.commitErrors[1:]
+		}
+		s.mu.Unlock()
+	}
+	data, _ := bson.Marshal(reply)
+	return data
+}
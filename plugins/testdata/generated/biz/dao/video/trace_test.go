@@ -0,0 +1,124 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package video
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"test/biz/dao/mongox"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type span struct {
+	info                        *mongox.SpanInfo
+	matched, modified           int64
+	inserted, upserted, deleted int64
+	err                         error
+	ended                       bool
+}
+
+func (s *span) SetCounts(matched, modified int64) {
+	s.matched, s.modified = matched, modified
+}
+
+func (s *span) SetBulkCounts(inserted, upserted, deleted int64) {
+	s.inserted, s.upserted, s.deleted = inserted, upserted, deleted
+}
+
+func (s *span) RecordError(err error) {
+	s.err = err
+}
+
+func (s *span) End() {
+	s.ended = true
+}
+
+// tracer records the spans it starts.
+type tracer struct {
+	spans []*span
+}
+
+func (t *tracer) Start(ctx context.Context, info *mongox.SpanInfo) (context.Context, mongox.Span) {
+	s := &span{info: info}
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+func TestTracer(t *testing.T) {
+	errStop := errors.New("stop")
+	// stop records the counts on the span of the tracer, which is the outermost interceptor,
+	// and returns without running the method
+	stop := func(ctx context.Context, invocation *mongox.Invocation, next mongox.Handler) error {
+		mongox.RecordCounts(ctx, 2, 1)
+		return errStop
+	}
+	tr := &tracer{}
+	collection := newClient(t).Database("test").Collection("video")
+	repo := NewVideoRepository(collection, WithVideoInterceptors(stop), WithVideoTracer(tr))
+
+	if _, err := repo.UpdateTitle(mongox.WithTenant(context.Background(), "a"), "title", 1); !errors.Is(err, errStop) {
+		t.Fatalf("got error %v, want %v", err, errStop)
+	}
+	if len(tr.spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(tr.spans))
+	}
+	s := tr.spans[0]
+	if s.info.Collection != "video" || s.info.Method != "UpdateTitle" || s.info.Operation != "Update" {
+		t.Fatalf("got span info %+v", s.info)
+	}
+	if s.info.Filter != `{"$and":[{"$and":[{"_id":"?"},{"deleted_at":{"$in":["?","?"]}}]},{"$and":[{"tenant_id":"?"}]}]}` {
+		t.Fatalf("got filter shape %s", s.info.Filter)
+	}
+	if s.matched != 2 || s.modified != 1 || s.err != errStop || !s.ended {
+		t.Fatalf("got span %+v", s)
+	}
+}
+
+func TestBulkTracer(t *testing.T) {
+	srv, client := newServer(t)
+	tr := &tracer{}
+	repo := NewVideoRepository(client.Database("test").Collection("video"), WithVideoTracer(tr))
+
+	result, err := repo.Bulk().
+		InsertOne(newVideo(1, "hello")).
+		InsertOne(newVideo(2, "world")).
+		UpdateByIdEqual(1, bson.M{"$set": bson.M{"title": "other"}}).
+		HardDeleteOne(bson.M{"_id": 2}).
+		Exec(mongox.WithTenant(context.Background(), "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.InsertedCount != 2 || result.ModifiedCount != 1 || result.DeletedCount != 1 {
+		t.Fatalf("got result %+v", result)
+	}
+	if commands := srv.Commands(); len(commands) != 3 {
+		t.Fatalf("got commands %v", commands)
+	}
+	if len(tr.spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(tr.spans))
+	}
+	s := tr.spans[0]
+	if s.info.Collection != "video" || s.info.Method != "Bulk" || s.info.Operation != "Bulk" {
+		t.Fatalf("got span info %+v", s.info)
+	}
+	if s.inserted != 2 || s.matched != 1 || s.modified != 1 || s.deleted != 1 || s.err != nil || !s.ended {
+		t.Fatalf("got span %+v", s)
+	}
+}
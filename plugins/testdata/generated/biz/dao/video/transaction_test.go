@@ -0,0 +1,146 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package video
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"test/biz/dao/mongox"
+)
+
+// newClient returns a client of an unreachable server, the transactions which do not run any
+// operation do not connect to it.
+func newClient(t *testing.T) *mongo.Client {
+	t.Helper()
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = client.Disconnect(context.Background()) })
+	return client
+}
+
+func TestRunTransactionAttempts(t *testing.T) {
+	transient := mongo.CommandError{Code: 112, Message: "WriteConflict", Labels: []string{"TransientTransactionError"}}
+	other := errors.New("other")
+	tests := []struct {
+		name     string
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{name: "committed", errs: []error{nil}, attempts: 1},
+		{name: "not retried", errs: []error{other}, attempts: 1, err: other},
+		{name: "retried", errs: []error{transient, transient, nil}, attempts: 3},
+		{name: "exhausted", errs: []error{transient, transient, transient, transient}, attempts: mongox.DefaultMaxAttempts, err: ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := runTransaction(context.Background(), newClient(t), nil, nil, func(mongo.SessionContext) error {
+				attempts++
+				return tt.errs[attempts-1]
+			})
+			if attempts != tt.attempts {
+				t.Fatalf("got %d attempts, want %d", attempts, tt.attempts)
+			}
+			if (err == nil) != (tt.err == nil) || err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRunTransactionJoinsUnitOfWork(t *testing.T) {
+	client := newClient(t)
+	transient := mongo.CommandError{Code: 112, Message: "WriteConflict", Labels: []string{"TransientTransactionError"}}
+
+	runs, attempts := 0, 0
+	err := mongox.NewUnitOfWork(client).WithMaxAttempts(2).Run(context.Background(), func(ctx context.Context) error {
+		runs++
+		return runTransaction(ctx, client, nil, nil, func(sessionContext mongo.SessionContext) error {
+			attempts++
+			if !mongox.InTransaction(sessionContext) {
+				t.Fatal("the transaction does not join the unit of work")
+			}
+			return transient
+		})
+	})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("got error %v, want %v", err, ErrConflict)
+	}
+	// the joined transaction is retried by the unit of work only
+	if runs != 2 || attempts != 2 {
+		t.Fatalf("got %d runs and %d attempts, want 2 and 2", runs, attempts)
+	}
+}
+
+func TestRunTransactionCommitRetries(t *testing.T) {
+	transient := bson.M{
+		"ok": 0, "code": 112, "codeName": "WriteConflict", "errmsg": "WriteConflict",
+		"errorLabels": bson.A{"TransientTransactionError"},
+	}
+	unknown := bson.M{
+		"ok": 0, "code": 1, "codeName": "InternalError", "errmsg": "InternalError",
+		"errorLabels": bson.A{"UnknownTransactionCommitResult"},
+	}
+	tests := []struct {
+		name     string
+		replies  []bson.M
+		attempts int
+		commits  int
+		err      error
+	}{
+		{name: "commit retried", replies: []bson.M{unknown, unknown}, attempts: 1, commits: 3},
+		{name: "attempt retried", replies: []bson.M{transient}, attempts: 2, commits: 2},
+		{
+			name: "exhausted", replies: []bson.M{transient, transient, transient, transient},
+			attempts: mongox.DefaultMaxAttempts, commits: mongox.DefaultMaxAttempts, err: mongox.ErrMaxAttempts,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newServer(t)
+			srv.FailCommits(tt.replies...)
+			collection := client.Database("db").Collection("video")
+
+			attempts := 0
+			err := runTransaction(context.Background(), client, nil, nil, func(sessionContext mongo.SessionContext) error {
+				attempts++
+				_, err := collection.InsertOne(sessionContext, bson.M{"_id": attempts})
+				return err
+			})
+			if !errors.Is(err, tt.err) || tt.err == nil && err != nil {
+				t.Fatalf("got error %v, want %v", err, tt.err)
+			}
+			commits := 0
+			for _, command := range srv.Commands() {
+				if command == "commitTransaction" {
+					commits++
+				}
+			}
+			if attempts != tt.attempts || commits != tt.commits {
+				t.Fatalf("got %d attempts and %d commits, want %d and %d", attempts, commits, tt.attempts, tt.commits)
+			}
+		})
+	}
+}
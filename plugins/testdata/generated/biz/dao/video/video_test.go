@@ -0,0 +1,550 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package video
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"test/biz/dao/mongox"
+	"test/biz/model/video"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const duplicateKeyMessage = `E11000 duplicate key error collection: db.video index: tenant_id_1_title_1 ` +
+	`dup key: { tenant_id: "a", title: "hello" }`
+
+func TestTranslateError(t *testing.T) {
+	other := errors.New("other")
+	duplicate := &DuplicateKeyError{err: other}
+	tests := []struct {
+		name  string
+		err   error
+		check func(err error) bool
+	}{
+		{"nil", nil, func(err error) bool { return err == nil }},
+		{"other", other, func(err error) bool { return err == other }},
+		{"translated", duplicate, func(err error) bool { return err == duplicate }},
+		{
+			"no documents", mongo.ErrNoDocuments,
+			func(err error) bool { return errors.Is(err, ErrNotFound) && errors.Is(err, mongo.ErrNoDocuments) },
+		},
+		{
+			"duplicate key",
+			mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: duplicateKeyMessage}}},
+			func(err error) bool {
+				var duplicateKey *DuplicateKeyError
+				return errors.As(err, &duplicateKey) && errors.Is(err, ErrDuplicateKey) &&
+					reflect.DeepEqual(duplicateKey.DuplicateKey, DuplicateKey{
+						Index:  "tenant_id_1_title_1",
+						Fields: []string{"tenant_id", "title"},
+					})
+			},
+		},
+		{
+			"write conflict", mongo.CommandError{Code: 112, Message: "WriteConflict"},
+			func(err error) bool { return errors.Is(err, ErrConflict) && !errors.Is(err, ErrNotFound) },
+		},
+		{
+			"bulk write",
+			mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+				{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: duplicateKeyMessage}},
+				{WriteError: mongo.WriteError{Index: 3, Code: 121, Message: "Document failed validation"}},
+			}},
+			func(err error) bool {
+				var writeError *WriteError
+				return errors.As(err, &writeError) && errors.Is(err, ErrDuplicateKey) &&
+					reflect.DeepEqual(writeError.FailedIndexes(), []int{1, 3}) &&
+					writeError.Failures[0].DuplicateKey.Index == "tenant_id_1_title_1" &&
+					writeError.Failures[1].DuplicateKey == nil
+			},
+		},
+		{
+			"bulk write without duplicate key",
+			mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+				{WriteError: mongo.WriteError{Index: 0, Code: 121, Message: "Document failed validation"}},
+			}},
+			func(err error) bool { return !errors.Is(err, ErrDuplicateKey) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := translateError(tt.err); !tt.check(got) {
+				t.Fatalf("unexpected error %#v", got)
+			}
+		})
+	}
+}
+
+func TestParseDuplicateKey(t *testing.T) {
+	tests := []struct {
+		message string
+		want    DuplicateKey
+	}{
+		{duplicateKeyMessage, DuplicateKey{Index: "tenant_id_1_title_1", Fields: []string{"tenant_id", "title"}}},
+		{`E11000 duplicate key error collection: db.video index: _id_ dup key: { _id: 1 }`, DuplicateKey{
+			Index:  "_id_",
+			Fields: []string{"_id"},
+		}},
+		{
+			`E11000 duplicate key error collection: db.video index: author.name_1_title_1 ` +
+				`dup key: { author.name: "a, b: c", title: "say \"x, y: z\"" }`,
+			DuplicateKey{Index: "author.name_1_title_1", Fields: []string{"author.name", "title"}},
+		},
+		{"E11000 duplicate key error", DuplicateKey{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := parseDuplicateKey(tt.message); !reflect.DeepEqual(*got, tt.want) {
+				t.Fatalf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWriteError(t *testing.T) {
+	other := errors.New("other")
+	if err := newWriteError(other); err != other {
+		t.Fatalf("got %v, want the error as it is", err)
+	}
+	if err := newWriteError(mongo.BulkWriteException{}); !reflect.DeepEqual(err, mongo.BulkWriteException{}) {
+		t.Fatalf("got %v, want the exception without write errors as it is", err)
+	}
+
+	// the unordered insert of 5 documents failed at the indexes 1 and 3, the others are written
+	exception := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: duplicateKeyMessage}},
+		{WriteError: mongo.WriteError{Index: 3, Code: 121, Message: "Document failed validation"}},
+	}}
+	err := newWriteError(fmt.Errorf("insert: %w", exception))
+	var writeError *WriteError
+	if !errors.As(err, &writeError) {
+		t.Fatalf("got %#v, want *WriteError", err)
+	}
+	want := []WriteFailure{
+		{
+			Index: 1, Code: 11000, Message: duplicateKeyMessage,
+			DuplicateKey: &DuplicateKey{Index: "tenant_id_1_title_1", Fields: []string{"tenant_id", "title"}},
+		},
+		{Index: 3, Code: 121, Message: "Document failed validation"},
+	}
+	if !reflect.DeepEqual(writeError.Failures, want) {
+		t.Fatalf("got %+v, want %+v", writeError.Failures, want)
+	}
+	if !reflect.DeepEqual(writeError.FailedIndexes(), []int{1, 3}) {
+		t.Fatalf("got failed indexes %v", writeError.FailedIndexes())
+	}
+	if !errors.Is(err, ErrDuplicateKey) || errors.Is(err, ErrNotFound) {
+		t.Fatalf("unexpected sentinel of %v", err)
+	}
+	var unwrapped mongo.BulkWriteException
+	if !errors.As(err, &unwrapped) || len(unwrapped.WriteErrors) != 2 {
+		t.Fatalf("got %v, want the exception unwrapped", err)
+	}
+	wantMessage := "2 documents failed to be written: index 1: " + duplicateKeyMessage +
+		"; index 3: Document failed validation"
+	if err.Error() != wantMessage {
+		t.Fatalf("got message %q, want %q", err.Error(), wantMessage)
+	}
+}
+
+// chunkFailure returns the failure of the chunk which fails the documents of the indexes in the chunk.
+func chunkFailure(indexes ...int) error {
+	exception := mongo.BulkWriteException{}
+	for _, index := range indexes {
+		exception.WriteErrors = append(exception.WriteErrors, mongo.BulkWriteError{
+			WriteError: mongo.WriteError{Index: index, Code: 11000, Message: duplicateKeyMessage},
+		})
+	}
+	return exception
+}
+
+func TestWriteChunks(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		ordered  bool
+		failures map[int][]int
+		chunks   [][2]int
+		indexes  []int
+	}{
+		{name: "empty", n: 0, ordered: true},
+		{name: "chunks", n: 5, ordered: true, chunks: [][2]int{{0, 2}, {2, 4}, {4, 5}}},
+		{
+			name: "ordered stops", n: 5, ordered: true, failures: map[int][]int{2: {1}},
+			chunks: [][2]int{{0, 2}, {2, 4}}, indexes: []int{3},
+		},
+		{
+			name: "unordered merges", n: 5, ordered: false, failures: map[int][]int{0: {0}, 2: {0, 1}, 4: {0}},
+			chunks: [][2]int{{0, 2}, {2, 4}, {4, 5}}, indexes: []int{0, 2, 3, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chunks [][2]int
+			var progress [][2]int
+			err := writeChunks(tt.n, tt.ordered, func(done, total int) {
+				progress = append(progress, [2]int{done, total})
+			}, func(start, end int) error {
+				chunks = append(chunks, [2]int{start, end})
+				if indexes, ok := tt.failures[start]; ok {
+					return chunkFailure(indexes...)
+				}
+				return nil
+			})
+			if !reflect.DeepEqual(chunks, tt.chunks) {
+				t.Fatalf("got chunks %v, want %v", chunks, tt.chunks)
+			}
+			if tt.indexes == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(progress) != len(tt.chunks) {
+					t.Fatalf("got progress %v", progress)
+				}
+				return
+			}
+			var writeError *WriteError
+			if !errors.As(err, &writeError) || !reflect.DeepEqual(writeError.FailedIndexes(), tt.indexes) {
+				t.Fatalf("got error %v, want the failures of %v", err, tt.indexes)
+			}
+		})
+	}
+}
+
+func TestWriteChunksError(t *testing.T) {
+	err := writeChunks(3, false, nil, func(start, end int) error {
+		return mongo.CommandError{Code: 112, Message: "WriteConflict"}
+	})
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("got error %v", err)
+	}
+}
+
+func newVideo(id int64, title string) *video.Video {
+	return &video.Video{Id: id, Title: title, Status: 1}
+}
+
+func TestTenantScope(t *testing.T) {
+	repo := NewVideoRepositoryMemory()
+	a := mongox.WithTenant(context.Background(), "a")
+	b := mongox.WithTenant(context.Background(), "b")
+	if _, err := repo.InsertOne(a, newVideo(1, "hello")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.InsertOne(b, newVideo(2, "hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := repo.FindById(a, 1); err != nil || v.TenantId != "a" {
+		t.Fatalf("got %+v, %v", v, err)
+	}
+	if _, err := repo.FindById(b, 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got error %v", err)
+	}
+	if _, err := repo.FindById(context.Background(), 1); !errors.Is(err, mongox.ErrNoTenant) {
+		t.Fatalf("got error %v", err)
+	}
+	if v, err := repo.FindById(mongox.WithoutTenant(context.Background()), 2); err != nil || v.TenantId != "b" {
+		t.Fatalf("got %+v, %v", v, err)
+	}
+
+	if updated, err := repo.UpdateTitle(b, "other", 1); err != nil || updated {
+		t.Fatalf("got %v, %v", updated, err)
+	}
+	if deleted, err := repo.HardDeleteById(b, 1); err != nil || deleted {
+		t.Fatalf("got %v, %v", deleted, err)
+	}
+	if n, err := repo.CountAll(a); err != nil || n != 1 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	if n, err := repo.CountAll(mongox.WithoutTenant(context.Background())); err != nil || n != 2 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	repo := NewVideoRepositoryMemory()
+	ctx := mongox.WithTenant(context.Background(), "a")
+	if _, err := repo.InsertMany(ctx, []*video.Video{newVideo(1, "one"), newVideo(2, "two")}); err != nil {
+		t.Fatal(err)
+	}
+
+	if deleted, err := repo.DeleteById(ctx, 1); err != nil || !deleted {
+		t.Fatalf("got %v, %v", deleted, err)
+	}
+	if deleted, err := repo.DeleteById(ctx, 1); err != nil || deleted {
+		t.Fatalf("deleted twice: %v, %v", deleted, err)
+	}
+	if _, err := repo.FindById(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got error %v", err)
+	}
+	if v, err := repo.FindWithDeletedById(ctx, 1); err != nil || v.DeletedAt == 0 {
+		t.Fatalf("got %+v, %v", v, err)
+	}
+	if vs, err := repo.FindByStatus(ctx, 1); err != nil || len(vs) != 1 || vs[0].Id != 2 {
+		t.Fatalf("got %+v, %v", vs, err)
+	}
+	if n, err := repo.CountAll(ctx); err != nil || n != 1 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	if updated, err := repo.UpdateTitle(ctx, "other", 1); err != nil || updated {
+		t.Fatalf("updated the deleted video: %v, %v", updated, err)
+	}
+
+	if deleted, err := repo.HardDeleteById(ctx, 1); err != nil || !deleted {
+		t.Fatalf("got %v, %v", deleted, err)
+	}
+	if _, err := repo.FindWithDeletedById(ctx, 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got error %v", err)
+	}
+}
+
+func TestInsertManyChunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		insert  func(repo VideoRepository, ctx context.Context, vs []*video.Video) ([]interface{}, error)
+		indexes []int
+		count   int
+	}{
+		{
+			name: "ordered",
+			insert: func(repo VideoRepository, ctx context.Context, vs []*video.Video) ([]interface{}, error) {
+				return repo.InsertMany(ctx, vs)
+			},
+			indexes: []int{3},
+			count:   3,
+		},
+		{
+			name: "unordered",
+			insert: func(repo VideoRepository, ctx context.Context, vs []*video.Video) ([]interface{}, error) {
+				return repo.InsertUnordered(ctx, vs)
+			},
+			indexes: []int{3, 4},
+			count:   3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewVideoRepositoryMemory()
+			ctx := mongox.WithTenant(context.Background(), "a")
+			if err := repo.EnsureIndexes(ctx); err != nil {
+				t.Fatal(err)
+			}
+			vs := []*video.Video{
+				newVideo(1, "one"), newVideo(2, "two"), newVideo(3, "three"), newVideo(4, "one"), newVideo(5, "two"),
+			}
+			_, err := tt.insert(repo, ctx, vs)
+			var writeError *WriteError
+			if !errors.As(err, &writeError) || !reflect.DeepEqual(writeError.FailedIndexes(), tt.indexes) {
+				t.Fatalf("got error %v, want the failures of %v", err, tt.indexes)
+			}
+			if !errors.Is(err, ErrDuplicateKey) {
+				t.Fatalf("got error %v", err)
+			}
+			if n, err := repo.CountAll(ctx); err != nil || n != tt.count {
+				t.Fatalf("got %d, %v", n, err)
+			}
+		})
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	repo := NewVideoRepositoryMemory()
+	ctx := mongox.WithTenant(context.Background(), "a")
+	v := newVideo(1, "one")
+	if _, err := repo.InsertOne(ctx, v); err != nil {
+		t.Fatal(err)
+	}
+	if v.CreatedAt == 0 || v.UpdatedAt != v.CreatedAt {
+		t.Fatalf("got %+v", v)
+	}
+
+	update := newVideo(1, "two")
+	if updated, err := repo.Update(ctx, update, 1); err != nil || !updated {
+		t.Fatalf("got %v, %v", updated, err)
+	}
+	got, err := repo.FindById(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "two" || got.CreatedAt != v.CreatedAt || got.UpdatedAt < v.UpdatedAt {
+		t.Fatalf("got %+v, inserted %+v", got, v)
+	}
+}
+
+func TestValidateWrites(t *testing.T) {
+	repo := NewVideoRepositoryMemory()
+	ctx := mongox.WithTenant(context.Background(), "a")
+	var validationError *video.ValidationError
+	if _, err := repo.InsertOne(ctx, newVideo(1, "")); !errors.As(err, &validationError) {
+		t.Fatalf("got error %v", err)
+	}
+	if _, err := repo.InsertMany(ctx, []*video.Video{newVideo(1, "one"), newVideo(2, "Two")}); !errors.As(err, &validationError) {
+		t.Fatalf("got error %v", err)
+	}
+	if n, err := repo.CountAll(ctx); err != nil || n != 0 {
+		t.Fatalf("the invalid documents are written: %d, %v", n, err)
+	}
+
+	if _, err := repo.InsertOne(ctx, newVideo(1, "one")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.UpdateTitle(ctx, "One", 1); !errors.As(err, &validationError) ||
+		validationError.Fields[0].Field != "Title" || validationError.Fields[0].Rule != "pattern" {
+		t.Fatalf("got error %v", err)
+	}
+	if ok, err := repo.UpdateTitle(ctx, "uno", 1); err != nil || !ok {
+		t.Fatalf("got %v, %v", ok, err)
+	}
+	if v, err := repo.FindById(ctx, 1); err != nil || v.Title != "uno" {
+		t.Fatalf("got %+v, %v", v, err)
+	}
+
+	b := &VideoBulk{ordered: true}
+	b.UpdateByIdEqual(1, bson.M{"$set": bson.D{{Key: "title", Value: "dos"}}})
+	if b.err != nil || len(b.models) != 1 {
+		t.Fatalf("got %d models, %v", len(b.models), b.err)
+	}
+	b.UpdateByIdEqual(1, bson.M{"$setOnInsert": bson.M{"title": "Dos"}}).UpdateByIdEqual(2, bson.M{"$set": bson.M{"title": "tres"}})
+	if !errors.As(b.err, &validationError) || validationError.Fields[0].Field != "Title" || len(b.models) != 1 {
+		t.Fatalf("got %d models, %v", len(b.models), b.err)
+	}
+}
+
+func TestMemoryBulk(t *testing.T) {
+	repo := NewVideoRepositoryMemory()
+	ctx := mongox.WithTenant(context.Background(), "a")
+	if _, err := repo.InsertOne(ctx, newVideo(1, "one")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := repo.Bulk().InsertOne(newVideo(2, "two")).UpdateByIdEqual(1, bson.M{"$set": bson.M{"title": "uno"}}).Exec(ctx)
+	if err != nil || result.InsertedCount != 1 || result.MatchedCount != 1 || result.ModifiedCount != 1 {
+		t.Fatalf("got %+v, %v", result, err)
+	}
+	if v, err := repo.FindById(ctx, 1); err != nil || v.Title != "uno" {
+		t.Fatalf("got %+v, %v", v, err)
+	}
+
+	// the failed write rolls back the insertion of the third video
+	var writeError *WriteError
+	_, err = repo.Bulk().InsertOne(newVideo(3, "three")).InsertOne(newVideo(2, "dos")).Exec(ctx)
+	if !errors.As(err, &writeError) || !reflect.DeepEqual(writeError.FailedIndexes(), []int{1}) {
+		t.Fatalf("got error %v", err)
+	}
+	if n, err := repo.CountAll(ctx); err != nil || n != 2 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+}
+
+func TestMemoryTransaction(t *testing.T) {
+	repo := NewVideoRepositoryMemory()
+	ctx := mongox.WithTenant(context.Background(), "a")
+	if err := repo.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.InsertOne(ctx, newVideo(1, "one")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.InsertAndRename(ctx, nil, newVideo(2, "two"), "uno", 1); err != nil {
+		t.Fatal(err)
+	}
+	// the renaming conflicts with the unique title of the second video and rolls back the insertion
+	var duplicateKey *DuplicateKeyError
+	if err := repo.InsertAndRename(ctx, nil, newVideo(3, "three"), "two", 1); !errors.As(err, &duplicateKey) {
+		t.Fatalf("got error %v", err)
+	}
+	if n, err := repo.CountAll(ctx); err != nil || n != 2 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+
+	stop := errors.New("stop")
+	var abort *TransactionAbortError
+	err := repo.Rename(ctx, nil, 1, func(*video.Video) error { return stop }, "dos")
+	if !errors.As(err, &abort) || !errors.Is(err, stop) {
+		t.Fatalf("got error %v", err)
+	}
+	if v, err := repo.FindById(ctx, 1); err != nil || v.Title != "uno" {
+		t.Fatalf("got %+v, %v", v, err)
+	}
+}
+
+func TestBulkBuilder(t *testing.T) {
+	ctx := mongox.WithTenant(context.Background(), "a")
+	b := &VideoBulk{ordered: true}
+	b.UpdateByIdEqual(1, bson.M{"$set": bson.D{{Key: "title", Value: "two"}}})
+	set := b.models[0].(*mongo.UpdateOneModel).Update.(bson.M)["$set"].(bson.M)
+	if set["title"] != "two" || set["updated_at"] == nil {
+		t.Fatalf("got $set %v", set)
+	}
+	b.UpdateByIdEqual(2, bson.M{"$set": 1})
+	if b.err == nil {
+		t.Fatal("the invalid $set is accepted")
+	}
+
+	filter := b.models[0].(*mongo.UpdateOneModel).Filter
+	for i := 0; i < 2; i++ {
+		scoped := b.scopeTenant(ctx, false)[0].(*mongo.UpdateOneModel).Filter
+		if !reflect.DeepEqual(scoped, mongox.TenantFilter(ctx, "tenant_id", filter)) {
+			t.Fatalf("got filter %v of Exec %d", scoped, i)
+		}
+	}
+	if got := b.models[0].(*mongo.UpdateOneModel).Filter; !reflect.DeepEqual(got, filter) {
+		t.Fatalf("the added filter is changed to %v", got)
+	}
+	// the collection of the tenant holds the documents of the tenant only
+	if routed := b.scopeTenant(ctx, true)[0].(*mongo.UpdateOneModel).Filter; !reflect.DeepEqual(routed, filter) {
+		t.Fatalf("got routed filter %v", routed)
+	}
+}
+
+func TestNewVideoRepositoryFromDB(t *testing.T) {
+	// the client connects lazily, no server is needed to create the repositories
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+	db := client.Database("db")
+
+	interceptor := func(ctx context.Context, invocation *mongox.Invocation, next mongox.Handler) error {
+		return next(ctx)
+	}
+	repo, ok := NewVideoRepositoryFromDB(db, WithVideoInterceptors(interceptor),
+		WithVideoCollectionOptions(options.Collection().SetReadPreference(readpref.Secondary()))).(*VideoRepositoryMongo)
+	if !ok {
+		t.Fatal("the repository is not a VideoRepositoryMongo")
+	}
+	if repo.collection.Name() != CollectionName || repo.collection.Database().Name() != "db" ||
+		len(repo.interceptors) != 1 {
+		t.Fatalf("got collection %s.%s and %d interceptors", repo.collection.Database().Name(),
+			repo.collection.Name(), len(repo.interceptors))
+	}
+
+	if _, ok := NewVideoRepositoryFromDB(db, WithVideoCache(mongox.NewLRUCache(1))).(*CachedVideoRepository); !ok {
+		t.Fatal("the repository with the cache is not a CachedVideoRepository")
+	}
+}
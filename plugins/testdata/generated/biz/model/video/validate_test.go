@@ -0,0 +1,95 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package video
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	score := func(score int32) *int32 { return &score }
+	tests := []struct {
+		name  string
+		video Video
+		rules []string
+	}{
+		{"valid", Video{Title: "hello", Status: 1}, nil},
+		{"valid optional", Video{Title: "hello", Status: 2, Score: score(100), Tags: []string{"a", "b", "c"}}, nil},
+		{"required", Video{Status: 1}, []string{"Title required", "Title pattern"}},
+		{"max len", Video{Title: "abcdefghijklmnopq", Status: 1}, []string{"Title max_len"}},
+		{"pattern", Video{Title: "Hello", Status: 1}, []string{"Title pattern"}},
+		{"enum", Video{Title: "hello", Status: 3}, []string{"Status enum"}},
+		{"unset enum", Video{Title: "hello"}, nil},
+		{"min", Video{Title: "hello", Status: 1, Score: score(-1)}, []string{"Score min"}},
+		{"max", Video{Title: "hello", Status: 1, Score: score(101)}, []string{"Score max"}},
+		{"list max len", Video{Title: "hello", Status: 1, Tags: []string{"a", "b", "c", "d"}}, []string{"Tags max_len"}},
+		{
+			"every field",
+			Video{Title: "HELLO", Status: 3, Score: score(101), Tags: []string{"a", "b", "c", "d"}},
+			[]string{"Title pattern", "Status enum", "Score max", "Tags max_len"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.video.Validate(), tt.rules)
+		})
+	}
+}
+
+func TestValidateField(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func() error
+		rules    []string
+	}{
+		{"valid", (&Video{Title: "hello"}).ValidateTitle, nil},
+		{"other fields are not validated", (&Video{Title: "hello", Status: 3}).ValidateTitle, nil},
+		{"required", (&Video{}).ValidateTitle, []string{"Title required", "Title pattern"}},
+		{"pattern", (&Video{Title: "Hello"}).ValidateTitle, []string{"Title pattern"}},
+		{"unset enum", (&Video{}).ValidateStatus, nil},
+		{"enum", (&Video{Status: 3}).ValidateStatus, []string{"Status enum"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidationError(t, tt.validate(), tt.rules)
+		})
+	}
+}
+
+// checkValidationError checks the rules of the fields of the validation error, the error is nil if rules is nil.
+func checkValidationError(t *testing.T, err error, rules []string) {
+	t.Helper()
+	if rules == nil {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) {
+		t.Fatalf("got error %v", err)
+	}
+	got := make([]string, 0, len(validationError.Fields))
+	for _, field := range validationError.Fields {
+		got = append(got, field.Field+" "+field.Rule)
+	}
+	if !reflect.DeepEqual(got, rules) {
+		t.Fatalf("got %v, want %v", got, rules)
+	}
+}
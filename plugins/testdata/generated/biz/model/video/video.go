@@ -0,0 +1,31 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package video is the model of video.thrift written as the thriftgo generates it,
+// without the thrift codec which the repositories do not use.
+package video
+
+type Video struct {
+	Id        int64    `thrift:"Id,1" bson:"_id"`
+	Title     string   `thrift:"Title,2" bson:"title"`
+	Status    int32    `thrift:"Status,3" bson:"status"`
+	Score     *int32   `thrift:"Score,4,optional" bson:"score,omitempty"`
+	Tags      []string `thrift:"Tags,5" bson:"tags"`
+	TenantId  string   `thrift:"TenantId,6" bson:"tenant_id"`
+	DeletedAt int64    `thrift:"DeletedAt,7" bson:"deleted_at,omitempty"`
+	CreatedAt int64    `thrift:"CreatedAt,8" bson:"created_at,omitempty"`
+	UpdatedAt int64    `thrift:"UpdatedAt,9" bson:"updated_at"`
+}
@@ -0,0 +1,9 @@
+module test
+
+go 1.18
+
+require (
+	go.mongodb.org/mongo-driver v1.17.1
+	go.opentelemetry.io/otel v1.29.0
+	go.opentelemetry.io/otel/trace v1.29.0
+)
@@ -0,0 +1,38 @@
+namespace go video
+
+struct Video {
+    1: i64 Id (go.tag="bson:\"_id\"")
+    2: string Title (go.tag="bson:\"title\"", mongo.required="true", mongo.max_len="16", mongo.pattern="^[a-z ]+$")
+    3: i32 Status (go.tag="bson:\"status\"", mongo.enum="1, 2")
+    4: optional i32 Score (go.tag="bson:\"score,omitempty\"", mongo.min="0", mongo.max="100")
+    5: list<string> Tags (go.tag="bson:\"tags\"", mongo.max_len="3")
+    6: string TenantId (go.tag="bson:\"tenant_id\"")
+    7: i64 DeletedAt (go.tag="bson:\"deleted_at,omitempty\"")
+    8: i64 CreatedAt (go.tag="bson:\"created_at,omitempty\"")
+    9: i64 UpdatedAt (go.tag="bson:\"updated_at\"")
+}
+(
+    mongo.database = "media"
+    mongo.tenant = "tenant_id"
+    mongo.soft_delete = "deleted_at"
+    mongo.created_at = "created_at"
+    mongo.updated_at = "updated_at"
+    mongo.chunk_size = "2"
+    mongo.index = "TenantId, Title; unique"
+    mongo.cache = "FindById: ttl=5m"
+    mongo.InsertOne = "InsertOne(ctx context.Context, v *video.Video) (interface{}, error)"
+    mongo.InsertMany = "InsertMany(ctx context.Context, vs []*video.Video) ([]interface{}, error)"
+    mongo.InsertManyUnordered = "InsertUnordered(ctx context.Context, vs []*video.Video) ([]interface{}, error)"
+    mongo.FindByIdEqual = "FindById(ctx context.Context, id int64) (*video.Video, error)"
+    mongo.FindWithDeletedByIdEqual = "FindWithDeletedById(ctx context.Context, id int64) (*video.Video, error)"
+    mongo.FindOrderbyIdByStatusEqual = "FindByStatus(ctx context.Context, status int32) ([]*video.Video, error)"
+    mongo.UpdateTitleByIdEqual = "UpdateTitle(ctx context.Context, title string, id int64) (bool, error)"
+    mongo.UpdateByIdEqual = "Update(ctx context.Context, v *video.Video, id int64) (bool, error)"
+    mongo.WatchInsertUpdateByStatusEqual = "WatchStatus(ctx context.Context, status int32, opts *options.ChangeStreamOptions) (*VideoChangeStream, error)"
+    mongo.DeleteByIdEqual = "DeleteById(ctx context.Context, id int64) (bool, error)"
+    mongo.HardDeleteByIdEqual = "HardDeleteById(ctx context.Context, id int64) (bool, error)"
+    mongo.TransactionInsertOneUpdateOneTitleByIdEqual = "InsertAndRename(ctx context.Context, client *mongo.Client, v *video.Video, title string, id int64) error"
+    mongo.TransactionFindOneByIdEqualUpdateOneTitleByIdEqualFound = "Rename(ctx context.Context, client *mongo.Client, id int64, guard func(*video.Video) error, title string) error"
+    mongo.transaction_options = "InsertAndRename: read_concern=snapshot, write_concern=majority, max_commit_time=2s"
+    mongo.CountAll = "CountAll(ctx context.Context) (int, error)"
+)
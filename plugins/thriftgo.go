@@ -20,6 +20,7 @@ import (
 	"go/format"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/hertz-contrib/thrift-gen-mongo/utils"
 
@@ -28,14 +29,20 @@ import (
 	"github.com/hertz-contrib/thrift-gen-mongo/code"
 	"github.com/hertz-contrib/thrift-gen-mongo/codegen"
 	"github.com/hertz-contrib/thrift-gen-mongo/extract"
+	"github.com/hertz-contrib/thrift-gen-mongo/parse"
 	"github.com/hertz-contrib/thrift-gen-mongo/template"
 )
 
 func buildResponse(args *args.Arguments,
 	structs []*extract.IdlExtractStruct,
 	methodRenders [][]*template.MethodRender,
+	memoryRenders [][]*template.MethodRender,
 	info *extract.ThriftMeta,
 ) (result []*plugin.Generated, err error) {
+	mongoxImportPath := extract.GetMongoxImportPath(args.GoMod, args.OutDir, args.DaoDir)
+	modelImportPaths := func(paths ...string) []string {
+		return append(info.ImportPaths[:len(info.ImportPaths):len(info.ImportPaths)], paths...)
+	}
 	for index, st := range structs {
 		// get base render
 		baseRender := getBaseRender(st, args.Version)
@@ -52,7 +59,7 @@ func buildResponse(args *args.Arguments,
 			if err != nil {
 				return nil, err
 			}
-			formattedCode, err = extract.AddMongoModelImports(formattedCode, info.ImportPaths)
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, modelImportPaths(mongoxImportPath))
 			if err != nil {
 				return nil, err
 			}
@@ -80,7 +87,7 @@ func buildResponse(args *args.Arguments,
 			})
 		} else {
 			// build new mongo file
-			formattedCode, err := getNewMongoCode(methodRenders[index], st, baseRender)
+			formattedCode, err := getNewMongoCode(methodRenders[index], st, baseRender, true, args.Tracing)
 			if err != nil {
 				return nil, err
 			}
@@ -88,7 +95,7 @@ func buildResponse(args *args.Arguments,
 			if err != nil {
 				return nil, err
 			}
-			formattedCode, err = extract.AddMongoModelImports(formattedCode, info.ImportPaths)
+			formattedCode, err = extract.AddMongoModelImports(formattedCode, modelImportPaths(mongoxImportPath))
 			if err != nil {
 				return nil, err
 			}
@@ -115,9 +122,17 @@ func buildResponse(args *args.Arguments,
 				Name:    &fileIfName,
 			})
 		}
+
+		if result, err = buildStructFiles(result, args, st, memoryRenders[index], modelImportPaths, mongoxImportPath); err != nil {
+			return nil, err
+		}
 	}
 
-	return
+	if result, err = buildMongoxFiles(result, args, structs, mongoxImportPath); err != nil {
+		return nil, err
+	}
+
+	return buildValidateFiles(result, args, structs)
 }
 
 func getBaseRender(st *extract.IdlExtractStruct, version string) *template.BaseRender {
@@ -156,21 +171,7 @@ func getUpdateIfCode(st *extract.IdlExtractStruct, baseRender *template.BaseRend
 	}
 	tplIf.Renders = append(tplIf.Renders, baseRender)
 
-	methods := make(code.InterfaceMethods, 0, 10)
-	for _, preMethod := range st.PreIfMethods {
-		methods = append(methods, code.InterfaceMethod{
-			Name:    preMethod.Name,
-			Params:  preMethod.Params,
-			Returns: preMethod.Returns,
-		})
-	}
-	for _, rawMethod := range st.InterfaceInfo.Methods {
-		methods = append(methods, code.InterfaceMethod{
-			Name:    rawMethod.Name,
-			Params:  rawMethod.Params,
-			Returns: rawMethod.Returns,
-		})
-	}
+	methods := getRepositoryMethods(st)
 
 	ifRender := &template.InterfaceRender{
 		Name:    st.Name + "Repository",
@@ -190,14 +191,24 @@ func getUpdateIfCode(st *extract.IdlExtractStruct, baseRender *template.BaseRend
 	return string(formattedCode), nil
 }
 
-func getNewMongoCode(methodRenders []*template.MethodRender, st *extract.IdlExtractStruct, baseRender *template.BaseRender) (string, error) {
+func getNewMongoCode(methodRenders []*template.MethodRender, st *extract.IdlExtractStruct,
+	baseRender *template.BaseRender, intercepted, tracing bool,
+) (string, error) {
 	tplMongo := &template.Template{
 		Renders: []template.Render{},
 	}
 
 	tplMongo.Renders = append(tplMongo.Renders, baseRender)
-	tplMongo.Renders = append(tplMongo.Renders, codegen.GetFuncRender(st))
-	tplMongo.Renders = append(tplMongo.Renders, codegen.GetStructRender(st))
+	tplMongo.Renders = append(tplMongo.Renders, codegen.GetFuncRender(st, intercepted))
+	if intercepted {
+		tplMongo.Renders = append(tplMongo.Renders, &template.OptionRender{
+			StructName:     st.Name,
+			Tracing:        tracing,
+			TenantResolver: codegen.TenantRouted(st),
+			Cached:         len(st.CacheOptions) != 0,
+		})
+	}
+	tplMongo.Renders = append(tplMongo.Renders, codegen.GetStructRender(st, intercepted))
 	for _, methodRender := range methodRenders {
 		tplMongo.Renders = append(tplMongo.Renders, methodRender)
 	}
@@ -214,20 +225,34 @@ func getNewMongoCode(methodRenders []*template.MethodRender, st *extract.IdlExtr
 	return string(formattedCode), nil
 }
 
+// getRepositoryMethods returns the methods of the repository interface, the methods which existed before
+// the update come first.
+func getRepositoryMethods(st *extract.IdlExtractStruct) code.InterfaceMethods {
+	methods := make(code.InterfaceMethods, 0, len(st.PreIfMethods)+len(st.InterfaceInfo.Methods)+3)
+	for _, method := range append(st.PreIfMethods[:len(st.PreIfMethods):len(st.PreIfMethods)], st.InterfaceInfo.Methods...) {
+		methods = append(methods, code.InterfaceMethod{
+			Name:    method.Name,
+			Params:  method.Params,
+			Returns: method.Returns,
+		})
+	}
+	if len(st.Indexes) != 0 {
+		methods = append(methods, codegen.EnsureIndexesMethod)
+	}
+	if st.ShardKey != nil {
+		methods = append(methods, codegen.ShardCollectionMethod)
+	}
+	return append(methods, codegen.BulkMethod(st))
+}
+
 func getNewIfCode(st *extract.IdlExtractStruct, baseRender *template.BaseRender) (string, error) {
 	tplIf := &template.Template{
 		Renders: []template.Render{},
 	}
 	tplIf.Renders = append(tplIf.Renders, baseRender)
 
-	methods := make(code.InterfaceMethods, 0, 10)
-	for _, rawMethod := range st.InterfaceInfo.Methods {
-		methods = append(methods, code.InterfaceMethod{
-			Name:    rawMethod.Name,
-			Params:  rawMethod.Params,
-			Returns: rawMethod.Returns,
-		})
-	}
+	methods := getRepositoryMethods(st)
+
 	ifRender := &template.InterfaceRender{
 		Name:    st.Name + "Repository",
 		Methods: methods,
@@ -246,6 +271,24 @@ func getNewIfCode(st *extract.IdlExtractStruct, baseRender *template.BaseRender)
 	return string(formattedCode), nil
 }
 
+func hasTransaction(st *extract.IdlExtractStruct) bool {
+	for _, method := range st.InterfaceInfo.Methods {
+		if strings.HasPrefix(method.ParsedTokens, parse.Transaction) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasWatch(st *extract.IdlExtractStruct) bool {
+	for _, method := range st.InterfaceInfo.Methods {
+		if strings.HasPrefix(method.ParsedTokens, parse.Watch) {
+			return true
+		}
+	}
+	return false
+}
+
 func generateBaseMongoFile(daoDir string, importPaths []string, methodRenders []*template.MethodRender, version string) (err error) {
 	st := &extract.IdlExtractStruct{
 		Name:          "Base",
@@ -269,7 +312,7 @@ func generateBaseMongoFile(daoDir string, importPaths []string, methodRenders []
 	}
 
 	// build new mongo file
-	formattedCode, err := getNewMongoCode(methodRenders, st, baseRender)
+	formattedCode, err := getNewMongoCode(methodRenders, st, baseRender, false, false)
 	if err != nil {
 		return err
 	}
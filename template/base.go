@@ -25,10 +25,11 @@ import (
 var baseTemplate = `// Code generated by cwgo ({{.Version}}). DO NOT EDIT. err := HandleRequest(req)
 
 package {{.PackageName}}
-
+{{if .Imports}}
 import (
 {{.GetImports}}
-)` + "\n"
+)
+{{end}}`
 
 type BaseRender struct {
 	Version     string            // cwgo version
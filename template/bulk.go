@@ -0,0 +1,586 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var bulkBuilderTemplate = `
+// {{.StructName}}Bulk builds the bulk write of the {{.StructName}} at runtime,
+// the operations are written in the order they are added.
+type {{.StructName}}Bulk struct {
+	collection mongox.BulkWriter
+	models     []mongo.WriteModel
+	ordered    bool
+	err        error
+{{- if .ChunkSize}}
+	progress   func(done, total int)
+{{- end}}
+{{- if eq .IDStrategy "sequence"}}
+	inserted   []{{.ModelType}}
+{{- end}}
+{{- if .TenantResolver}}
+	resolver   mongox.TenantResolver
+{{- end}}
+{{- if .Intercepted}}
+	interceptors []mongox.Interceptor
+{{- end}}
+{{- if .Cached}}
+	// invalidate deletes the cached results of the Cached{{.StructName}}Repository which started the bulk write
+	invalidate func()
+{{- end}}
+}
+
+// Bulk starts a bulk write of the collection of the repository.
+func (r *{{.StructName}}RepositoryMongo) Bulk() *{{.StructName}}Bulk {
+	return &{{.StructName}}Bulk{collection: r.collection, ordered: true{{if .TenantResolver}}, resolver: r.tenantResolver{{end}}{{if .Intercepted}}, interceptors: r.interceptors{{end}}}
+}
+
+// Unordered continues writing the remaining operations after an operation failed.
+func (b *{{.StructName}}Bulk) Unordered() *{{.StructName}}Bulk {
+	b.ordered = false
+	return b
+}
+{{- if .ChunkSize}}
+
+// OnProgress sets the progress which is called after every chunk of the operations is written.
+func (b *{{.StructName}}Bulk) OnProgress(progress func(done, total int)) *{{.StructName}}Bulk {
+	b.progress = progress
+	return b
+}
+{{- end}}
+
+// Len returns the number of the added operations.
+func (b *{{.StructName}}Bulk) Len() int {
+	return len(b.models)
+}
+
+// InsertOne adds the insertion of the doc.
+func (b *{{.StructName}}Bulk) InsertOne(doc {{.ModelType}}) *{{.StructName}}Bulk {
+{{- if .HasValidation}}
+	if !b.validate(doc) {
+		return b
+	}
+{{- end}}
+{{- if .CreatedAtField}}
+	doc.{{.CreatedAtField}} = {{.TimestampNow}}
+{{- if .UpdatedAtField}}
+	doc.{{.UpdatedAtField}} = doc.{{.CreatedAtField}}
+{{- end}}
+{{- else if .UpdatedAtField}}
+	doc.{{.UpdatedAtField}} = {{.TimestampNow}}
+{{- end}}
+{{- if eq .IDStrategy "sequence"}}
+	b.inserted = append(b.inserted, doc)
+{{- else if .IDStrategy}}
+	if doc.{{.IDField}} == {{.IDZero}} {
+		doc.{{.IDField}} = newID()
+	}
+{{- end}}
+	b.models = append(b.models, mongo.NewInsertOneModel().SetDocument(doc))
+	return b
+}
+
+// ReplaceOne adds the replacement of the first document matching the filter with the doc.
+func (b *{{.StructName}}Bulk) ReplaceOne(filter interface{}, doc {{.ModelType}}) *{{.StructName}}Bulk {
+	return b.replaceOne(filter, doc, false)
+}
+
+// ReplaceOneUpsert adds the replacement of the first document matching the filter with the doc,
+// the doc is inserted if no document matches.
+func (b *{{.StructName}}Bulk) ReplaceOneUpsert(filter interface{}, doc {{.ModelType}}) *{{.StructName}}Bulk {
+	return b.replaceOne(filter, doc, true)
+}
+
+// UpdateOne adds the update of the first document matching the filter.
+func (b *{{.StructName}}Bulk) UpdateOne(filter interface{}, update bson.M) *{{.StructName}}Bulk {
+{{- if .ValidatedFields}}
+	if !b.validateUpdate(update) {
+		return b
+	}
+{{- end}}
+	b.models = append(b.models, mongo.NewUpdateOneModel().SetFilter({{if .SoftDeleteKey}}b.filter(filter){{else}}filter{{end}}).SetUpdate({{if or .CreatedAtKey .UpdatedAtKey}}b.update(update, false){{else}}update{{end}}))
+	return b
+}
+
+// UpdateOneUpsert adds the update of the first document matching the filter,
+// a document is inserted if no document matches.
+func (b *{{.StructName}}Bulk) UpdateOneUpsert(filter interface{}, update bson.M) *{{.StructName}}Bulk {
+{{- if .ValidatedFields}}
+	if !b.validateUpdate(update) {
+		return b
+	}
+{{- end}}
+	b.models = append(b.models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate({{if or .CreatedAtKey .UpdatedAtKey}}b.update(update, true){{else}}update{{end}}).SetUpsert(true))
+	return b
+}
+
+// UpdateMany adds the update of all the documents matching the filter.
+func (b *{{.StructName}}Bulk) UpdateMany(filter interface{}, update bson.M) *{{.StructName}}Bulk {
+{{- if .ValidatedFields}}
+	if !b.validateUpdate(update) {
+		return b
+	}
+{{- end}}
+	b.models = append(b.models, mongo.NewUpdateManyModel().SetFilter({{if .SoftDeleteKey}}b.filter(filter){{else}}filter{{end}}).SetUpdate({{if or .CreatedAtKey .UpdatedAtKey}}b.update(update, false){{else}}update{{end}}))
+	return b
+}
+
+// UpdateManyUpsert adds the update of all the documents matching the filter,
+// a document is inserted if no document matches.
+func (b *{{.StructName}}Bulk) UpdateManyUpsert(filter interface{}, update bson.M) *{{.StructName}}Bulk {
+{{- if .ValidatedFields}}
+	if !b.validateUpdate(update) {
+		return b
+	}
+{{- end}}
+	b.models = append(b.models, mongo.NewUpdateManyModel().SetFilter(filter).SetUpdate({{if or .CreatedAtKey .UpdatedAtKey}}b.update(update, true){{else}}update{{end}}).SetUpsert(true))
+	return b
+}
+
+// DeleteOne adds the deletion of the first document matching the filter.
+func (b *{{.StructName}}Bulk) DeleteOne(filter interface{}) *{{.StructName}}Bulk {
+{{- if .SoftDeleteKey}}
+	b.models = append(b.models, mongo.NewUpdateOneModel().SetFilter(b.filter(filter)).SetUpdate(softDeleteUpdate()))
+{{- else}}
+	b.models = append(b.models, mongo.NewDeleteOneModel().SetFilter(filter))
+{{- end}}
+	return b
+}
+
+// DeleteMany adds the deletion of all the documents matching the filter.
+func (b *{{.StructName}}Bulk) DeleteMany(filter interface{}) *{{.StructName}}Bulk {
+{{- if .SoftDeleteKey}}
+	b.models = append(b.models, mongo.NewUpdateManyModel().SetFilter(b.filter(filter)).SetUpdate(softDeleteUpdate()))
+{{- else}}
+	b.models = append(b.models, mongo.NewDeleteManyModel().SetFilter(filter))
+{{- end}}
+	return b
+}
+{{- if .SoftDeleteKey}}
+
+// HardDeleteOne adds the removal of the first document matching the filter, including the soft deleted one.
+func (b *{{.StructName}}Bulk) HardDeleteOne(filter interface{}) *{{.StructName}}Bulk {
+	b.models = append(b.models, mongo.NewDeleteOneModel().SetFilter(filter))
+	return b
+}
+
+// HardDeleteMany adds the removal of all the documents matching the filter, including the soft deleted ones.
+func (b *{{.StructName}}Bulk) HardDeleteMany(filter interface{}) *{{.StructName}}Bulk {
+	b.models = append(b.models, mongo.NewDeleteManyModel().SetFilter(filter))
+	return b
+}
+{{- end}}
+{{- if .IDKey}}
+
+// ReplaceBy{{.IDField}}Equal adds the replacement of the document whose {{.IDField}} is equal to the id.
+func (b *{{.StructName}}Bulk) ReplaceBy{{.IDField}}Equal(id {{.IDType}}, doc {{.ModelType}}) *{{.StructName}}Bulk {
+	return b.ReplaceOne(bson.M{"{{.IDKey}}": id}, doc)
+}
+
+// UpdateBy{{.IDField}}Equal adds the update of the document whose {{.IDField}} is equal to the id.
+func (b *{{.StructName}}Bulk) UpdateBy{{.IDField}}Equal(id {{.IDType}}, update bson.M) *{{.StructName}}Bulk {
+	return b.UpdateOne(bson.M{"{{.IDKey}}": id}, update)
+}
+
+// DeleteBy{{.IDField}}Equal adds the deletion of the document whose {{.IDField}} is equal to the id.
+func (b *{{.StructName}}Bulk) DeleteBy{{.IDField}}Equal(id {{.IDType}}) *{{.StructName}}Bulk {
+	return b.DeleteOne(bson.M{"{{.IDKey}}": id})
+}
+{{- end}}
+
+// Exec writes the added operations, it returns the first error of building the operations without writing.
+{{- if .Intercepted}}
+// The write runs through the interceptors of the repository as the Bulk method, the added operations are the Args.
+func (b *{{.StructName}}Bulk) Exec(ctx context.Context) (*mongo.BulkWriteResult, error) {
+{{- if .Cached}}
+	if b.invalidate != nil {
+		defer mongox.AfterCommit(ctx, b.invalidate)
+	}
+{{- end}}
+	if len(b.interceptors) == 0 {
+		return b.exec(ctx)
+	}
+	args := make([]interface{}, 0, len(b.models))
+	for _, model := range b.models {
+		args = append(args, model)
+	}
+	var result *mongo.BulkWriteResult
+	err := mongox.Intercept(ctx, b.interceptors, &mongox.Invocation{
+		Collection: b.collection.Name(),
+		Repository: "{{.StructName}}",
+		Method:     "Bulk",
+		Operation:  "Bulk",
+		Args:       args,
+	}, func(ctx context.Context) error {
+		var err error
+		result, err = b.exec(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (b *{{.StructName}}Bulk) exec(ctx context.Context) (*mongo.BulkWriteResult, error) {
+{{- else}}
+func (b *{{.StructName}}Bulk) Exec(ctx context.Context) (*mongo.BulkWriteResult, error) {
+{{- if .Cached}}
+	if b.invalidate != nil {
+		defer mongox.AfterCommit(ctx, b.invalidate)
+	}
+{{- end}}
+{{- end}}
+	if b.err != nil {
+		return nil, b.err
+	}
+	models, collection := b.models, b.collection
+{{- if .TenantKey}}
+	if err := mongox.CheckTenant(ctx); err != nil {
+		return nil, err
+	}
+{{- end}}
+{{- if .TenantResolver}}
+	if b.resolver != nil {
+		// the resolver is set by the MongoDB repository only
+		resolved, err := mongox.ResolveTenant(ctx, b.resolver, b.collection.(*mongo.Collection))
+		if err != nil {
+			return nil, err
+		}
+		collection = resolved
+	}
+{{- end}}
+{{- if .TenantKey}}
+	models = b.scopeTenant(ctx, {{if .TenantResolver}}collection != b.collection{{else}}false{{end}})
+{{- end}}
+{{- if eq .IDStrategy "sequence"}}
+{{- if .SequenceBatch}}
+	var reserve int64
+	for _, doc := range b.inserted {
+		if doc.{{.IDField}} == 0 {
+			reserve++
+		}
+	}
+	if reserve > 0 {
+		seq, err := b.nextSequence(ctx, collection, reserve)
+		if err != nil {
+			return nil, err
+		}
+		seq -= reserve
+		for _, doc := range b.inserted {
+			if doc.{{.IDField}} == 0 {
+				seq++
+				doc.{{.IDField}} = seq
+			}
+		}
+	}
+{{- else}}
+	for _, doc := range b.inserted {
+		if doc.{{.IDField}} == 0 {
+			seq, err := b.nextSequence(ctx, collection, 1)
+			if err != nil {
+				return nil, err
+			}
+			doc.{{.IDField}} = seq
+		}
+	}
+{{- end}}
+{{- end}}
+{{- if .ChunkSize}}
+	result := &mongo.BulkWriteResult{UpsertedIDs: make(map[int64]interface{})}
+	err := writeChunks(len(models), b.ordered, b.progress, func(start, end int) error {
+		chunk, err := collection.BulkWrite(ctx, models[start:end], options.BulkWrite().SetOrdered(b.ordered))
+		if chunk != nil {
+			result.InsertedCount += chunk.InsertedCount
+			result.MatchedCount += chunk.MatchedCount
+			result.ModifiedCount += chunk.ModifiedCount
+			result.DeletedCount += chunk.DeletedCount
+			result.UpsertedCount += chunk.UpsertedCount
+			for index, id := range chunk.UpsertedIDs {
+				result.UpsertedIDs[int64(start)+index] = id
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return result, translateError(err)
+	}
+{{- else}}
+	result, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(b.ordered))
+	if err != nil {
+		return result, translateError(err)
+	}
+{{- end}}
+{{- if .Tracing}}
+	mongox.RecordBulkCounts(ctx, result.InsertedCount, result.MatchedCount, result.ModifiedCount,
+		result.DeletedCount, result.UpsertedCount)
+{{- end}}
+	return result, nil
+}
+
+func (b *{{.StructName}}Bulk) replaceOne(filter interface{}, doc {{.ModelType}}, upsert bool) *{{.StructName}}Bulk {
+{{- if .HasValidation}}
+	if !b.validate(doc) {
+		return b
+	}
+{{- end}}
+{{- if .UpdatedAtField}}
+	doc.{{.UpdatedAtField}} = {{.TimestampNow}}
+{{- end}}
+{{- if .CreatedAtField}}
+	// the replacement keeps the created-at timestamp carried by the doc
+	if upsert && {{.CreatedAtUnset}} {
+		doc.{{.CreatedAtField}} = {{.TimestampNow}}
+	}
+{{- end}}
+{{- if .SoftDeleteKey}}
+	if !upsert {
+		filter = b.filter(filter)
+	}
+{{- end}}
+	b.models = append(b.models, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(doc).SetUpsert(upsert))
+	return b
+}
+{{- if .HasValidation}}
+
+// validate keeps the first validation error which is returned by Exec.
+func (b *{{.StructName}}Bulk) validate(doc {{.ModelType}}) bool {
+	if b.err != nil {
+		return false
+	}
+	if err := doc.Validate(); err != nil {
+		b.err = err
+		return false
+	}
+	return true
+}
+{{- end}}
+{{- if .ValidatedFields}}
+
+// validateUpdate keeps the first validation error of the values set by the update, which is returned by Exec.
+// The values of the fields with validation annotations in $set and $setOnInsert are decoded into the {{.StructName}}
+// and validated by the Validate methods of the fields, as the Update methods of the fields do.
+func (b *{{.StructName}}Bulk) validateUpdate(update bson.M) bool {
+	if b.err != nil {
+		return false
+	}
+	for _, operator := range []string{"$set", "$setOnInsert"} {
+		value, ok := update[operator]
+		if !ok {
+			continue
+		}
+		var set bson.M
+		data, err := bson.Marshal(value)
+		if err == nil {
+			err = bson.Unmarshal(data, &set)
+		}
+		doc := new({{.ModelElem}})
+		if err == nil {
+			err = bson.Unmarshal(data, doc)
+		}
+		if err != nil {
+			b.err = fmt.Errorf("bulk update: invalid %s: %w", operator, err)
+			return false
+		}
+{{- range .ValidatedFields}}
+		if _, ok := set["{{.Key}}"]; ok {
+			if err = doc.Validate{{.Name}}(); err != nil {
+				b.err = err
+				return false
+			}
+		}
+{{- end}}
+	}
+	return true
+}
+{{- end}}
+{{- if eq .IDStrategy "sequence"}}
+
+// nextSequence reserves n ids of the sequence of the written collection, which is in memory for the in-memory repository.
+func (b *{{.StructName}}Bulk) nextSequence(ctx context.Context, collection mongox.BulkWriter, n int64) (int64, error) {
+	if memory, ok := collection.(*mongox.MemoryCollection); ok {
+		return memory.NextSequence(ctx, n)
+	}
+	return nextSequence(ctx, collection.(*mongo.Collection), n)
+}
+{{- end}}
+{{- if .SoftDeleteKey}}
+
+// filter excludes the soft deleted documents from the filter,
+// the upserts are not filtered, otherwise an upsert of a soft deleted document would insert another one.
+func (b *{{.StructName}}Bulk) filter(filter interface{}) interface{} {
+	return bson.M{"$and": bson.A{filter, {{.NotDeleted}}}}
+}
+{{- end}}
+{{- if .TenantKey}}
+
+// scopeTenant returns the operations whose filters are scoped to the tenant of the context, the filters are kept
+// if the operations are routed to the collection of the tenant. The added operations are not modified so that
+// Exec can be called again. The tenant is stamped on the inserted and replacing docs.
+func (b *{{.StructName}}Bulk) scopeTenant(ctx context.Context, routed bool) []mongo.WriteModel {
+	tenant, ok := mongox.TenantFromContext(ctx)
+	stamp := func(document interface{}) {
+		if doc, isDoc := document.({{.ModelType}}); isDoc && ok {
+			doc.{{.TenantField}} = tenant
+		}
+	}
+	scope := func(filter interface{}) interface{} {
+		if routed {
+			return filter
+		}
+		return mongox.TenantFilter(ctx, "{{.TenantKey}}", filter)
+	}
+	models := make([]mongo.WriteModel, 0, len(b.models))
+	for _, model := range b.models {
+		switch model := model.(type) {
+		case *mongo.InsertOneModel:
+			stamp(model.Document)
+			models = append(models, model)
+		case *mongo.ReplaceOneModel:
+			stamp(model.Replacement)
+			scoped := *model
+			scoped.Filter = scope(model.Filter)
+			models = append(models, &scoped)
+		case *mongo.UpdateOneModel:
+			scoped := *model
+			scoped.Filter = scope(model.Filter)
+			models = append(models, &scoped)
+		case *mongo.UpdateManyModel:
+			scoped := *model
+			scoped.Filter = scope(model.Filter)
+			models = append(models, &scoped)
+		case *mongo.DeleteOneModel:
+			scoped := *model
+			scoped.Filter = scope(model.Filter)
+			models = append(models, &scoped)
+		case *mongo.DeleteManyModel:
+			scoped := *model
+			scoped.Filter = scope(model.Filter)
+			models = append(models, &scoped)
+		default:
+			models = append(models, model)
+		}
+	}
+	return models
+}
+{{- end}}
+
+{{- if or .CreatedAtKey .UpdatedAtKey}}
+
+// update adds the timestamp fields into the $set and $setOnInsert of the update, the update is not modified.
+// The $set of another document type is converted into bson.M, the error of the conversion is returned by Exec.
+func (b *{{.StructName}}Bulk) update(update bson.M, upsert bool) bson.M {
+	set := bson.M{}
+	if value, ok := update["$set"]; ok {
+		if set, ok = value.(bson.M); !ok {
+			data, err := bson.Marshal(value)
+			if err == nil {
+				err = bson.Unmarshal(data, &set)
+			}
+			if err != nil {
+				if b.err == nil {
+					b.err = fmt.Errorf("bulk update: invalid $set: %w", err)
+				}
+				return update
+			}
+		}
+	}
+	result := make(bson.M, len(update)+1)
+	for key, value := range update {
+		result[key] = value
+	}
+	now := {{.TimestampNow}}
+{{- if .UpdatedAtKey}}
+	if _, ok := set["{{.UpdatedAtKey}}"]; !ok {
+		updated := make(bson.M, len(set)+1)
+		for key, value := range set {
+			updated[key] = value
+		}
+		updated["{{.UpdatedAtKey}}"] = now
+		result["$set"] = updated
+	}
+{{- end}}
+{{- if .CreatedAtKey}}
+	if _, ok := set["{{.CreatedAtKey}}"]; upsert && !ok {
+		if _, ok = update["$setOnInsert"]; !ok {
+			result["$setOnInsert"] = bson.M{"{{.CreatedAtKey}}": now}
+		}
+	}
+{{- end}}
+	return result
+}
+{{- end}}
+{{- if .SoftDeleteKey}}
+
+func softDeleteUpdate() bson.M {
+	return bson.M{"$set": bson.M{"{{.SoftDeleteKey}}": {{.SoftDeleteValue}}}}
+}
+{{- end}}
+`
+
+// BulkBuilderRender renders the runtime bulk builder of the repository, the empty fields are not set by annotations.
+type BulkBuilderRender struct {
+	StructName string
+	// ModelType is the pointer type of the struct, such as *user.User
+	ModelType     string
+	HasValidation bool
+	// ModelElem is the struct type, such as user.User, ValidatedFields are its fields with validation annotations
+	ModelElem       string
+	ValidatedFields []BulkValidatedField
+
+	IDField       string
+	IDType        string
+	IDZero        string
+	IDKey         string
+	IDStrategy    string
+	SequenceBatch bool
+	ChunkSize     int
+
+	CreatedAtField string
+	CreatedAtKey   string
+	UpdatedAtField string
+	UpdatedAtKey   string
+	// TimestampNow is the current time of the timestamp fields, CreatedAtUnset is the condition that
+	// the created-at timestamp of the doc is not set
+	TimestampNow   string
+	CreatedAtUnset string
+
+	SoftDeleteKey   string
+	SoftDeleteValue string
+	NotDeleted      string
+
+	TenantField    string
+	TenantKey      string
+	TenantResolver bool
+
+	// Cached invalidates the results of the cached repository after Exec
+	Cached bool
+	// Intercepted runs Exec through the interceptors of the repository,
+	// Tracing records the result counts of Exec on the tracing span
+	Intercepted bool
+	Tracing     bool
+}
+
+// BulkValidatedField is the field with validation annotations of the bulk builder, Key is its bson name.
+type BulkValidatedField struct {
+	Name string
+	Key  string
+}
+
+func (br *BulkBuilderRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "bulkBuilderTemplate", bulkBuilderTemplate, br); err != nil {
+		return err
+	}
+	return nil
+}
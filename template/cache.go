@@ -0,0 +1,261 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var cacheTemplate = `
+// Cache stores the results of the cached repositories, it must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	// Set stores the value which expires after ttl, it does not expire if ttl is zero
+	Set(key string, value interface{}, ttl time.Duration)
+	// DeletePrefix deletes the values whose keys start with prefix
+	DeletePrefix(prefix string)
+}
+
+// CacheRepositoryScope returns the prefix of all the keys of the repository.
+func CacheRepositoryScope(repository string) string {
+	return repository + "|"
+}
+
+// CacheScope returns the prefix of the keys of the methods which do not select one document by the id,
+// they are invalidated by all the writes of the repository.
+func CacheScope(repository string) string {
+	return repository + "|*|"
+}
+
+// CacheIDScope returns the prefix of the keys of the methods which select the document of id,
+// they are invalidated by the writes of the document and the writes which do not select one document by the id.
+func CacheIDScope(repository string, id interface{}) string {
+	return repository + "|id=" + cacheArg(id) + "|"
+}
+
+// CacheKey returns the key of the result of the method called with args.
+func CacheKey(scope, method string, args ...interface{}) string {
+	var b strings.Builder
+	b.WriteString(scope)
+	b.WriteString(method)
+	for _, arg := range args {
+		b.WriteString("|")
+		b.WriteString(cacheArg(arg))
+	}
+	return b.String()
+}
+
+func cacheArg(arg interface{}) string {
+	data, err := json.Marshal(arg)
+	if err != nil {
+		return fmt.Sprintf("%#v", arg)
+	}
+	return string(data)
+}
+
+// LRUCache is the in-memory Cache which evicts the least recently used value when it is full,
+// it stores the copies of the values and returns the copies of them so that the callers do not share them.
+type LRUCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  *list.List
+	items    map[string]*list.Element
+}
+
+type lruEntry struct {
+	key      string
+	value    interface{}
+	expireAt time.Time
+}
+
+// NewLRUCache returns the LRUCache which stores at most capacity values.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		entries:  list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+		c.remove(element)
+		return nil, false
+	}
+	c.entries.MoveToFront(element)
+	return copyValue(entry.value), true
+}
+
+func (c *LRUCache) Set(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value = copyValue(value)
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+	if element, ok := c.items[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expireAt = value, expireAt
+		c.entries.MoveToFront(element)
+		return
+	}
+	c.items[key] = c.entries.PushFront(&lruEntry{key: key, value: value, expireAt: expireAt})
+	for c.entries.Len() > c.capacity {
+		c.remove(c.entries.Back())
+	}
+}
+
+func (c *LRUCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, element := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(element)
+		}
+	}
+}
+
+func (c *LRUCache) remove(element *list.Element) {
+	c.entries.Remove(element)
+	delete(c.items, element.Value.(*lruEntry).key)
+}
+
+// copyValue returns the deep copy of the value, the unexported fields of the structs are copied shallowly.
+func copyValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return copyReflect(reflect.ValueOf(value)).Interface()
+}
+
+func copyReflect(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.New(value.Type().Elem())
+		clone.Elem().Set(copyReflect(value.Elem()))
+		return clone
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.New(value.Type()).Elem()
+		clone.Set(copyReflect(value.Elem()))
+		return clone
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			clone.Index(i).Set(copyReflect(value.Index(i)))
+		}
+		return clone
+	case reflect.Array:
+		clone := reflect.New(value.Type()).Elem()
+		for i := 0; i < value.Len(); i++ {
+			clone.Index(i).Set(copyReflect(value.Index(i)))
+		}
+		return clone
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			clone.SetMapIndex(iter.Key(), copyReflect(iter.Value()))
+		}
+		return clone
+	case reflect.Struct:
+		clone := reflect.New(value.Type()).Elem()
+		clone.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if clone.Field(i).CanSet() {
+				clone.Field(i).Set(copyReflect(value.Field(i)))
+			}
+		}
+		return clone
+	default:
+		return value
+	}
+}
+`
+
+// CacheRender renders the Cache of the mongox package, which is shared by all the repositories.
+type CacheRender struct{}
+
+func (cr *CacheRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "cacheTemplate", cacheTemplate, cr); err != nil {
+		return err
+	}
+	return nil
+}
+
+var cachedRepositoryTemplate = `
+// Cached{{.StructName}}Repository is the {{.StructName}}Repository which caches the results of the annotated
+// Find methods, the writes invalidate the results they may change. The reads in the transactions are not
+// cached, the writes in a mongox.UnitOfWork invalidate the results after the commit and the writes of the
+// Transaction methods run out of a UnitOfWork invalidate them after the methods return.
+type Cached{{.StructName}}Repository struct {
+	{{.StructName}}Repository
+	cache mongox.Cache
+}
+
+// NewCached{{.StructName}}Repository returns the Cached{{.StructName}}Repository which caches the results
+// of repository in cache.
+func NewCached{{.StructName}}Repository(repository {{.StructName}}Repository, cache mongox.Cache) *Cached{{.StructName}}Repository {
+	return &Cached{{.StructName}}Repository{
+		{{.StructName}}Repository: repository,
+		cache: cache,
+	}
+}
+
+// Bulk starts a bulk write of the repository whose Exec invalidates all the results of the repository.
+func (r *Cached{{.StructName}}Repository) Bulk() *{{.StructName}}Bulk {
+	b := r.{{.StructName}}Repository.Bulk()
+	if b != nil {
+		b.invalidate = func() {
+			r.cache.DeletePrefix(mongox.CacheRepositoryScope("{{.StructName}}"))
+		}
+	}
+	return b
+}
+`
+
+// CachedRepositoryRender renders the cached repository which decorates the repository of the struct.
+type CachedRepositoryRender struct {
+	StructName string
+}
+
+func (cr *CachedRepositoryRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "cachedRepositoryTemplate", cachedRepositoryTemplate, cr); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var chunkTemplate = `
+// chunkSize is the maximum number of the documents or the operations sent by one write.
+const chunkSize = {{.ChunkSize}}
+
+// writeChunks calls write with the bounds [start, end) of every chunk of the n documents or operations,
+// progress is called after every chunk if it is not nil. The ordered write stops at the first failed chunk,
+// the unordered write continues and merges the failures of all the chunks. The indexes of the failures
+// are the indexes of the whole input.
+func writeChunks(n int, ordered bool, progress func(done, total int), write func(start, end int) error) error {
+	var failures *WriteError
+	for start := 0; start < n; start += chunkSize {
+		end := start + chunkSize
+		if end > n {
+			end = n
+		}
+		if err := write(start, end); err != nil {
+			writeError, ok := newWriteError(err).(*WriteError)
+			if !ok {
+				return translateError(err)
+			}
+			for i := range writeError.Failures {
+				writeError.Failures[i].Index += start
+			}
+			if ordered {
+				return writeError
+			}
+			if failures == nil {
+				failures = writeError
+			} else {
+				failures.Failures = append(failures.Failures, writeError.Failures...)
+			}
+		}
+		if progress != nil {
+			progress(end, n)
+		}
+	}
+	if failures != nil {
+		return failures
+	}
+	return nil
+}
+`
+
+// ChunkRender renders the chunked writes of the repository, it is generated with the repository of the struct
+// which has the chunk size annotation.
+type ChunkRender struct {
+	ChunkSize int
+}
+
+func (cr *ChunkRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "chunkTemplate", chunkTemplate, cr); err != nil {
+		return err
+	}
+	return nil
+}
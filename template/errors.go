@@ -0,0 +1,220 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var errorsTemplate = `
+var (
+	// ErrNotFound is returned when no document matches the query of the One methods
+	ErrNotFound = errors.New("document not found")
+
+	// ErrDuplicateKey is matched by the errors of the writes which violate a unique index,
+	// *DuplicateKeyError or *WriteError describes the violated index
+	ErrDuplicateKey = errors.New("duplicate key")
+
+	// ErrConflict is returned when the write conflicts with a concurrent write, it can be retried
+	ErrConflict = errors.New("write conflict")
+)
+
+// DuplicateKeyError is returned when the write of one document violates a unique index.
+type DuplicateKeyError struct {
+	DuplicateKey
+
+	err error
+}
+
+func (e *DuplicateKeyError) Error() string {
+	return fmt.Sprintf("%s: index %s: %s", ErrDuplicateKey.Error(), e.Index, strings.Join(e.Fields, ", "))
+}
+
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrDuplicateKey
+}
+
+func (e *DuplicateKeyError) Unwrap() error {
+	return e.err
+}
+
+// WriteError is returned by InsertMany and BulkWrite when some documents failed to be written,
+// the documents which are not in Failures have been written unless the write is ordered.
+type WriteError struct {
+	// Failures stores the failed writes in the order of the input indexes
+	Failures []WriteFailure
+
+	err error
+}
+
+// WriteFailure describes why the document at Index of the input failed to be written.
+type WriteFailure struct {
+	Index   int
+	Code    int
+	Message string
+
+	// DuplicateKey is not nil when the write violates a unique index
+	DuplicateKey *DuplicateKey
+}
+
+// DuplicateKey describes the unique index violated by a write.
+type DuplicateKey struct {
+	Index  string
+	Fields []string
+}
+
+func (e *WriteError) Error() string {
+	messages := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		messages = append(messages, fmt.Sprintf("index %d: %s", failure.Index, failure.Message))
+	}
+	return fmt.Sprintf("%d documents failed to be written: %s", len(e.Failures), strings.Join(messages, "; "))
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is ErrDuplicateKey and some documents violate a unique index.
+func (e *WriteError) Is(target error) bool {
+	if target != ErrDuplicateKey {
+		return false
+	}
+	for _, failure := range e.Failures {
+		if failure.DuplicateKey != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// FailedIndexes returns the input indexes of the documents which failed to be written.
+func (e *WriteError) FailedIndexes() []int {
+	indexes := make([]int, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		indexes = append(indexes, failure.Index)
+	}
+	return indexes
+}
+
+var (
+	duplicateKeyIndexRegexp = regexp.MustCompile(` + "`index: (\\S+)`" + `)
+	// the quoted values are matched first so that the separators in them are not taken as fields
+	duplicateKeyFieldRegexp = regexp.MustCompile(` + "`\"(?:[^\"\\\\]|\\\\.)*\"|[{,] ([^\\s:]+): `" + `)
+)
+
+const (
+	duplicateKeyCode  = 11000
+	writeConflictCode = 112
+)
+
+// driverError is the sentinel error translated from the error of the driver, which is kept in the chain.
+type driverError struct {
+	sentinel error
+	err      error
+}
+
+func (e *driverError) Error() string {
+	return e.sentinel.Error() + ": " + e.err.Error()
+}
+
+func (e *driverError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+func (e *driverError) Unwrap() error {
+	return e.err
+}
+
+// translateError translates the errors of the driver into the errors of the package, the errors of the driver
+// are kept in the chain. The translated errors and other errors are returned as they are.
+func translateError(err error) error {
+	var (
+		writeError   *WriteError
+		duplicateKey *DuplicateKeyError
+		translated   *driverError
+		exception    mongo.BulkWriteException
+		serverError  mongo.ServerError
+	)
+	switch {
+	case err == nil, errors.As(err, &writeError), errors.As(err, &duplicateKey), errors.As(err, &translated):
+		return err
+	case errors.As(err, &exception):
+		return newWriteError(err)
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return &driverError{sentinel: ErrNotFound, err: err}
+	case mongo.IsDuplicateKeyError(err):
+		return &DuplicateKeyError{DuplicateKey: *parseDuplicateKey(err.Error()), err: err}
+	case errors.As(err, &serverError) && serverError.HasErrorCode(writeConflictCode):
+		return &driverError{sentinel: ErrConflict, err: err}
+	default:
+		return err
+	}
+}
+
+// newWriteError converts the mongo.BulkWriteException into *WriteError,
+// other errors are returned as they are.
+func newWriteError(err error) error {
+	var exception mongo.BulkWriteException
+	if !errors.As(err, &exception) || len(exception.WriteErrors) == 0 {
+		return err
+	}
+
+	writeError := &WriteError{
+		Failures: make([]WriteFailure, 0, len(exception.WriteErrors)),
+		err:      err,
+	}
+	for _, we := range exception.WriteErrors {
+		failure := WriteFailure{
+			Index:   we.Index,
+			Code:    we.Code,
+			Message: we.Message,
+		}
+		if we.Code == duplicateKeyCode {
+			failure.DuplicateKey = parseDuplicateKey(we.Message)
+		}
+		writeError.Failures = append(writeError.Failures, failure)
+	}
+	return writeError
+}
+
+// parseDuplicateKey parses the message like
+// E11000 duplicate key error collection: db.video index: title_1 dup key: { title: "hello" }
+func parseDuplicateKey(message string) *DuplicateKey {
+	duplicateKey := &DuplicateKey{}
+	if match := duplicateKeyIndexRegexp.FindStringSubmatch(message); match != nil {
+		duplicateKey.Index = match[1]
+	}
+	if index := strings.Index(message, "dup key:"); index != -1 {
+		for _, match := range duplicateKeyFieldRegexp.FindAllStringSubmatch(message[index:], -1) {
+			if match[1] != "" {
+				duplicateKey.Fields = append(duplicateKey.Fields, match[1])
+			}
+		}
+	}
+	return duplicateKey
+}
+`
+
+// ErrorsRender renders the errors returned by the generated methods and the translation of the errors of the driver,
+// it is generated with every repository.
+type ErrorsRender struct{}
+
+func (er *ErrorsRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "errorsTemplate", errorsTemplate, er); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var idTemplate = `{{if eq .Strategy "sequence"}}
+const (
+	sequenceCollection = "{{.SequenceCollection}}"
+	sequenceName       = "{{.SequenceName}}"
+)
+
+// nextSequence increases the sequence of the {{.FieldName}} of the {{.StructName}} by n in the database of the collection,
+// it returns the last reserved {{.FieldName}}.
+func nextSequence(ctx context.Context, collection *mongo.Collection, n int64) (int64, error) {
+	var counter struct {
+		Seq int64 ` + "`bson:\"seq\"`" + `
+	}
+	err := collection.Database().Collection(sequenceCollection).FindOneAndUpdate(ctx,
+		bson.M{"_id": sequenceName},
+		bson.M{"$inc": bson.M{"seq": n}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return 0, translateError(err)
+	}
+	return counter.Seq, nil
+}
+{{else}}
+// IDGenerator generates the {{.FieldName}} of the {{.StructName}} which is inserted with zero {{.FieldName}},
+// it can be replaced before the repository is used{{if eq .Strategy "custom"}}, it must be set for the custom strategy{{end}}.
+var IDGenerator func() {{.FieldType}}{{if ne .Strategy "custom"}} = {{.DefaultGenerator}}{{end}}
+
+func newID() {{.FieldType}} {
+	if IDGenerator == nil {
+		panic("{{.PackageName}}: IDGenerator is not set")
+	}
+	return IDGenerator()
+}
+{{end}}{{if eq .Strategy "objectid"}}
+func newObjectID() string {
+	return primitive.NewObjectID().Hex()
+}
+{{else if eq .Strategy "uuidv4"}}
+func newUUIDv4() string {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		panic(err)
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return formatUUID(uuid)
+}
+{{else if eq .Strategy "uuidv7"}}
+func newUUIDv7() string {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[6:]); err != nil {
+		panic(err)
+	}
+	var milli [8]byte
+	binary.BigEndian.PutUint64(milli[:], uint64(time.Now().UnixMilli()))
+	copy(uuid[:6], milli[2:])
+	uuid[6] = uuid[6]&0x0f | 0x70
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return formatUUID(uuid)
+}
+{{end}}{{if or (eq .Strategy "uuidv4") (eq .Strategy "uuidv7")}}
+func formatUUID(uuid [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+	return string(buf[:])
+}
+{{else if eq .Strategy "snowflake"}}
+const (
+	snowflakeEpoch        = 1704067200000 // 2024-01-01T00:00:00Z in milliseconds
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+)
+
+// SnowflakeNode is the node of the snowflake ids generated by this process, it should be unique
+// among the processes writing the collection and in [0, 1024).
+var SnowflakeNode int64
+
+var snowflake struct {
+	sync.Mutex
+	milli    int64
+	sequence int64
+}
+
+func newSnowflakeID() int64 {
+	snowflake.Lock()
+	defer snowflake.Unlock()
+
+	milli := time.Now().UnixMilli()
+	if milli < snowflake.milli {
+		// the clock moved backwards, keep the ids increasing
+		milli = snowflake.milli
+	}
+	if milli == snowflake.milli {
+		snowflake.sequence = (snowflake.sequence + 1) & (1<<snowflakeSequenceBits - 1)
+		if snowflake.sequence == 0 {
+			for milli <= snowflake.milli {
+				milli = time.Now().UnixMilli()
+			}
+		}
+	} else {
+		snowflake.sequence = 0
+	}
+	snowflake.milli = milli
+
+	return (milli-snowflakeEpoch)<<(snowflakeNodeBits+snowflakeSequenceBits) |
+		(SnowflakeNode&(1<<snowflakeNodeBits-1))<<snowflakeSequenceBits | snowflake.sequence
+}
+{{end}}`
+
+// IDRender renders the id generator of the repository, it is generated with the repository of the struct
+// which has the id strategy annotation.
+type IDRender struct {
+	PackageName string
+	StructName  string
+	FieldName   string
+	FieldType   string
+	Strategy    string
+
+	SequenceName       string
+	SequenceCollection string
+}
+
+func (ir *IDRender) DefaultGenerator() string {
+	switch ir.Strategy {
+	case "objectid":
+		return "newObjectID"
+	case "uuidv4":
+		return "newUUIDv4"
+	case "uuidv7":
+		return "newUUIDv7"
+	case "snowflake":
+		return "newSnowflakeID"
+	default:
+		return ""
+	}
+}
+
+func (ir *IDRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "idTemplate", idTemplate, ir); err != nil {
+		return err
+	}
+	return nil
+}
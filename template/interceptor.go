@@ -0,0 +1,142 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var interceptorTemplate = `
+// Invocation describes the call of a repository method which is passed to the interceptors.
+type Invocation struct {
+	// Collection is the name of the collection of the repository
+	Collection string
+	// Repository is the name of the struct of the repository
+	Repository string
+	// Method is the name of the repository method
+	Method string
+	// Operation is the operation kind of the method, such as Insert, Find, Update, Delete and Count
+	Operation string
+	// Filter is the filter document of the method, it is nil if the operation has no filter
+	Filter interface{}
+	// Args are the arguments of the method except for the context
+	Args []interface{}
+}
+
+// Handler runs the repository method with ctx.
+type Handler func(ctx context.Context) error
+
+// Interceptor wraps the repository methods, it may act before and after calling next
+// and must return the error of next unless it replaces the error.
+type Interceptor func(ctx context.Context, invocation *Invocation, next Handler) error
+
+// Intercept runs handler through the interceptors, the first interceptor is the outermost one.
+func Intercept(ctx context.Context, interceptors []Interceptor, invocation *Invocation, handler Handler) error {
+	if len(interceptors) == 0 {
+		return handler(ctx)
+	}
+	return interceptors[0](ctx, invocation, func(ctx context.Context) error {
+		return Intercept(ctx, interceptors[1:], invocation, handler)
+	})
+}
+`
+
+// InterceptorRender renders the Interceptor of the mongox package, which is shared by all the repositories.
+type InterceptorRender struct{}
+
+func (ir *InterceptorRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "interceptorTemplate", interceptorTemplate, ir); err != nil {
+		return err
+	}
+	return nil
+}
+
+var optionTemplate = `
+// {{.StructName}}RepositoryOption configures the {{.StructName}}RepositoryMongo.
+type {{.StructName}}RepositoryOption func(r *{{.StructName}}RepositoryMongo)
+
+// With{{.StructName}}Interceptors appends the interceptors which wrap every method of the repository.
+func With{{.StructName}}Interceptors(interceptors ...mongox.Interceptor) {{.StructName}}RepositoryOption {
+	return func(r *{{.StructName}}RepositoryMongo) {
+		r.interceptors = append(r.interceptors, interceptors...)
+	}
+}
+
+// With{{.StructName}}CollectionOptions configures the collection of the repository such as the codec registry,
+// the read preference and the write concern, the options are applied over the options of the database.
+func With{{.StructName}}CollectionOptions(opts ...*options.CollectionOptions) {{.StructName}}RepositoryOption {
+	return func(r *{{.StructName}}RepositoryMongo) {
+		r.collection = r.collection.Database().Collection(r.collection.Name(), opts...)
+	}
+}
+{{- if .Cached}}
+
+// With{{.StructName}}Cache caches the results of the cached methods in cache,
+// the constructor returns the repository decorated by the Cached{{.StructName}}Repository.
+func With{{.StructName}}Cache(cache mongox.Cache) {{.StructName}}RepositoryOption {
+	return func(r *{{.StructName}}RepositoryMongo) {
+		r.cache = cache
+	}
+}
+{{- end}}
+{{- if .Tracing}}
+
+// With{{.StructName}}Tracer starts a span of the tracer for every method of the repository,
+// the span is the outermost one of the interceptors.
+func With{{.StructName}}Tracer(tracer mongox.Tracer) {{.StructName}}RepositoryOption {
+	return func(r *{{.StructName}}RepositoryMongo) {
+		r.interceptors = append([]mongox.Interceptor{mongox.TraceInterceptor(tracer)}, r.interceptors...)
+	}
+}
+{{- end}}
+{{- if .TenantResolver}}
+
+// With{{.StructName}}TenantResolver routes every method of the repository to the collection of the tenant
+// of the context resolved by the resolver.
+func With{{.StructName}}TenantResolver(resolver mongox.TenantResolver) {{.StructName}}RepositoryOption {
+	return func(r *{{.StructName}}RepositoryMongo) {
+		r.tenantResolver = resolver
+	}
+}
+
+// forTenant returns the repository of the collection of the tenant of the context,
+// the repository is routed if the collection is not the shared one.
+func (r *{{.StructName}}RepositoryMongo) forTenant(ctx context.Context) (*{{.StructName}}RepositoryMongo, error) {
+	collection, err := mongox.ResolveTenant(ctx, r.tenantResolver, r.collection)
+	if err != nil {
+		return nil, err
+	}
+	repository := *r
+	repository.collection, repository.tenantResolver = collection, nil
+	repository.tenantRouted = collection != r.collection
+	return &repository, nil
+}
+{{- end}}
+`
+
+// OptionRender renders the functional options of the constructor of the repository.
+type OptionRender struct {
+	StructName     string
+	Tracing        bool
+	TenantResolver bool
+	Cached         bool
+}
+
+func (or *OptionRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "optionTemplate", optionTemplate, or); err != nil {
+		return err
+	}
+	return nil
+}
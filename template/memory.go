@@ -0,0 +1,1368 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var memoryTemplate = `
+// ErrUnsupported is returned by the methods of the in-memory repositories which need the MongoDB server,
+// such as the change streams and the transactions writing the other collections.
+var ErrUnsupported = errors.New("mongox: the method is not supported by the in-memory repository")
+
+// BulkWriter is the collection written by the bulk builders, the *mongo.Collection or the *MemoryCollection.
+type BulkWriter interface {
+	Name() string
+	BulkWrite(ctx context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error)
+}
+
+// MemoryCollection stores the documents of the in-memory repositories, it evaluates the filters, the sorts,
+// the projections and the updates built for the MongoDB server over a slice of documents, so that the
+// in-memory repositories behave like the MongoDB repositories in the unit tests. It is safe for concurrent use.
+type MemoryCollection struct {
+	mu        sync.RWMutex
+	tx        sync.Mutex
+	name      string
+	documents []bson.D
+	indexes   []memoryIndex
+	sequence  int64
+}
+
+// MemoryIndex is the unique index enforced by the MemoryCollection, the other indexes do not change the results.
+type MemoryIndex struct {
+	Name   string
+	Keys   []string
+	Sparse bool
+	// Partial is the partial filter expression, the documents which do not match it are not indexed
+	Partial interface{}
+}
+
+type memoryIndex struct {
+	MemoryIndex
+	partial bson.D
+}
+
+// NewMemoryCollection returns the empty MemoryCollection of name.
+func NewMemoryCollection(name string) *MemoryCollection {
+	return &MemoryCollection{
+		name:    name,
+		indexes: []memoryIndex{
+			{MemoryIndex: MemoryIndex{Name: "_id_", Keys: []string{"_id"}}},
+		},
+	}
+}
+
+func (c *MemoryCollection) Name() string {
+	return c.name
+}
+
+// CreateUniqueIndex enforces the unique index on the documents, it fails if the stored documents violate the index.
+func (c *MemoryCollection) CreateUniqueIndex(index MemoryIndex) error {
+	partial, err := toDocument(index.Partial)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, existing := range c.indexes {
+		if existing.Name == index.Name {
+			return nil
+		}
+	}
+	c.indexes = append(c.indexes, memoryIndex{MemoryIndex: index, partial: partial})
+	for i, document := range c.documents {
+		if writeError := c.checkUnique(document, i); writeError != nil {
+			c.indexes = c.indexes[:len(c.indexes)-1]
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{*writeError}}
+		}
+	}
+	return nil
+}
+
+// NextSequence increases the sequence of the collection by n, it returns the last reserved value.
+func (c *MemoryCollection) NextSequence(ctx context.Context, n int64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sequence += n
+	return c.sequence, nil
+}
+
+func (c *MemoryCollection) InsertOne(ctx context.Context, document interface{},
+	opts ...*options.InsertOneOptions,
+) (*mongo.InsertOneResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	doc, id, err := newMemoryDocument(document)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if writeError := c.checkUnique(doc, -1); writeError != nil {
+		return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{*writeError}}
+	}
+	c.documents = append(c.documents, doc)
+	return &mongo.InsertOneResult{InsertedID: id}, nil
+}
+
+func (c *MemoryCollection) InsertMany(ctx context.Context, documents []interface{},
+	opts ...*options.InsertManyOptions,
+) (*mongo.InsertManyResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(documents) == 0 {
+		return nil, mongo.ErrEmptySlice
+	}
+	ordered := true
+	for _, opt := range opts {
+		if opt != nil && opt.Ordered != nil {
+			ordered = *opt.Ordered
+		}
+	}
+	docs := make([]bson.D, 0, len(documents))
+	ids := make([]interface{}, 0, len(documents))
+	for _, document := range documents {
+		doc, id, err := newMemoryDocument(document)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+		ids = append(ids, id)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := &mongo.InsertManyResult{}
+	var exception mongo.BulkWriteException
+	for i, doc := range docs {
+		if writeError := c.checkUnique(doc, -1); writeError != nil {
+			writeError.Index = i
+			exception.WriteErrors = append(exception.WriteErrors, mongo.BulkWriteError{
+				WriteError: *writeError,
+				Request:    mongo.NewInsertOneModel().SetDocument(documents[i]),
+			})
+			if ordered {
+				break
+			}
+			continue
+		}
+		c.documents = append(c.documents, doc)
+		result.InsertedIDs = append(result.InsertedIDs, ids[i])
+	}
+	if len(exception.WriteErrors) != 0 {
+		return result, exception
+	}
+	return result, nil
+}
+
+func (c *MemoryCollection) FindOne(ctx context.Context, filter interface{},
+	opts ...*options.FindOneOptions,
+) *mongo.SingleResult {
+	if err := ctx.Err(); err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	query := memoryQuery{limit: 1}
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Sort != nil {
+			query.sort = opt.Sort
+		}
+		if opt.Projection != nil {
+			query.projection = opt.Projection
+		}
+		if opt.Skip != nil {
+			query.skip = *opt.Skip
+		}
+	}
+	documents, err := c.find(filter, query)
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	if len(documents) == 0 {
+		return mongo.NewSingleResultFromDocument(bson.D{}, mongo.ErrNoDocuments, nil)
+	}
+	return mongo.NewSingleResultFromDocument(documents[0], nil, nil)
+}
+
+func (c *MemoryCollection) Find(ctx context.Context, filter interface{},
+	opts ...*options.FindOptions,
+) (*mongo.Cursor, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var query memoryQuery
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Sort != nil {
+			query.sort = opt.Sort
+		}
+		if opt.Projection != nil {
+			query.projection = opt.Projection
+		}
+		if opt.Skip != nil {
+			query.skip = *opt.Skip
+		}
+		if opt.Limit != nil {
+			query.limit = *opt.Limit
+		}
+	}
+	documents, err := c.find(filter, query)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]interface{}, 0, len(documents))
+	for _, document := range documents {
+		results = append(results, document)
+	}
+	return mongo.NewCursorFromDocuments(results, nil, nil)
+}
+
+func (c *MemoryCollection) UpdateOne(ctx context.Context, filter, update interface{},
+	opts ...*options.UpdateOptions,
+) (*mongo.UpdateResult, error) {
+	return c.update(ctx, filter, update, false, opts)
+}
+
+func (c *MemoryCollection) UpdateMany(ctx context.Context, filter, update interface{},
+	opts ...*options.UpdateOptions,
+) (*mongo.UpdateResult, error) {
+	return c.update(ctx, filter, update, true, opts)
+}
+
+func (c *MemoryCollection) DeleteOne(ctx context.Context, filter interface{},
+	opts ...*options.DeleteOptions,
+) (*mongo.DeleteResult, error) {
+	return c.delete(ctx, filter, false)
+}
+
+func (c *MemoryCollection) DeleteMany(ctx context.Context, filter interface{},
+	opts ...*options.DeleteOptions,
+) (*mongo.DeleteResult, error) {
+	return c.delete(ctx, filter, true)
+}
+
+// BulkWrite writes the models in order, unlike the server a failed model rolls back the models written before it,
+// so that the failed bulk write leaves the collection unchanged and the ordered option makes no difference.
+// The *mongo.BulkWriteException of the failure has the index of the failed model.
+func (c *MemoryCollection) BulkWrite(ctx context.Context, models []mongo.WriteModel,
+	opts ...*options.BulkWriteOptions,
+) (*mongo.BulkWriteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(models) == 0 {
+		return nil, mongo.ErrEmptySlice
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	documents := append([]bson.D(nil), c.documents...)
+	result := &mongo.BulkWriteResult{UpsertedIDs: make(map[int64]interface{})}
+	for i, model := range models {
+		err := c.writeModel(model, int64(i), result)
+		if err == nil {
+			continue
+		}
+		c.documents = documents
+		var exception mongo.WriteException
+		if !errors.As(err, &exception) || len(exception.WriteErrors) == 0 {
+			return nil, err
+		}
+		writeError := mongo.BulkWriteError{WriteError: exception.WriteErrors[0], Request: model}
+		writeError.Index = i
+		return &mongo.BulkWriteResult{UpsertedIDs: make(map[int64]interface{})}, mongo.BulkWriteException{
+			WriteErrors: []mongo.BulkWriteError{writeError},
+		}
+	}
+	return result, nil
+}
+
+// Transaction runs fn and rolls back the documents written during fn if it returns an error. The transactions
+// of the collection run one at a time, the writes out of the transactions are not isolated from fn.
+func (c *MemoryCollection) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.tx.Lock()
+	defer c.tx.Unlock()
+
+	c.mu.RLock()
+	documents := append([]bson.D(nil), c.documents...)
+	c.mu.RUnlock()
+	if err := fn(ctx); err != nil {
+		c.mu.Lock()
+		c.documents = documents
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// writeModel writes the model of the bulk write at index and adds its counts to the result.
+func (c *MemoryCollection) writeModel(model mongo.WriteModel, index int64, result *mongo.BulkWriteResult) error {
+	var updated *mongo.UpdateResult
+	var err error
+	switch model := model.(type) {
+	case *mongo.InsertOneModel:
+		document, _, err := newMemoryDocument(model.Document)
+		if err != nil {
+			return err
+		}
+		if writeError := c.checkUnique(document, -1); writeError != nil {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{*writeError}}
+		}
+		c.documents = append(c.documents, document)
+		result.InsertedCount++
+		return nil
+	case *mongo.UpdateOneModel:
+		updated, err = c.updateDocuments(model.Filter, model.Update, false, model.Upsert != nil && *model.Upsert)
+	case *mongo.UpdateManyModel:
+		updated, err = c.updateDocuments(model.Filter, model.Update, true, model.Upsert != nil && *model.Upsert)
+	case *mongo.ReplaceOneModel:
+		updated, err = c.replaceDocument(model.Filter, model.Replacement, model.Upsert != nil && *model.Upsert)
+	case *mongo.DeleteOneModel:
+		deleted, err := c.deleteDocuments(model.Filter, false)
+		if err != nil {
+			return err
+		}
+		result.DeletedCount += deleted.DeletedCount
+		return nil
+	case *mongo.DeleteManyModel:
+		deleted, err := c.deleteDocuments(model.Filter, true)
+		if err != nil {
+			return err
+		}
+		result.DeletedCount += deleted.DeletedCount
+		return nil
+	default:
+		return fmt.Errorf("mongox: the write model %T is not supported by the in-memory collection", model)
+	}
+	if err != nil {
+		return err
+	}
+	result.MatchedCount += updated.MatchedCount
+	result.ModifiedCount += updated.ModifiedCount
+	result.UpsertedCount += updated.UpsertedCount
+	if updated.UpsertedID != nil {
+		result.UpsertedIDs[index] = updated.UpsertedID
+	}
+	return nil
+}
+
+func (c *MemoryCollection) CountDocuments(ctx context.Context, filter interface{},
+	opts ...*options.CountOptions,
+) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	var query memoryQuery
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if opt.Skip != nil {
+			query.skip = *opt.Skip
+		}
+		if opt.Limit != nil {
+			query.limit = *opt.Limit
+		}
+	}
+	documents, err := c.find(filter, query)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(documents)), nil
+}
+
+func (c *MemoryCollection) EstimatedDocumentCount(ctx context.Context,
+	opts ...*options.EstimatedDocumentCountOptions,
+) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return int64(len(c.documents)), nil
+}
+
+type memoryQuery struct {
+	sort       interface{}
+	projection interface{}
+	skip       int64
+	limit      int64
+}
+
+// find returns the documents matching the filter in the natural order unless they are sorted.
+func (c *MemoryCollection) find(filter interface{}, query memoryQuery) ([]bson.D, error) {
+	f, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	sortKeys, err := toDocument(query.sort)
+	if err != nil {
+		return nil, err
+	}
+	projection, err := toDocument(query.projection)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	documents := make([]bson.D, 0, len(c.documents))
+	for _, document := range c.documents {
+		matched, err := matchDocument(document, f)
+		if err != nil {
+			c.mu.RUnlock()
+			return nil, err
+		}
+		if matched {
+			documents = append(documents, document)
+		}
+	}
+	c.mu.RUnlock()
+
+	if len(sortKeys) != 0 {
+		sort.SliceStable(documents, func(i, j int) bool {
+			for _, key := range sortKeys {
+				direction := 1
+				if !positive(key.Value) {
+					direction = -1
+				}
+				path := strings.Split(key.Key, ".")
+				result := compareValues(sortValue(lookup(documents[i], path), direction),
+					sortValue(lookup(documents[j], path), direction))
+				if result != 0 {
+					return result*direction < 0
+				}
+			}
+			return false
+		})
+	}
+
+	if query.skip > 0 {
+		if query.skip >= int64(len(documents)) {
+			return nil, nil
+		}
+		documents = documents[query.skip:]
+	}
+	if query.limit < 0 {
+		query.limit = -query.limit
+	}
+	if query.limit > 0 && query.limit < int64(len(documents)) {
+		documents = documents[:query.limit]
+	}
+	if len(projection) != 0 {
+		for i, document := range documents {
+			documents[i] = project(document, projection)
+		}
+	}
+	return documents, nil
+}
+
+func (c *MemoryCollection) update(ctx context.Context, filter, update interface{}, many bool,
+	opts []*options.UpdateOptions,
+) (*mongo.UpdateResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	upsert := false
+	for _, opt := range opts {
+		if opt != nil && opt.Upsert != nil {
+			upsert = *opt.Upsert
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.updateDocuments(filter, update, many, upsert)
+}
+
+// updateDocuments updates the documents matching the filter, the caller holds the lock.
+func (c *MemoryCollection) updateDocuments(filter, update interface{}, many, upsert bool) (*mongo.UpdateResult, error) {
+	f, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	u, err := toDocument(update)
+	if err != nil {
+		return nil, err
+	}
+	if len(u) == 0 || !strings.HasPrefix(u[0].Key, "$") {
+		return nil, errors.New("update document must contain key beginning with '$'")
+	}
+
+	result := &mongo.UpdateResult{}
+	for i, document := range c.documents {
+		matched, err := matchDocument(document, f)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+		result.MatchedCount++
+		updated, err := applyUpdate(document, u, false)
+		if err != nil {
+			return nil, err
+		}
+		if compareValues(document, updated) != 0 {
+			if writeError := c.checkUnique(updated, i); writeError != nil {
+				return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{*writeError}}
+			}
+			c.documents[i] = updated
+			result.ModifiedCount++
+		}
+		if !many {
+			break
+		}
+	}
+	if result.MatchedCount != 0 || !upsert {
+		return result, nil
+	}
+
+	// the upserted document is built from the equality conditions of the filter and the update
+	document, err := applyUpdate(equalityFields(bson.D{}, f), u, true)
+	if err != nil {
+		return nil, err
+	}
+	document, id := ensureID(document)
+	if writeError := c.checkUnique(document, -1); writeError != nil {
+		return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{*writeError}}
+	}
+	c.documents = append(c.documents, document)
+	result.UpsertedCount, result.UpsertedID = 1, id
+	return result, nil
+}
+
+// replaceDocument replaces the first document matching the filter, the replacement keeps the _id of the
+// replaced document. The upserted replacement takes the _id of the equality condition of the filter.
+// The caller holds the lock.
+func (c *MemoryCollection) replaceDocument(filter, replacement interface{}, upsert bool) (*mongo.UpdateResult, error) {
+	f, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+	r, err := toDocument(replacement)
+	if err != nil {
+		return nil, err
+	}
+	if len(r) != 0 && strings.HasPrefix(r[0].Key, "$") {
+		return nil, errors.New("replacement document cannot contain keys beginning with '$'")
+	}
+
+	result := &mongo.UpdateResult{}
+	for i, document := range c.documents {
+		matched, err := matchDocument(document, f)
+		if err != nil {
+			return nil, err
+		}
+		if !matched {
+			continue
+		}
+		result.MatchedCount++
+		id := lookup(document, []string{"_id"})
+		replaced := r
+		if ids := lookup(r, []string{"_id"}); len(ids) == 0 {
+			replaced = append(bson.D{bson.E{Key: "_id", Value: id[0]}}, r...)
+		} else if compareValues(bson.A(id), bson.A(ids)) != 0 {
+			writeError := mongo.WriteError{
+				Code:    66,
+				Message: "After applying the update, the (immutable) field '_id' was found to have been altered",
+			}
+			return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{writeError}}
+		}
+		if compareValues(document, replaced) != 0 {
+			if writeError := c.checkUnique(replaced, i); writeError != nil {
+				return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{*writeError}}
+			}
+			c.documents[i] = replaced
+			result.ModifiedCount++
+		}
+		return result, nil
+	}
+	if !upsert {
+		return result, nil
+	}
+
+	document := r
+	if ids := lookup(r, []string{"_id"}); len(ids) == 0 {
+		if ids = lookup(equalityFields(bson.D{}, f), []string{"_id"}); len(ids) != 0 {
+			document = append(bson.D{bson.E{Key: "_id", Value: ids[0]}}, r...)
+		}
+	}
+	document, id := ensureID(document)
+	if writeError := c.checkUnique(document, -1); writeError != nil {
+		return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{*writeError}}
+	}
+	c.documents = append(c.documents, document)
+	result.UpsertedCount, result.UpsertedID = 1, id
+	return result, nil
+}
+
+func (c *MemoryCollection) delete(ctx context.Context, filter interface{}, many bool) (*mongo.DeleteResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.deleteDocuments(filter, many)
+}
+
+// deleteDocuments deletes the documents matching the filter, the caller holds the lock.
+func (c *MemoryCollection) deleteDocuments(filter interface{}, many bool) (*mongo.DeleteResult, error) {
+	f, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &mongo.DeleteResult{}
+	remaining := make([]bson.D, 0, len(c.documents))
+	for _, document := range c.documents {
+		if many || result.DeletedCount == 0 {
+			matched, err := matchDocument(document, f)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				result.DeletedCount++
+				continue
+			}
+		}
+		remaining = append(remaining, document)
+	}
+	c.documents = remaining
+	return result, nil
+}
+
+// checkUnique returns the duplicate key error if the document violates the unique indexes,
+// the stored document at index self is the document itself.
+func (c *MemoryCollection) checkUnique(document bson.D, self int) *mongo.WriteError {
+	for _, index := range c.indexes {
+		if !index.covers(document) {
+			continue
+		}
+		key := index.key(document)
+		for i, other := range c.documents {
+			if i == self || !index.covers(other) || compareValues(key, index.key(other)) != 0 {
+				continue
+			}
+			fields := make([]string, 0, len(index.Keys))
+			for j, name := range index.Keys {
+				fields = append(fields, name+": "+formatValue(key[j]))
+			}
+			return &mongo.WriteError{
+				Code: 11000,
+				Message: fmt.Sprintf("E11000 duplicate key error collection: %s index: %s dup key: { %s }",
+					c.name, index.Name, strings.Join(fields, ", ")),
+			}
+		}
+	}
+	return nil
+}
+
+// covers reports whether the document is indexed.
+func (index memoryIndex) covers(document bson.D) bool {
+	if len(index.partial) != 0 {
+		if matched, err := matchDocument(document, index.partial); err != nil || !matched {
+			return false
+		}
+	}
+	if !index.Sparse {
+		return true
+	}
+	for _, name := range index.Keys {
+		if len(lookup(document, strings.Split(name, "."))) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (index memoryIndex) key(document bson.D) bson.A {
+	key := make(bson.A, 0, len(index.Keys))
+	for _, name := range index.Keys {
+		var value interface{}
+		if values := lookup(document, strings.Split(name, ".")); len(values) != 0 {
+			value = values[0]
+		}
+		key = append(key, value)
+	}
+	return key
+}
+
+// toDocument converts the value into bson.D by marshaling it, the nested documents are converted
+// into bson.D and the arrays into bson.A.
+func toDocument(value interface{}) (bson.D, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var document bson.D
+	if err = bson.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// newMemoryDocument converts the inserted value into the stored document, the ObjectID is generated
+// for the document without the _id like the driver.
+func newMemoryDocument(value interface{}) (bson.D, interface{}, error) {
+	document, err := toDocument(value)
+	if err != nil {
+		return nil, nil, err
+	}
+	document, id := ensureID(document)
+	return document, id, nil
+}
+
+func ensureID(document bson.D) (bson.D, interface{}) {
+	for _, e := range document {
+		if e.Key == "_id" {
+			return document, e.Value
+		}
+	}
+	id := primitive.NewObjectID()
+	return append(bson.D{bson.E{Key: "_id", Value: id}}, document...), id
+}
+
+// matchDocument reports whether the document matches the filter.
+func matchDocument(document, filter bson.D) (bool, error) {
+	for _, e := range filter {
+		var matched bool
+		var err error
+		switch {
+		case e.Key == "$and" || e.Key == "$or" || e.Key == "$nor":
+			matched, err = matchLogical(document, e.Key, e.Value)
+		case strings.HasPrefix(e.Key, "$"):
+			return false, fmt.Errorf("mongox: the query operator %s is not supported by the in-memory collection", e.Key)
+		default:
+			matched, err = matchField(document, e.Key, e.Value)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(document bson.D, operator string, value interface{}) (bool, error) {
+	clauses, ok := value.(bson.A)
+	if !ok || len(clauses) == 0 {
+		return false, fmt.Errorf("mongox: %s must be a nonempty array", operator)
+	}
+	for _, clause := range clauses {
+		filter, ok := clause.(bson.D)
+		if !ok {
+			return false, fmt.Errorf("mongox: the elements of %s must be documents", operator)
+		}
+		matched, err := matchDocument(document, filter)
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case operator == "$and" && !matched:
+			return false, nil
+		case operator == "$or" && matched:
+			return true, nil
+		case operator == "$nor" && matched:
+			return false, nil
+		}
+	}
+	return operator != "$or", nil
+}
+
+func matchField(document bson.D, path string, condition interface{}) (bool, error) {
+	values := lookup(document, strings.Split(path, "."))
+	operators, ok := condition.(bson.D)
+	if !ok || len(operators) == 0 || !strings.HasPrefix(operators[0].Key, "$") {
+		return matchEqual(values, condition), nil
+	}
+	for _, operator := range operators {
+		matched, err := matchOperator(values, operator.Key, operator.Value)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchOperator(values []interface{}, operator string, operand interface{}) (bool, error) {
+	switch operator {
+	case "$eq":
+		return matchEqual(values, operand), nil
+	case "$ne":
+		return !matchEqual(values, operand), nil
+	case "$in", "$nin":
+		targets, ok := operand.(bson.A)
+		if !ok {
+			return false, fmt.Errorf("mongox: %s needs an array", operator)
+		}
+		in := false
+		for _, target := range targets {
+			if matchEqual(values, target) {
+				in = true
+				break
+			}
+		}
+		return in == (operator == "$in"), nil
+	case "$lt", "$lte", "$gt", "$gte":
+		for _, value := range flatten(values) {
+			if typeRank(value) != typeRank(operand) {
+				continue
+			}
+			result := compareValues(value, operand)
+			if operator == "$lt" && result < 0 || operator == "$lte" && result <= 0 ||
+				operator == "$gt" && result > 0 || operator == "$gte" && result >= 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$exists":
+		return (len(values) != 0) == positive(operand), nil
+	default:
+		return false, fmt.Errorf("mongox: the query operator %s is not supported by the in-memory collection", operator)
+	}
+}
+
+// matchEqual reports whether one of the values or their elements equals the target,
+// the missing field equals null.
+func matchEqual(values []interface{}, target interface{}) bool {
+	if typeRank(target) == typeRank(nil) && len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if compareValues(value, target) == 0 {
+			return true
+		}
+	}
+	for _, value := range flatten(values) {
+		if compareValues(value, target) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the values of the dotted path in the value, the arrays of the documents on the path are traversed.
+func lookup(value interface{}, path []string) []interface{} {
+	if len(path) == 0 {
+		return []interface{}{value}
+	}
+	switch value := value.(type) {
+	case bson.D:
+		for _, e := range value {
+			if e.Key == path[0] {
+				return lookup(e.Value, path[1:])
+			}
+		}
+	case bson.A:
+		var values []interface{}
+		for _, element := range value {
+			if _, ok := element.(bson.D); ok {
+				values = append(values, lookup(element, path)...)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// flatten replaces the arrays of the values with their elements.
+func flatten(values []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		if array, ok := value.(bson.A); ok {
+			result = append(result, array...)
+			continue
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+// sortValue returns the value which the document is sorted by, the smallest element of the arrays
+// is used for the ascending sort and the largest for the descending sort.
+func sortValue(values []interface{}, direction int) interface{} {
+	values = flatten(values)
+	if len(values) == 0 {
+		return nil
+	}
+	result := values[0]
+	for _, value := range values[1:] {
+		if compareValues(value, result)*direction < 0 {
+			result = value
+		}
+	}
+	return result
+}
+
+// typeRank returns the order of the type of the value in the comparison of the values of different types.
+func typeRank(value interface{}) int {
+	switch value.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int32, int64, float64:
+		return 2
+	case string:
+		return 3
+	case bson.D:
+		return 4
+	case bson.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	default:
+		return 12
+	}
+}
+
+// compareValues compares the values like the MongoDB server, the numbers of different types are compared by value.
+func compareValues(a, b interface{}) int {
+	if rankA, rankB := typeRank(a), typeRank(b); rankA != rankB {
+		return compareInt64(int64(rankA), int64(rankB))
+	}
+	switch a := a.(type) {
+	case int32, int64, float64:
+		return compareNumbers(a, b)
+	case string:
+		return strings.Compare(a, b.(string))
+	case bson.D:
+		b := b.(bson.D)
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if result := strings.Compare(a[i].Key, b[i].Key); result != 0 {
+				return result
+			}
+			if result := compareValues(a[i].Value, b[i].Value); result != 0 {
+				return result
+			}
+		}
+		return compareInt64(int64(len(a)), int64(len(b)))
+	case bson.A:
+		b := b.(bson.A)
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if result := compareValues(a[i], b[i]); result != 0 {
+				return result
+			}
+		}
+		return compareInt64(int64(len(a)), int64(len(b)))
+	case primitive.Binary:
+		b := b.(primitive.Binary)
+		if a.Subtype != b.Subtype {
+			return compareInt64(int64(a.Subtype), int64(b.Subtype))
+		}
+		return bytes.Compare(a.Data, b.Data)
+	case primitive.ObjectID:
+		b := b.(primitive.ObjectID)
+		return bytes.Compare(a[:], b[:])
+	case bool:
+		b := b.(bool)
+		switch {
+		case a == b:
+			return 0
+		case b:
+			return -1
+		default:
+			return 1
+		}
+	case primitive.DateTime:
+		return compareInt64(int64(a), int64(b.(primitive.DateTime)))
+	case primitive.Timestamp:
+		b := b.(primitive.Timestamp)
+		if a.T != b.T {
+			return compareInt64(int64(a.T), int64(b.T))
+		}
+		return compareInt64(int64(a.I), int64(b.I))
+	case primitive.Regex:
+		b := b.(primitive.Regex)
+		if result := strings.Compare(a.Pattern, b.Pattern); result != 0 {
+			return result
+		}
+		return strings.Compare(a.Options, b.Options)
+	case nil, primitive.Null, primitive.Undefined:
+		return 0
+	default:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
+
+func compareNumbers(a, b interface{}) int {
+	intA, okA := a.(int64)
+	if value, ok := a.(int32); ok {
+		intA, okA = int64(value), true
+	}
+	intB, okB := b.(int64)
+	if value, ok := b.(int32); ok {
+		intB, okB = int64(value), true
+	}
+	if okA && okB {
+		return compareInt64(intA, intB)
+	}
+	floatA, floatB := toFloat64(a), toFloat64(b)
+	switch {
+	case floatA < floatB:
+		return -1
+	case floatA > floatB:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func toFloat64(value interface{}) float64 {
+	switch value := value.(type) {
+	case int32:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case float64:
+		return value
+	default:
+		return 0
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// positive reports whether the value of the sort, the projection or $exists is positive.
+func positive(value interface{}) bool {
+	switch value := value.(type) {
+	case bool:
+		return value
+	case int32, int64, float64:
+		return toFloat64(value) > 0
+	case nil, primitive.Null, primitive.Undefined:
+		return false
+	default:
+		return true
+	}
+}
+
+func formatValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(value)
+}
+
+// project returns the fields of the document selected by the projection, the _id is kept unless it is excluded.
+func project(document, projection bson.D) bson.D {
+	include, keepID := false, true
+	paths := make([][]string, 0, len(projection))
+	for _, e := range projection {
+		if e.Key == "_id" {
+			keepID = positive(e.Value)
+			continue
+		}
+		include = positive(e.Value)
+		paths = append(paths, strings.Split(e.Key, "."))
+	}
+	if include {
+		if keepID {
+			paths = append(paths, []string{"_id"})
+		}
+		return includeFields(document, paths)
+	}
+	if !keepID {
+		paths = append(paths, []string{"_id"})
+	}
+	return excludeFields(document, paths)
+}
+
+func includeFields(document bson.D, paths [][]string) bson.D {
+	result := bson.D{}
+	for _, e := range document {
+		whole, nested := splitPaths(e.Key, paths)
+		if whole {
+			result = append(result, e)
+			continue
+		}
+		if len(nested) == 0 {
+			continue
+		}
+		switch value := e.Value.(type) {
+		case bson.D:
+			result = append(result, bson.E{Key: e.Key, Value: includeFields(value, nested)})
+		case bson.A:
+			array := bson.A{}
+			for _, element := range value {
+				if doc, ok := element.(bson.D); ok {
+					array = append(array, includeFields(doc, nested))
+				}
+			}
+			result = append(result, bson.E{Key: e.Key, Value: array})
+		}
+	}
+	return result
+}
+
+func excludeFields(document bson.D, paths [][]string) bson.D {
+	result := bson.D{}
+	for _, e := range document {
+		whole, nested := splitPaths(e.Key, paths)
+		if whole {
+			continue
+		}
+		if len(nested) == 0 {
+			result = append(result, e)
+			continue
+		}
+		switch value := e.Value.(type) {
+		case bson.D:
+			result = append(result, bson.E{Key: e.Key, Value: excludeFields(value, nested)})
+		case bson.A:
+			array := make(bson.A, 0, len(value))
+			for _, element := range value {
+				if doc, ok := element.(bson.D); ok {
+					element = excludeFields(doc, nested)
+				}
+				array = append(array, element)
+			}
+			result = append(result, bson.E{Key: e.Key, Value: array})
+		default:
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// splitPaths reports whether the paths select the whole field of key and returns the paths nested in the field.
+func splitPaths(key string, paths [][]string) (bool, [][]string) {
+	whole := false
+	var nested [][]string
+	for _, path := range paths {
+		if path[0] != key {
+			continue
+		}
+		if len(path) == 1 {
+			whole = true
+		} else {
+			nested = append(nested, path[1:])
+		}
+	}
+	return whole, nested
+}
+
+// applyUpdate returns the document updated by the operators, $setOnInsert is applied only to the upserted document.
+func applyUpdate(document, update bson.D, insert bool) (bson.D, error) {
+	result := cloneDocument(document)
+	for _, e := range update {
+		fields, ok := e.Value.(bson.D)
+		if !ok {
+			return nil, fmt.Errorf("mongox: the value of %s must be a document", e.Key)
+		}
+		switch e.Key {
+		case "$set":
+		case "$setOnInsert":
+			if !insert {
+				continue
+			}
+		case "$unset":
+		default:
+			return nil, fmt.Errorf("mongox: the update operator %s is not supported by the in-memory collection", e.Key)
+		}
+		for _, field := range fields {
+			path := strings.Split(field.Key, ".")
+			if e.Key == "$unset" {
+				result = unsetPath(result, path)
+			} else {
+				result = setPath(result, path, field.Value)
+			}
+		}
+	}
+	if !insert && compareValues(bson.A(lookup(document, []string{"_id"})), bson.A(lookup(result, []string{"_id"}))) != 0 {
+		writeError := mongo.WriteError{
+			Code:    66,
+			Message: "Performing an update on the path '_id' would modify the immutable field '_id'",
+		}
+		return nil, mongo.WriteException{WriteErrors: mongo.WriteErrors{writeError}}
+	}
+	return result, nil
+}
+
+func setPath(document bson.D, path []string, value interface{}) bson.D {
+	for i, e := range document {
+		if e.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			document[i].Value = value
+		} else {
+			nested, _ := e.Value.(bson.D)
+			document[i].Value = setPath(nested, path[1:], value)
+		}
+		return document
+	}
+	if len(path) == 1 {
+		return append(document, bson.E{Key: path[0], Value: value})
+	}
+	return append(document, bson.E{Key: path[0], Value: setPath(bson.D{}, path[1:], value)})
+}
+
+func unsetPath(document bson.D, path []string) bson.D {
+	for i, e := range document {
+		if e.Key != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return append(document[:i:i], document[i+1:]...)
+		}
+		if nested, ok := e.Value.(bson.D); ok {
+			document[i].Value = unsetPath(nested, path[1:])
+		}
+		return document
+	}
+	return document
+}
+
+// equalityFields sets the fields compared by the equality conditions of the filter into the document.
+func equalityFields(document, filter bson.D) bson.D {
+	for _, e := range filter {
+		if e.Key == "$and" {
+			clauses, _ := e.Value.(bson.A)
+			for _, clause := range clauses {
+				if nested, ok := clause.(bson.D); ok {
+					document = equalityFields(document, nested)
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(e.Key, "$") {
+			continue
+		}
+		value := e.Value
+		if operators, ok := value.(bson.D); ok && len(operators) != 0 && strings.HasPrefix(operators[0].Key, "$") {
+			value = nil
+			for _, operator := range operators {
+				if operator.Key == "$eq" {
+					value = operator.Value
+				}
+			}
+			if value == nil {
+				continue
+			}
+		}
+		document = setPath(document, strings.Split(e.Key, "."), value)
+	}
+	return document
+}
+
+func cloneDocument(document bson.D) bson.D {
+	result := make(bson.D, 0, len(document))
+	for _, e := range document {
+		result = append(result, bson.E{Key: e.Key, Value: cloneValue(e.Value)})
+	}
+	return result
+}
+
+func cloneValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case bson.D:
+		return cloneDocument(value)
+	case bson.A:
+		array := make(bson.A, 0, len(value))
+		for _, element := range value {
+			array = append(array, cloneValue(element))
+		}
+		return array
+	default:
+		return value
+	}
+}
+`
+
+// MemoryRender renders the MemoryCollection of the mongox package, which is shared by all the in-memory repositories.
+type MemoryRender struct{}
+
+func (mr *MemoryRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "memoryTemplate", memoryTemplate, mr); err != nil {
+		return err
+	}
+	return nil
+}
+
+var memoryRepositoryTemplate = `
+// {{.StructName}}RepositoryMemory is the in-memory {{.StructName}}Repository for the unit tests, it executes the
+// same queries as the {{.StructName}}RepositoryMongo over the documents stored in memory. The bulk writes and
+// the transactions roll back their writes if they fail. Watch and the transactions writing the other collections
+// are not supported, they return mongox.ErrUnsupported.
+type {{.StructName}}RepositoryMemory struct {
+	collection *mongox.MemoryCollection
+}
+
+// New{{.StructName}}RepositoryMemory returns the empty in-memory {{.StructName}}Repository.
+func New{{.StructName}}RepositoryMemory() {{.StructName}}Repository {
+	return &{{.StructName}}RepositoryMemory{
+		collection: mongox.NewMemoryCollection(CollectionName),
+	}
+}
+`
+
+// MemoryRepositoryRender renders the in-memory repository of the struct.
+type MemoryRepositoryRender struct {
+	StructName string
+}
+
+func (mr *MemoryRepositoryRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "memoryRepositoryTemplate", memoryRepositoryTemplate, mr); err != nil {
+		return err
+	}
+	return nil
+}
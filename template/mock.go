@@ -0,0 +1,181 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import (
+	"bytes"
+
+	"github.com/hertz-contrib/thrift-gen-mongo/code"
+)
+
+var mockRecorderTemplate = `
+// MockCall is a call recorded by the mocks of the repositories.
+type MockCall struct {
+	Method string
+	// Args are the arguments of the call except for the context
+	Args []interface{}
+}
+
+// TestingT is the part of testing.TB used by the assertions of the mocks.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// MockRecorder records the calls of the mock of a repository, it is embedded by the generated mocks.
+type MockRecorder struct {
+	mu    sync.Mutex
+	calls []MockCall
+}
+
+// Record records the call of the method with args.
+func (r *MockRecorder) Record(method string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, MockCall{Method: method, Args: args})
+}
+
+// Calls returns the calls of the method in the order they are made, all the calls are returned if method is empty.
+func (r *MockRecorder) Calls(method string) []MockCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := make([]MockCall, 0, len(r.calls))
+	for _, call := range r.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// CallCount returns the number of the calls of the method.
+func (r *MockRecorder) CallCount(method string) int {
+	return len(r.Calls(method))
+}
+
+// Reset forgets the recorded calls.
+func (r *MockRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
+
+// AssertCalled asserts that the method is called with args at least once, the args are compared by reflect.DeepEqual.
+func (r *MockRecorder) AssertCalled(t TestingT, method string, args ...interface{}) bool {
+	t.Helper()
+	calls := r.Calls(method)
+	for _, call := range calls {
+		if argsEqual(call.Args, args) {
+			return true
+		}
+	}
+	if len(calls) == 0 {
+		t.Errorf("%s is not called", method)
+		return false
+	}
+	recorded := make([]string, 0, len(calls))
+	for _, call := range calls {
+		recorded = append(recorded, formatArgs(call.Args))
+	}
+	t.Errorf("%s is not called with %s, it is called with %s", method, formatArgs(args), strings.Join(recorded, ", "))
+	return false
+}
+
+// AssertNotCalled asserts that the method is not called.
+func (r *MockRecorder) AssertNotCalled(t TestingT, method string) bool {
+	t.Helper()
+	if count := r.CallCount(method); count != 0 {
+		t.Errorf("%s is called %d times", method, count)
+		return false
+	}
+	return true
+}
+
+// AssertCallCount asserts that the method is called count times.
+func (r *MockRecorder) AssertCallCount(t TestingT, method string, count int) bool {
+	t.Helper()
+	if actual := r.CallCount(method); actual != count {
+		t.Errorf("%s is called %d times, expected %d times", method, actual, count)
+		return false
+	}
+	return true
+}
+
+func argsEqual(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !reflect.DeepEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatArgs(args []interface{}) string {
+	formatted := make([]string, 0, len(args))
+	for _, arg := range args {
+		formatted = append(formatted, fmt.Sprintf("%+v", arg))
+	}
+	return "(" + strings.Join(formatted, ", ") + ")"
+}
+`
+
+// MockRecorderRender renders the MockRecorder of the mongox package, which is shared by all the mocks.
+type MockRecorderRender struct{}
+
+func (mr *MockRecorderRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "mockRecorderTemplate", mockRecorderTemplate, mr); err != nil {
+		return err
+	}
+	return nil
+}
+
+var mockTemplate = `
+// {{.StructName}}RepositoryMock is the mock of the {{.StructName}}Repository, it records the calls of the methods and
+// returns the values of the Func fields, the methods whose Func fields are nil return the zero values.
+// The Func fields should be set before the mock is used.
+type {{.StructName}}RepositoryMock struct {
+	mongox.MockRecorder
+
+{{.Fields.GetCode}}
+}
+
+// New{{.StructName}}RepositoryMock returns the {{.StructName}}RepositoryMock whose methods are not stubbed.
+func New{{.StructName}}RepositoryMock() *{{.StructName}}RepositoryMock {
+	return &{{.StructName}}RepositoryMock{}
+}
+
+var _ {{.StructName}}Repository = (*{{.StructName}}RepositoryMock)(nil)
+`
+
+// MockRender renders the mock of the repository interface of the struct.
+type MockRender struct {
+	StructName string
+	Fields     code.StructFields
+}
+
+func (mr *MockRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "mockTemplate", mockTemplate, mr); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var schemaTemplate = `
+// CollectionName is the name of the collection of the {{.StructName}}.
+const CollectionName = {{printf "%q" .CollectionName}}
+{{- if .DatabaseName}}
+
+// DatabaseName is the name of the database of the collection of the {{.StructName}}.
+const DatabaseName = {{printf "%q" .DatabaseName}}
+{{- end}}
+
+// namespaceExistsCode is the code of the error returned by create when the collection exists.
+const namespaceExistsCode = 48
+
+// {{.StructName}}Schema returns the $jsonSchema validator of the collection, it is generated from the fields
+// of the {{.StructName}}, the fields without omitempty are required.
+func {{.StructName}}Schema() bson.M {
+	return {{.Schema}}
+}
+
+// EnsureCollection creates the collection of the {{.StructName}} in db with the $jsonSchema validator,
+// or replaces the validator of the existing collection by collMod.
+func EnsureCollection(ctx context.Context, db *mongo.Database) error {
+	validator := bson.M{"$jsonSchema": {{.StructName}}Schema()}
+	err := db.CreateCollection(ctx, CollectionName, options.CreateCollection().SetValidator(validator))
+	var commandErr mongo.CommandError
+	if !errors.As(err, &commandErr) || commandErr.Code != namespaceExistsCode {
+		return err
+	}
+	return db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: CollectionName},
+		{Key: "validator", Value: validator},
+	}).Err()
+}
+
+{{- if .Intercepted}}
+
+// New{{.StructName}}RepositoryFromDB returns the {{.StructName}}Repository of the collection named CollectionName in db,
+// opts configure the repository as the options of New{{.StructName}}Repository.
+func New{{.StructName}}RepositoryFromDB(db *mongo.Database, opts ...{{.StructName}}RepositoryOption) {{.StructName}}Repository {
+	return New{{.StructName}}Repository(db.Collection(CollectionName), opts...)
+}
+{{- else}}
+
+// {{.StructName}}RepositoryOption configures the {{.StructName}}RepositoryMongo,
+// the repository file generated before the interceptors are supported only has the collection options.
+type {{.StructName}}RepositoryOption func(r *{{.StructName}}RepositoryMongo)
+
+// With{{.StructName}}CollectionOptions configures the collection of the repository such as the codec registry,
+// the read preference and the write concern, the options are applied over the options of the database.
+func With{{.StructName}}CollectionOptions(opts ...*options.CollectionOptions) {{.StructName}}RepositoryOption {
+	return func(r *{{.StructName}}RepositoryMongo) {
+		r.collection = r.collection.Database().Collection(r.collection.Name(), opts...)
+	}
+}
+
+// New{{.StructName}}RepositoryFromDB returns the {{.StructName}}Repository of the collection named CollectionName in db,
+// opts configure the collection before it is passed to New{{.StructName}}Repository.
+func New{{.StructName}}RepositoryFromDB(db *mongo.Database, opts ...{{.StructName}}RepositoryOption) {{.StructName}}Repository {
+	r := &{{.StructName}}RepositoryMongo{collection: db.Collection(CollectionName)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return New{{.StructName}}Repository(r.collection)
+}
+{{- end}}
+{{- if .DatabaseName}}
+
+// New{{.StructName}}RepositoryFromClient returns the {{.StructName}}Repository of the collection named CollectionName
+// in the database named DatabaseName of client.
+func New{{.StructName}}RepositoryFromClient(client *mongo.Client, opts ...{{.StructName}}RepositoryOption) {{.StructName}}Repository {
+	return New{{.StructName}}RepositoryFromDB(client.Database(DatabaseName), opts...)
+}
+{{- end}}
+`
+
+// SchemaRender renders the names and the $jsonSchema validator of the collection, EnsureCollection
+// and the constructors of the repository from the database, it is generated with every repository.
+type SchemaRender struct {
+	StructName     string
+	CollectionName string
+	DatabaseName   string
+	Schema         string
+	Intercepted    bool
+}
+
+func (sr *SchemaRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "schemaTemplate", schemaTemplate, sr); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var tenantTemplate = `
+// ErrNoTenant is returned by the repositories scoped by the tenant if the context carries no tenant
+// and does not bypass the tenant scope.
+var ErrNoTenant = errors.New("mongox: no tenant in the context")
+
+type (
+	tenantKey       struct{}
+	tenantBypassKey struct{}
+)
+
+// WithTenant returns the context which carries the tenant.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// WithoutTenant returns the context which bypasses the tenant scope, the operations of the context
+// which carries no tenant run across all the tenants.
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantBypassKey{}, true)
+}
+
+// TenantExtractor extracts the tenant from the context, it can be replaced to read the tenant carried
+// by the context of other packages, the empty tenant is not a tenant.
+var TenantExtractor = func(ctx context.Context) (string, bool) {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant, tenant != ""
+}
+
+// TenantFromContext returns the tenant of the context extracted by the TenantExtractor.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	return TenantExtractor(ctx)
+}
+
+// TenantBypassed reports whether the context bypasses the tenant scope.
+func TenantBypassed(ctx context.Context) bool {
+	bypassed, _ := ctx.Value(tenantBypassKey{}).(bool)
+	return bypassed
+}
+
+// CheckTenant returns ErrNoTenant if the context carries no tenant and does not bypass the tenant scope.
+func CheckTenant(ctx context.Context) error {
+	if _, ok := TenantFromContext(ctx); ok || TenantBypassed(ctx) {
+		return nil
+	}
+	return ErrNoTenant
+}
+
+// TenantConditions returns the conditions which scope the filter to the tenant of the context by the field key,
+// they are ANDed into the filter. The conditions match all the documents if the context bypasses the tenant
+// scope without a tenant, and match no document if the context carries no tenant without bypassing.
+func TenantConditions(ctx context.Context, key string) []bson.M {
+	condition := bson.M{}
+	if tenant, ok := TenantFromContext(ctx); ok {
+		condition[key] = tenant
+	} else if !TenantBypassed(ctx) {
+		condition[key] = bson.M{"$in": bson.A{}}
+	}
+	return []bson.M{condition}
+}
+
+// RoutedTenantConditions returns the TenantConditions of the repository, there is no condition if the repository
+// is routed to the collection of the tenant, which holds the documents of the tenant only.
+func RoutedTenantConditions(ctx context.Context, key string, routed bool) []bson.M {
+	if routed {
+		return []bson.M{bson.M{}}
+	}
+	return TenantConditions(ctx, key)
+}
+
+// TenantFilter ANDs the TenantConditions into the filter.
+func TenantFilter(ctx context.Context, key string, filter interface{}) interface{} {
+	return bson.M{"$and": bson.A{filter, bson.M{"$and": TenantConditions(ctx, key)}}}
+}
+
+// TenantResolver resolves the collection of the tenant, collection is the collection the repository is created with.
+type TenantResolver func(ctx context.Context, tenant string, collection *mongo.Collection) (*mongo.Collection, error)
+
+// TenantDatabase routes the tenant to the collection of the same name in the database named prefix+tenant.
+func TenantDatabase(prefix string) TenantResolver {
+	return func(ctx context.Context, tenant string, collection *mongo.Collection) (*mongo.Collection, error) {
+		return collection.Database().Client().Database(prefix + tenant).Collection(collection.Name()), nil
+	}
+}
+
+// TenantCollection routes the tenant to the collection named the collection name+separator+tenant in the same database.
+func TenantCollection(separator string) TenantResolver {
+	return func(ctx context.Context, tenant string, collection *mongo.Collection) (*mongo.Collection, error) {
+		return collection.Database().Collection(collection.Name() + separator + tenant), nil
+	}
+}
+
+// ResolveTenant returns the collection of the tenant of the context resolved by the resolver, the collection is
+// returned as it is if the context bypasses the tenant scope without a tenant.
+func ResolveTenant(ctx context.Context, resolver TenantResolver, collection *mongo.Collection) (*mongo.Collection, error) {
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		if TenantBypassed(ctx) {
+			return collection, nil
+		}
+		return nil, ErrNoTenant
+	}
+	return resolver(ctx, tenant, collection)
+}
+`
+
+// TenantRender renders the tenant scope of the mongox package, which is shared by all the repositories.
+type TenantRender struct{}
+
+func (tr *TenantRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "tenantTemplate", tenantTemplate, tr); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var traceTemplate = `
+// SpanInfo describes the span of a repository method.
+type SpanInfo struct {
+	Collection string
+	Repository string
+	Method     string
+	Operation  string
+	// Filter is the shape of the filter document, see FilterShape
+	Filter string
+}
+
+// Tracer starts the spans of the repository methods.
+type Tracer interface {
+	Start(ctx context.Context, info *SpanInfo) (context.Context, Span)
+}
+
+// Span is the span of a repository method, it is ended when the method returns.
+type Span interface {
+	// SetCounts records the documents matched and modified by the update and delete methods
+	SetCounts(matched, modified int64)
+	RecordError(err error)
+	End()
+}
+
+// BulkSpan is the Span which also records the documents inserted, upserted and deleted by the bulk methods,
+// the spans which do not implement it record only the matched and modified documents.
+type BulkSpan interface {
+	Span
+	SetBulkCounts(inserted, upserted, deleted int64)
+}
+
+type spanKey struct{}
+
+// TraceInterceptor returns the Interceptor which starts a span of the tracer for every call of the methods.
+func TraceInterceptor(tracer Tracer) Interceptor {
+	return func(ctx context.Context, invocation *Invocation, next Handler) error {
+		ctx, span := tracer.Start(ctx, &SpanInfo{
+			Collection: invocation.Collection,
+			Repository: invocation.Repository,
+			Method:     invocation.Method,
+			Operation:  invocation.Operation,
+			Filter:     FilterShape(invocation.Filter),
+		})
+		defer span.End()
+
+		if err := next(context.WithValue(ctx, spanKey{}, span)); err != nil {
+			span.RecordError(err)
+			return err
+		}
+		return nil
+	}
+}
+
+// RecordCounts records the matched and modified documents on the span of ctx, it does nothing without the span.
+func RecordCounts(ctx context.Context, matched, modified int64) {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		span.SetCounts(matched, modified)
+	}
+}
+
+// RecordBulkCounts records the result counts of the bulk write on the span of ctx, it does nothing without the span.
+func RecordBulkCounts(ctx context.Context, inserted, matched, modified, deleted, upserted int64) {
+	if span, ok := ctx.Value(spanKey{}).(Span); ok {
+		span.SetCounts(matched, modified)
+		if bulkSpan, ok := span.(BulkSpan); ok {
+			bulkSpan.SetBulkCounts(inserted, upserted, deleted)
+		}
+	}
+}
+
+// FilterShape returns the filter as JSON whose values are replaced by "?", the fields and the operators
+// are kept so that the spans do not carry the data of the documents.
+func FilterShape(filter interface{}) string {
+	if filter == nil {
+		return ""
+	}
+	data, err := json.Marshal(shape(filter))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func shape(value interface{}) interface{} {
+	switch value := value.(type) {
+	case bson.M:
+		return shapeMap(value)
+	case map[string]interface{}:
+		return shapeMap(value)
+	case bson.D:
+		result := make(map[string]interface{}, len(value))
+		for _, e := range value {
+			result[e.Key] = shape(e.Value)
+		}
+		return result
+	case []bson.M:
+		result := make([]interface{}, 0, len(value))
+		for _, v := range value {
+			result = append(result, shape(v))
+		}
+		return result
+	case bson.A:
+		return shapeSlice(value)
+	case []interface{}:
+		return shapeSlice(value)
+	default:
+		return "?"
+	}
+}
+
+func shapeMap(value map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(value))
+	for k, v := range value {
+		result[k] = shape(v)
+	}
+	return result
+}
+
+func shapeSlice(value []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(value))
+	for _, v := range value {
+		result = append(result, shape(v))
+	}
+	return result
+}
+`
+
+// TraceRender renders the Tracer of the mongox package, which is shared by all the repositories.
+type TraceRender struct{}
+
+func (tr *TraceRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "traceTemplate", traceTemplate, tr); err != nil {
+		return err
+	}
+	return nil
+}
+
+var otelTemplate = `
+// instrumentationName is the name of the OpenTelemetry tracer of the repositories.
+const instrumentationName = "{{.MongoxImportPath}}"
+
+// Tracer adapts the OpenTelemetry tracer to mongox.Tracer.
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer returns the Tracer which starts the spans with the tracer of tp,
+// the global TracerProvider is used if tp is nil.
+func NewTracer(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return &Tracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+func (t *Tracer) Start(ctx context.Context, info *mongox.SpanInfo) (context.Context, mongox.Span) {
+	attributes := []attribute.KeyValue{
+		attribute.String("db.system", "mongodb"),
+		attribute.String("db.mongodb.collection", info.Collection),
+		attribute.String("db.operation", info.Operation),
+		attribute.String("db.repository.method", info.Repository+"."+info.Method),
+	}
+	if info.Filter != "" {
+		attributes = append(attributes, attribute.String("db.statement", info.Filter))
+	}
+	ctx, s := t.tracer.Start(ctx, info.Repository+"."+info.Method,
+		trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attributes...))
+	return ctx, &span{span: s}
+}
+
+type span struct {
+	span trace.Span
+}
+
+func (s *span) SetCounts(matched, modified int64) {
+	s.span.SetAttributes(
+		attribute.Int64("db.mongodb.matched_count", matched),
+		attribute.Int64("db.mongodb.modified_count", modified),
+	)
+}
+
+func (s *span) SetBulkCounts(inserted, upserted, deleted int64) {
+	s.span.SetAttributes(
+		attribute.Int64("db.mongodb.inserted_count", inserted),
+		attribute.Int64("db.mongodb.upserted_count", upserted),
+		attribute.Int64("db.mongodb.deleted_count", deleted),
+	)
+}
+
+func (s *span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *span) End() {
+	s.span.End()
+}
+`
+
+// OtelRender renders the OpenTelemetry adapter of the mongox.Tracer.
+type OtelRender struct {
+	MongoxImportPath string
+}
+
+func (or *OtelRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "otelTemplate", otelTemplate, or); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var transactionTemplate = `
+// TransactionAbortError aborts the transaction when the Find One step found no document
+// or the guard of the Find or Count step returned Err, Step is the index of the step starting from 1.
+type TransactionAbortError struct {
+	Step      int
+	Operation string
+	Err       error
+}
+
+func (e *TransactionAbortError) Error() string {
+	return fmt.Sprintf("transaction aborted by step %d %s: %s", e.Step, e.Operation, e.Err.Error())
+}
+
+func (e *TransactionAbortError) Unwrap() error {
+	return e.Err
+}
+
+// readError aborts the transaction with *TransactionAbortError wrapping ErrNotFound if the read step found
+// no document.
+func readError(step int, operation string, err error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &TransactionAbortError{Step: step, Operation: operation, Err: translateError(err)}
+	}
+	return err
+}
+
+// runTransaction runs fn in a transaction of a new session of the client, the transaction is aborted
+// when fn returns an error and committed otherwise, the nil options use the defaults of the client.
+// fn joins the transaction of the mongox.UnitOfWork running ctx instead, the options are ignored then.
+// The attempts are limited by mongox.TransactionMaxAttempts of ctx, the errors of the driver are translated
+// after the transaction since mongox.WithTransaction retries them by the labels.
+func runTransaction(ctx context.Context, client *mongo.Client, sessionOptions *options.SessionOptions,
+	transactionOptions *options.TransactionOptions, fn func(sessionContext mongo.SessionContext) error,
+) error {
+	if mongox.InTransaction(ctx) {
+		return translateError(fn(mongo.NewSessionContext(ctx, mongo.SessionFromContext(ctx))))
+	}
+
+	session, err := client.StartSession(sessionOptions)
+	if err != nil {
+		return translateError(err)
+	}
+	defer session.EndSession(ctx)
+
+	err = mongox.WithTransaction(ctx, session, transactionOptions, mongox.TransactionMaxAttempts(ctx), fn)
+	return translateError(err)
+}
+`
+
+// TransactionRender renders the transaction runner of the repository, it is generated with the repository
+// which has Transaction methods.
+type TransactionRender struct{}
+
+func (tr *TransactionRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "transactionTemplate", transactionTemplate, tr); err != nil {
+		return err
+	}
+	return nil
+}
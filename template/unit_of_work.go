@@ -0,0 +1,190 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var unitOfWorkTemplate = `
+// DefaultMaxAttempts is the default maximum number of attempts of a transaction.
+const DefaultMaxAttempts = {{.MaxAttempts}}
+
+type unitOfWorkKey struct{}
+
+// commitHooks are the hooks of the running attempt of a UnitOfWork, they run after the attempt commits.
+type commitHooks struct {
+	mu    sync.Mutex
+	hooks []func()
+}
+
+type maxAttemptsKey struct{}
+
+// UnitOfWork runs the methods of the generated repositories in one transaction, the methods join the
+// transaction with the context passed to the function of Run, whichever repository they belong to.
+type UnitOfWork struct {
+	client             *mongo.Client
+	sessionOptions     *options.SessionOptions
+	transactionOptions *options.TransactionOptions
+	maxAttempts        int
+}
+
+// NewUnitOfWork returns the UnitOfWork which starts the transactions on the sessions of the client.
+func NewUnitOfWork(client *mongo.Client) *UnitOfWork {
+	return &UnitOfWork{
+		client:      client,
+		maxAttempts: DefaultMaxAttempts,
+	}
+}
+
+// WithSessionOptions returns a copy of the UnitOfWork which starts the sessions with opts.
+func (u *UnitOfWork) WithSessionOptions(opts *options.SessionOptions) *UnitOfWork {
+	unit := *u
+	unit.sessionOptions = opts
+	return &unit
+}
+
+// WithTransactionOptions returns a copy of the UnitOfWork which starts the transactions with opts.
+func (u *UnitOfWork) WithTransactionOptions(opts *options.TransactionOptions) *UnitOfWork {
+	unit := *u
+	unit.transactionOptions = opts
+	return &unit
+}
+
+// WithMaxAttempts returns a copy of the UnitOfWork which makes at most n attempts of the transaction,
+// see WithTransaction for the attempts.
+func (u *UnitOfWork) WithMaxAttempts(n int) *UnitOfWork {
+	unit := *u
+	unit.maxAttempts = n
+	return &unit
+}
+
+// Run runs fn in a transaction, the transaction is aborted when fn returns an error and committed otherwise.
+// fn must pass its ctx to the repository methods and must not keep the results of a failed attempt,
+// Run called inside fn joins the running transaction.
+func (u *UnitOfWork) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if InTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	session, err := u.client.StartSession(u.sessionOptions)
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	var hooks *commitHooks
+	err = WithTransaction(ctx, session, u.transactionOptions, u.maxAttempts, func(sessionContext mongo.SessionContext) error {
+		// the hooks of the failed attempts are dropped
+		hooks = &commitHooks{}
+		return fn(context.WithValue(sessionContext, unitOfWorkKey{}, hooks))
+	})
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks.hooks {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit runs hook after the transaction of the UnitOfWork running ctx commits, hook is not run if the
+// transaction fails. hook is run at once if ctx is not passed by the Run of a UnitOfWork.
+func AfterCommit(ctx context.Context, hook func()) {
+	if hooks, ok := ctx.Value(unitOfWorkKey{}).(*commitHooks); ok && InTransaction(ctx) {
+		hooks.mu.Lock()
+		hooks.hooks = append(hooks.hooks, hook)
+		hooks.mu.Unlock()
+		return
+	}
+	hook()
+}
+
+// ErrMaxAttempts is returned by WithTransaction when the commit of the last attempt failed with the
+// TransientTransactionError label.
+var ErrMaxAttempts = errors.New("mongox: the transaction is not committed in the max attempts")
+
+// WithTransaction runs fn in a transaction of the session by session.WithTransaction, the transaction is aborted
+// when fn returns an error and committed otherwise. fn is run again when the transaction fails with the
+// TransientTransactionError label, at most maxAttempts times, and the driver retries the commit which fails with
+// the UnknownTransactionCommitResult label. The error of the last attempt is returned when the attempts are
+// exhausted, it is ErrMaxAttempts if the commit of the last attempt failed.
+func WithTransaction(ctx context.Context, session mongo.Session, transactionOptions *options.TransactionOptions,
+	maxAttempts int, fn func(sessionContext mongo.SessionContext) error,
+) error {
+	attempts := 0
+	var last error
+	_, err := session.WithTransaction(ctx, func(sessionContext mongo.SessionContext) (interface{}, error) {
+		// the driver retries until its timeout, the first attempt is always made
+		if attempts++; attempts > 1 && attempts > maxAttempts {
+			return nil, &exhaustedError{err: last}
+		}
+		last = fn(sessionContext)
+		return nil, last
+	}, transactionOptions)
+
+	var exhausted *exhaustedError
+	if !errors.As(err, &exhausted) {
+		return err
+	}
+	if exhausted.err == nil {
+		return ErrMaxAttempts
+	}
+	return exhausted.err
+}
+
+// exhaustedError stops the retries of session.WithTransaction, it does not unwrap the error of the last attempt
+// since the driver retries the errors which wrap the TransientTransactionError label.
+type exhaustedError struct {
+	err error
+}
+
+func (e *exhaustedError) Error() string {
+	return "mongox: the attempts of the transaction are exhausted"
+}
+
+// WithTransactionMaxAttempts returns the context whose Transaction methods of the repositories make at most n
+// attempts of the transaction, see WithTransaction for the attempts.
+func WithTransactionMaxAttempts(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxAttemptsKey{}, n)
+}
+
+// TransactionMaxAttempts returns the maximum number of attempts of the transactions of the Transaction methods
+// run with ctx, it is DefaultMaxAttempts if ctx does not set it.
+func TransactionMaxAttempts(ctx context.Context) int {
+	if n, ok := ctx.Value(maxAttemptsKey{}).(int); ok {
+		return n
+	}
+	return DefaultMaxAttempts
+}
+
+// InTransaction reports whether ctx is passed by the Run of a UnitOfWork,
+// the Transaction methods of the repositories join the transaction of ctx instead of starting one.
+func InTransaction(ctx context.Context) bool {
+	return ctx.Value(unitOfWorkKey{}) != nil && mongo.SessionFromContext(ctx) != nil
+}
+`
+
+// UnitOfWorkRender renders the UnitOfWork of the mongox package, which is shared by all the repositories.
+type UnitOfWorkRender struct {
+	MaxAttempts int
+}
+
+func (ur *UnitOfWorkRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "unitOfWorkTemplate", unitOfWorkTemplate, ur); err != nil {
+		return err
+	}
+	return nil
+}
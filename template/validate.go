@@ -0,0 +1,67 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var validationTemplate = `
+// ValidationError is returned by Validate and the repository writes, it lists every field which failed the validation.
+type ValidationError struct {
+	Struct string
+	Fields []ValidationFieldError
+}
+
+// ValidationFieldError describes the Rule of the validation annotations violated by the Field.
+type ValidationFieldError struct {
+	Field   string
+	Rule    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+" "+field.Message)
+	}
+	return fmt.Sprintf("invalid %s: %s", e.Struct, strings.Join(messages, "; "))
+}
+{{if .Patterns}}
+var (
+{{- range .Patterns}}
+	{{.Name}} = regexp.MustCompile({{.Pattern}})
+{{- end}}
+)
+{{end}}`
+
+// PatternVar is the compiled regexp of the pattern annotation, Pattern is a go string literal.
+type PatternVar struct {
+	Name    string
+	Pattern string
+}
+
+// ValidationRender renders the validation error and the patterns of the Validate methods,
+// it is generated in the model package.
+type ValidationRender struct {
+	Patterns []PatternVar
+}
+
+func (vr *ValidationRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "validationTemplate", validationTemplate, vr); err != nil {
+		return err
+	}
+	return nil
+}
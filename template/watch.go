@@ -0,0 +1,101 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package template
+
+import "bytes"
+
+var watchTemplate = `
+// {{.StructName}}ChangeEvent is a change of the collection of the {{.StructName}}, Document is the
+// document after the change, it is nil for the delete events and the documents deleted before the lookup.
+type {{.StructName}}ChangeEvent struct {
+	OperationType string ` + "`bson:\"operationType\"`" + `
+	Document      *{{.ModelPackage}}.{{.StructName}} ` + "`bson:\"fullDocument\"`" + `
+	DocumentKey   bson.Raw ` + "`bson:\"documentKey\"`" + `
+	ClusterTime   primitive.Timestamp ` + "`bson:\"clusterTime\"`" + `
+	// ResumeToken resumes the stream after the event with options.ChangeStream().SetResumeAfter
+	ResumeToken bson.Raw ` + "`bson:\"_id\"`" + `
+}
+
+// {{.StructName}}ChangeStream iterates the change events of the Watch methods, it must be closed after use.
+type {{.StructName}}ChangeStream struct {
+	stream *mongo.ChangeStream
+	event  {{.StructName}}ChangeEvent
+	err    error
+}
+
+// Next blocks until the next event is decoded, it returns false when the stream is closed or fails.
+func (s *{{.StructName}}ChangeStream) Next(ctx context.Context) bool {
+	if s.err != nil || !s.stream.Next(ctx) {
+		return false
+	}
+	return s.decode()
+}
+
+// TryNext decodes the next event if there is one available, it does not block.
+func (s *{{.StructName}}ChangeStream) TryNext(ctx context.Context) bool {
+	if s.err != nil || !s.stream.TryNext(ctx) {
+		return false
+	}
+	return s.decode()
+}
+
+func (s *{{.StructName}}ChangeStream) decode() bool {
+	s.event = {{.StructName}}ChangeEvent{}
+	if err := s.stream.Decode(&s.event); err != nil {
+		s.err = err
+		return false
+	}
+	return true
+}
+
+// Event returns the event decoded by the last Next or TryNext.
+func (s *{{.StructName}}ChangeStream) Event() *{{.StructName}}ChangeEvent {
+	return &s.event
+}
+
+// ResumeToken returns the token to resume the stream after the last event or the last empty batch.
+func (s *{{.StructName}}ChangeStream) ResumeToken() bson.Raw {
+	return s.stream.ResumeToken()
+}
+
+// Err returns the error which stopped the stream.
+func (s *{{.StructName}}ChangeStream) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return translateError(s.stream.Err())
+}
+
+// Close closes the stream.
+func (s *{{.StructName}}ChangeStream) Close(ctx context.Context) error {
+	return translateError(s.stream.Close(ctx))
+}
+`
+
+// WatchRender renders the change stream of the repository, it is generated with the repository
+// which has Watch methods.
+type WatchRender struct {
+	StructName   string
+	ModelPackage string
+}
+
+func (wr *WatchRender) RenderObj(buffer *bytes.Buffer) error {
+	if err := templateRender(buffer, "watchTemplate", watchTemplate, wr); err != nil {
+		return err
+	}
+	return nil
+}